@@ -0,0 +1,700 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/handler"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/jwt"
+	"pos-backoffice/pkg/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	config.AppConfig = &config.Config{
+		JWTSecret:        "router-test-secret",
+		JWTAlgorithm:     "HS256",
+		AccessTokenTTL:   time.Hour,
+		ImpersonationTTL: time.Hour,
+		MFATokenTTL:      time.Minute,
+	}
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// errOffline is what every repository still backed by the database gets:
+// the router tests run without one
+var errOffline = errors.New("no database in router tests")
+
+type offlineConnector struct{}
+
+func (offlineConnector) Connect(context.Context) (driver.Conn, error) { return nil, errOffline }
+func (offlineConnector) Driver() driver.Driver                        { return offlineDriver{} }
+
+type offlineDriver struct{}
+
+func (offlineDriver) Open(string) (driver.Conn, error) { return nil, errOffline }
+
+// revocations is the RevocationChecker of the test server
+type revocations struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+}
+
+func (r *revocations) IsTokenRevoked(tokenID string, sessionID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sessions[sessionID], nil
+}
+
+func (r *revocations) revoke(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[sessionID] = true
+}
+
+// testServer is the full router with products, stores, transactions and
+// users kept in memory. Everything else fails as if the database were down.
+type testServer struct {
+	t           *testing.T
+	router      *gin.Engine
+	users       *repository.MemoryUserRepository
+	revocations *revocations
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	db := sql.OpenDB(offlineConnector{})
+	t.Cleanup(func() { db.Close() })
+
+	memory := repository.NewMemoryStore()
+	userRepo := repository.NewMemoryUserRepository(memory)
+	productRepo := repository.NewMemoryProductRepository(memory)
+	storeRepo := repository.NewMemoryStoreRepository(memory)
+	transactionRepo := repository.NewMemoryTransactionRepository(memory)
+
+	sessionRepo := repository.NewSessionRepository(db)
+	loginRepo := repository.NewLoginRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	oidcRepo := repository.NewOIDCRepository(db)
+	periodRepo := repository.NewPeriodRepository(db)
+
+	signingKeyService := service.NewSigningKeyService(repository.NewSigningKeyRepository(db))
+	authService := service.NewAuthService(userRepo, sessionRepo, loginRepo, roleRepo, mfaRepo)
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), roleRepo)
+	impersonationService := service.NewImpersonationService(userRepo, sessionRepo, roleRepo, repository.NewImpersonationRepository(db))
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db))
+	passwordService := service.NewPasswordService(userRepo, repository.NewPasswordResetRepository(db), sessionRepo, loginRepo, oidcRepo, notify.NewLogNotifier())
+
+	revoked := &revocations{sessions: map[string]bool{}}
+	router := setupRouter(
+		revoked, apiKeyService, impersonationService, idempotencyService,
+		handler.NewAuthHandler(authService),
+		handler.NewMFAHandler(service.NewMFAService(userRepo, mfaRepo, roleRepo)),
+		handler.NewSSOHandler(service.NewSSOService(nil, oidcRepo, userRepo, roleRepo, authService)),
+		handler.NewPasswordHandler(passwordService),
+		handler.NewSigningKeyHandler(signingKeyService),
+		handler.NewUserHandler(service.NewUserService(userRepo, sessionRepo, loginRepo, roleRepo)),
+		handler.NewImpersonationHandler(impersonationService),
+		handler.NewRoleHandler(service.NewRoleService(roleRepo)),
+		handler.NewAPIKeyHandler(apiKeyService),
+		handler.NewProductHandler(service.NewProductService(productRepo)),
+		handler.NewStoreHandler(storeRepo),
+		handler.NewTransactionHandler(transactionRepo, productRepo),
+		handler.NewAuditHandler(service.NewAuditService(repository.NewAuditRepository(db))),
+		handler.NewReportHandler(service.NewReportService(repository.NewReportRepository(db))),
+		handler.NewInventoryHandler(service.NewInventoryService(repository.NewInventoryRepository(db))),
+		handler.NewReconciliationHandler(service.NewReconciliationService(repository.NewReconciliationRepository(db), userRepo)),
+		handler.NewPeriodHandler(service.NewPeriodService(periodRepo)),
+		handler.NewGLExportHandler(service.NewGLExportService(repository.NewGLExportRepository(db), periodRepo)),
+	)
+
+	return &testServer{
+		t:           t,
+		router:      router,
+		users:       userRepo,
+		revocations: revoked,
+	}
+}
+
+// allPermissions is what the seeded ADMIN role holds
+var allPermissions = []string{
+	models.PermProductWrite, models.PermProductPrice, models.PermStockAdjust,
+	models.PermStoreWrite, models.PermStoreAllAccess, models.PermReportViewCost,
+	models.PermUserManage, models.PermUserImpersonate, models.PermRoleManage,
+	models.PermAPIKeyManage, models.PermSigningKeyManage, models.PermAuditView,
+	models.PermInventoryReconcile, models.PermGLExport, models.PermPeriodManage,
+}
+
+// login creates a user and returns an access token carrying the given role,
+// permissions and stores
+func (s *testServer) login(username, role string, permissions []string, storeIDs []int64) (*models.User, string) {
+	s.t.Helper()
+
+	user := &models.User{Username: username, PasswordHash: "-", FullName: strings.ToUpper(username[:1]) + username[1:], Role: role, Status: "ACTIVE"}
+	if err := s.users.Create(user); err != nil {
+		s.t.Fatalf("create user %s: %v", username, err)
+	}
+	if storeIDs == nil {
+		storeIDs = []int64{}
+	}
+	token, _, err := jwt.GenerateToken(user, "session-"+username, permissions, storeIDs)
+	if err != nil {
+		s.t.Fatalf("token for %s: %v", username, err)
+	}
+	return user, token
+}
+
+// do sends a request and returns the recorded response. headers are
+// name, value pairs.
+func (s *testServer) do(method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect fails the test unless the response has the given status, and
+// decodes the response's data into dest when dest is not nil
+func expect(t *testing.T, rec *httptest.ResponseRecorder, status int, dest interface{}) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("status %d, want %d: %s", rec.Code, status, rec.Body.String())
+	}
+	if dest == nil {
+		return
+	}
+
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v: %s", err, rec.Body.String())
+	}
+	if err := json.Unmarshal(body.Data, dest); err != nil {
+		t.Fatalf("decode data: %v: %s", err, body.Data)
+	}
+}
+
+// Access levels of routeTable entries
+const (
+	public  = "public" // no credentials needed
+	anyUser = "user"   // any valid credentials
+)
+
+// routeTable lists every route setupRouter registers with what it takes to
+// reach the handler: public, anyUser or a permission code
+var routeTable = []struct {
+	method, path, access string
+}{
+	{"GET", "/health", public},
+	{"GET", "/.well-known/jwks.json", public},
+
+	{"POST", "/api/auth/login", public},
+	{"POST", "/api/auth/login/mfa", public},
+	{"POST", "/api/auth/login/mfa/enroll", public},
+	{"GET", "/api/auth/oidc/login", public},
+	{"GET", "/api/auth/oidc/callback", public},
+	{"POST", "/api/auth/oidc/exchange", public},
+	{"POST", "/api/auth/refresh", public},
+	{"POST", "/api/auth/forgot-password", public},
+	{"POST", "/api/auth/reset-password", public},
+
+	{"POST", "/api/auth/logout", anyUser},
+	{"POST", "/api/auth/change-password", anyUser},
+	{"GET", "/api/auth/mfa", anyUser},
+	{"POST", "/api/auth/mfa/enroll", anyUser},
+	{"POST", "/api/auth/mfa/verify", anyUser},
+	{"POST", "/api/auth/mfa/recovery-codes", anyUser},
+	{"POST", "/api/auth/mfa/disable", anyUser},
+
+	{"GET", "/api/users/login-history", models.PermUserManage},
+	{"PUT", "/api/users/:id/status", models.PermUserManage},
+	{"PUT", "/api/users/:id/role", models.PermUserManage},
+	{"GET", "/api/users/:id/stores", models.PermUserManage},
+	{"PUT", "/api/users/:id/stores", models.PermUserManage},
+	{"POST", "/api/users/:id/unlock", models.PermUserManage},
+	{"DELETE", "/api/users/:id/mfa", models.PermUserManage},
+	{"GET", "/api/users/impersonation-log", models.PermUserManage},
+	{"POST", "/api/users/:id/impersonate", models.PermUserManage},
+
+	{"GET", "/api/permissions", models.PermRoleManage},
+	{"GET", "/api/roles", models.PermRoleManage},
+	{"GET", "/api/roles/:id", models.PermRoleManage},
+	{"POST", "/api/roles", models.PermRoleManage},
+	{"PUT", "/api/roles/:id", models.PermRoleManage},
+	{"DELETE", "/api/roles/:id", models.PermRoleManage},
+
+	{"GET", "/api/api-keys", models.PermAPIKeyManage},
+	{"POST", "/api/api-keys", models.PermAPIKeyManage},
+	{"DELETE", "/api/api-keys/:id", models.PermAPIKeyManage},
+
+	{"GET", "/api/signing-keys", models.PermSigningKeyManage},
+	{"POST", "/api/signing-keys/rotate", models.PermSigningKeyManage},
+
+	{"GET", "/api/audit", models.PermAuditView},
+
+	{"GET", "/api/products", anyUser},
+	{"GET", "/api/products/:id", anyUser},
+	{"GET", "/api/products/:id/revisions", anyUser},
+	{"POST", "/api/products", models.PermProductWrite},
+	{"PUT", "/api/products/:id", models.PermProductWrite},
+	{"DELETE", "/api/products/:id", models.PermProductWrite},
+	{"POST", "/api/products/:id/revisions/:rev/revert", models.PermProductWrite},
+
+	{"GET", "/api/stores", anyUser},
+	{"GET", "/api/stores/:id", anyUser},
+	{"POST", "/api/stores", models.PermStoreWrite},
+	{"PUT", "/api/stores/:id", models.PermStoreWrite},
+	{"DELETE", "/api/stores/:id", models.PermStoreWrite},
+
+	{"GET", "/api/transactions", anyUser},
+	{"GET", "/api/transactions/product/:product_id", anyUser},
+	{"GET", "/api/transactions/store/:store_id", anyUser},
+	{"POST", "/api/transactions", models.PermStockAdjust},
+
+	{"GET", "/api/reports/movements", anyUser},
+
+	{"GET", "/api/inventory/as-of", anyUser},
+	{"GET", "/api/inventory/reconciliations", models.PermInventoryReconcile},
+	{"GET", "/api/inventory/reconciliations/:id", models.PermInventoryReconcile},
+	{"POST", "/api/inventory/reconciliations", models.PermInventoryReconcile},
+
+	{"GET", "/api/accounting-periods", anyUser},
+	{"GET", "/api/accounting-periods/:period", anyUser},
+	{"POST", "/api/accounting-periods/:period/close", models.PermPeriodManage},
+	{"POST", "/api/accounting-periods/:period/reopen", models.PermPeriodManage},
+
+	{"GET", "/api/gl-exports", models.PermGLExport},
+	{"GET", "/api/gl-exports/:period", models.PermGLExport},
+	{"POST", "/api/gl-exports/:period", models.PermGLExport},
+}
+
+// concretePath fills in a route's parameters
+var concretePath = strings.NewReplacer(
+	":id", "1", ":rev", "1", ":product_id", "1", ":store_id", "1", ":period", "2026-01",
+).Replace
+
+func TestRouteTableCoversRouter(t *testing.T) {
+	s := newTestServer(t)
+
+	registered := map[string]bool{}
+	for _, route := range s.router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	listed := map[string]bool{}
+	for _, route := range routeTable {
+		key := route.method + " " + route.path
+		if listed[key] {
+			t.Errorf("%s is listed twice", key)
+		}
+		listed[key] = true
+		if !registered[key] {
+			t.Errorf("%s is listed but not registered", key)
+		}
+	}
+
+	var missing []string
+	for key := range registered {
+		if !listed[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		t.Errorf("%s is registered but missing from routeTable", key)
+	}
+}
+
+func TestRouteAccess(t *testing.T) {
+	s := newTestServer(t)
+	_, staff := s.login("staff", "STAFF", nil, nil)
+	_, root := s.login("root", "ADMIN", allPermissions, nil)
+
+	for _, route := range routeTable {
+		route := route
+		path := concretePath(route.path)
+
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			if route.access != public {
+				rec := s.do(route.method, path, "", nil)
+				expect(t, rec, http.StatusUnauthorized, nil)
+
+				rec = s.do(route.method, path, "not-a-token", nil)
+				expect(t, rec, http.StatusUnauthorized, nil)
+			}
+
+			rec := s.do(route.method, path, staff, nil)
+			if denied := deniedByGuard(rec); denied != (route.access != public && route.access != anyUser) {
+				t.Fatalf("staff got %d: %s", rec.Code, rec.Body.String())
+			}
+
+			// Every handler answers with JSON, even when its repository is
+			// offline; a recovered panic would leave the body empty
+			rec = s.do(route.method, path, root, nil)
+			if deniedByGuard(rec) {
+				t.Fatalf("admin got %d: %s", rec.Code, rec.Body.String())
+			}
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Fatalf("status %d with a body that is not JSON: %q", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// deniedByGuard reports whether a RequirePermission or RequireRole guard
+// turned the request away, as opposed to the handler answering 403 itself
+func deniedByGuard(rec *httptest.ResponseRecorder) bool {
+	if rec.Code != http.StatusForbidden {
+		return false
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return strings.HasPrefix(body.Message, "Missing permission: ") || body.Message == "Insufficient permissions"
+}
+
+func TestRevokedSessionIsRejected(t *testing.T) {
+	s := newTestServer(t)
+	_, token := s.login("clerk", "STAFF", nil, nil)
+
+	expect(t, s.do("GET", "/api/products", token, nil), http.StatusOK, nil)
+
+	s.revocations.revoke("session-clerk")
+	expect(t, s.do("GET", "/api/products", token, nil), http.StatusUnauthorized, nil)
+}
+
+func TestProductLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	_, root := s.login("root", "ADMIN", allPermissions, nil)
+	_, editor := s.login("editor", "STAFF", []string{models.PermProductWrite}, nil)
+
+	var product models.Product
+	rec := s.do("POST", "/api/products", root, models.CreateProductRequest{
+		SKU: "TEA-001", Name: "Green tea", Price: 4.5, Cost: 2, Stock: 10,
+	})
+	expect(t, rec, http.StatusCreated, &product)
+	if product.ID == 0 || product.Version != 1 || product.Stock != 10 || product.Status != "ACTIVE" {
+		t.Fatalf("created product %+v", product)
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag %q", etag)
+	}
+	path := fmt.Sprintf("/api/products/%d", product.ID)
+
+	expect(t, s.do("POST", "/api/products", root, models.CreateProductRequest{
+		SKU: "TEA-001", Name: "Duplicate", Price: 1, Cost: 1,
+	}), http.StatusBadRequest, nil)
+
+	// The initial stock is in the ledger as an opening balance
+	var ledger models.TransactionListResponse
+	expect(t, s.do("GET", fmt.Sprintf("/api/transactions/product/%d", product.ID), root, nil), http.StatusOK, &ledger)
+	if ledger.Total != 1 || ledger.Transactions[0].TransactionType != "OPENING_BALANCE" || ledger.Transactions[0].Quantity != 10 {
+		t.Fatalf("opening balance ledger %+v", ledger)
+	}
+
+	update := models.UpdateProductRequest{Name: "Sencha", Description: "Loose leaf", Price: 4.5, Cost: 2}
+	expect(t, s.do("PUT", path, root, update), http.StatusPreconditionRequired, nil)
+	expect(t, s.do("PUT", path, root, update, "If-Match", `"7"`), http.StatusPreconditionFailed, nil)
+	expect(t, s.do("PUT", path, root, update, "If-Match", `"1"`), http.StatusOK, &product)
+	if product.Name != "Sencha" || product.Version != 2 {
+		t.Fatalf("updated product %+v", product)
+	}
+
+	// Price changes need product.price
+	priced := update
+	priced.Price = 6
+	expect(t, s.do("PUT", path, editor, priced, "If-Match", `"2"`), http.StatusForbidden, nil)
+	expect(t, s.do("PUT", path, root, priced, "If-Match", `"2"`), http.StatusOK, &product)
+
+	var revisions []models.ProductRevision
+	expect(t, s.do("GET", path+"/revisions", root, nil), http.StatusOK, &revisions)
+	if len(revisions) != 3 || revisions[0].Revision != 3 || revisions[0].Price != 6 || revisions[2].Name != "Green tea" {
+		t.Fatalf("revisions %+v", revisions)
+	}
+	if revisions[0].CreatedByName != "Root" || len(revisions[0].Changes) == 0 {
+		t.Fatalf("latest revision %+v", revisions[0])
+	}
+
+	expect(t, s.do("POST", path+"/revisions/1/revert", root, nil, "If-Match", `"3"`), http.StatusOK, &product)
+	if product.Name != "Green tea" || product.Price != 4.5 || product.Version != 4 {
+		t.Fatalf("reverted product %+v", product)
+	}
+	expect(t, s.do("GET", path+"/revisions", root, nil), http.StatusOK, &revisions)
+	if revisions[0].RevertedFrom == nil || *revisions[0].RevertedFrom != 1 {
+		t.Fatalf("revert revision %+v", revisions[0])
+	}
+
+	expect(t, s.do("DELETE", path, root, nil, "If-Match", `"3"`), http.StatusPreconditionFailed, nil)
+	expect(t, s.do("DELETE", path, root, nil, "If-Match", `"4"`), http.StatusOK, nil)
+	expect(t, s.do("GET", path, root, nil), http.StatusOK, &product)
+	if product.Status != "INACTIVE" {
+		t.Fatalf("deleted product %+v", product)
+	}
+
+	expect(t, s.do("GET", "/api/products/999", root, nil), http.StatusNotFound, nil)
+	expect(t, s.do("GET", "/api/products/abc", root, nil), http.StatusBadRequest, nil)
+}
+
+func TestProductListPagination(t *testing.T) {
+	s := newTestServer(t)
+	_, root := s.login("root", "ADMIN", allPermissions, nil)
+
+	for i := 1; i <= 5; i++ {
+		expect(t, s.do("POST", "/api/products", root, models.CreateProductRequest{
+			SKU: fmt.Sprintf("SKU-%d", i), Name: fmt.Sprintf("Item %d", i), Price: 2, Cost: 1,
+		}), http.StatusCreated, nil)
+	}
+
+	var list models.ProductListResponse
+	expect(t, s.do("GET", "/api/products?page=2&page_size=2", root, nil), http.StatusOK, &list)
+	if list.Total != 5 || list.TotalPages != 3 || len(list.Products) != 2 || list.Products[0].SKU != "SKU-3" {
+		t.Fatalf("offset page %+v", list)
+	}
+
+	expect(t, s.do("GET", "/api/products?search=item%204", root, nil), http.StatusOK, &list)
+	if list.Total != 1 || list.Products[0].SKU != "SKU-4" {
+		t.Fatalf("search %+v", list)
+	}
+
+	var skus []string
+	cursor := ""
+	for {
+		var page models.ProductPage
+		expect(t, s.do("GET", "/api/products?page_size=2&include_total=true&cursor="+cursor, root, nil), http.StatusOK, &page)
+		if page.Total == nil || *page.Total != 5 {
+			t.Fatalf("cursor page total %v", page.Total)
+		}
+		for _, p := range page.Products {
+			skus = append(skus, p.SKU)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if got := strings.Join(skus, ","); got != "SKU-5,SKU-4,SKU-3,SKU-2,SKU-1" {
+		t.Fatalf("cursor pages %s", got)
+	}
+
+	expect(t, s.do("GET", "/api/products?cursor=bogus", root, nil), http.StatusBadRequest, nil)
+}
+
+func TestStoreLifecycleAndScope(t *testing.T) {
+	s := newTestServer(t)
+	_, root := s.login("root", "ADMIN", allPermissions, nil)
+
+	var north, south models.Store
+	expect(t, s.do("POST", "/api/stores", root, models.StoreRequest{Code: "N1", Name: "North"}), http.StatusOK, &north)
+	expect(t, s.do("POST", "/api/stores", root, models.StoreRequest{Code: "S1", Name: "South"}), http.StatusOK, &south)
+	if north.Status != "ACTIVE" || north.Version != 1 {
+		t.Fatalf("created store %+v", north)
+	}
+	expect(t, s.do("POST", "/api/stores", root, models.StoreRequest{Code: "N1", Name: "Again"}), http.StatusInternalServerError, nil)
+
+	_, clerk := s.login("clerk", "STAFF", nil, []int64{south.ID})
+
+	var stores []models.Store
+	expect(t, s.do("GET", "/api/stores", clerk, nil), http.StatusOK, &stores)
+	if len(stores) != 1 || stores[0].ID != south.ID {
+		t.Fatalf("clerk stores %+v", stores)
+	}
+	expect(t, s.do("GET", fmt.Sprintf("/api/stores/%d", north.ID), clerk, nil), http.StatusNotFound, nil)
+
+	expect(t, s.do("GET", "/api/stores?search=nor", root, nil), http.StatusOK, &stores)
+	if len(stores) != 1 || stores[0].Code != "N1" {
+		t.Fatalf("search stores %+v", stores)
+	}
+
+	path := fmt.Sprintf("/api/stores/%d", north.ID)
+	rename := models.StoreRequest{Code: "N1", Name: "North Side", Status: "ACTIVE"}
+	expect(t, s.do("PUT", path, root, rename), http.StatusPreconditionRequired, nil)
+	expect(t, s.do("PUT", path, root, rename, "If-Match", `"1"`), http.StatusOK, &north)
+	if north.Name != "North Side" || north.Version != 2 {
+		t.Fatalf("updated store %+v", north)
+	}
+	expect(t, s.do("PUT", path, root, rename, "If-Match", `"1"`), http.StatusPreconditionFailed, nil)
+
+	expect(t, s.do("DELETE", path, root, nil, "If-Match", `"2"`), http.StatusOK, nil)
+	expect(t, s.do("GET", "/api/stores", root, nil), http.StatusOK, &stores)
+	if len(stores) != 1 || stores[0].ID != south.ID {
+		t.Fatalf("stores after delete %+v", stores)
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	s := newTestServer(t)
+	_, root := s.login("root", "ADMIN", allPermissions, nil)
+
+	var store, other models.Store
+	expect(t, s.do("POST", "/api/stores", root, models.StoreRequest{Code: "A", Name: "Alpha"}), http.StatusOK, &store)
+	expect(t, s.do("POST", "/api/stores", root, models.StoreRequest{Code: "B", Name: "Beta"}), http.StatusOK, &other)
+
+	var product models.Product
+	expect(t, s.do("POST", "/api/products", root, models.CreateProductRequest{
+		SKU: "MUG", Name: "Mug", Price: 8, Cost: 3,
+	}), http.StatusCreated, &product)
+
+	_, clerk := s.login("clerk", "STAFF", []string{models.PermStockAdjust}, []int64{store.ID})
+
+	// A store-scoped clerk can still receive stock into the warehouse
+	increase := models.TransactionRequest{TransactionType: "INCREASE", ProductID: product.ID, Quantity: 10, UnitPrice: 3}
+	expect(t, s.do("POST", "/api/transactions", clerk, increase), http.StatusOK, nil)
+
+	sale := models.TransactionRequest{TransactionType: "DECREASE", ProductID: product.ID, StoreID: &store.ID, Quantity: 4, UnitPrice: 8}
+	var posted models.Transaction
+	expect(t, s.do("POST", "/api/transactions", clerk, sale), http.StatusOK, &posted)
+	if posted.ID == 0 || posted.TotalAmount != 32 || posted.TransactionDate.IsZero() {
+		t.Fatalf("posted transaction %+v", posted)
+	}
+
+	// Validation and scope
+	noStore := sale
+	noStore.StoreID = nil
+	expect(t, s.do("POST", "/api/transactions", clerk, noStore), http.StatusBadRequest, nil)
+	elsewhere := sale
+	elsewhere.StoreID = &other.ID
+	expect(t, s.do("POST", "/api/transactions", clerk, elsewhere), http.StatusForbidden, nil)
+	tooMany := sale
+	tooMany.Quantity = 7
+	expect(t, s.do("POST", "/api/transactions", clerk, tooMany), http.StatusBadRequest, nil)
+
+	expect(t, s.do("GET", fmt.Sprintf("/api/products/%d", product.ID), root, nil), http.StatusOK, &product)
+	if product.Stock != 6 {
+		t.Fatalf("stock %d, want 6", product.Stock)
+	}
+
+	// The clerk's scope hides movements without a store
+	var list models.TransactionListResponse
+	expect(t, s.do("GET", "/api/transactions", clerk, nil), http.StatusOK, &list)
+	if list.Total != 1 || list.Transactions[0].StoreName != "Alpha" || list.Transactions[0].CreatedByName != "Clerk" {
+		t.Fatalf("clerk ledger %+v", list)
+	}
+	expect(t, s.do("GET", "/api/transactions", root, nil), http.StatusOK, &list)
+	if list.Total != 2 || list.Transactions[0].TransactionType != "DECREASE" {
+		t.Fatalf("admin ledger %+v", list)
+	}
+	expect(t, s.do("GET", "/api/transactions?type=increase&sort=amount&order=asc", root, nil), http.StatusOK, &list)
+	if list.Total != 1 || list.Transactions[0].Quantity != 10 {
+		t.Fatalf("filtered ledger %+v", list)
+	}
+	expect(t, s.do("GET", fmt.Sprintf("/api/transactions/store/%d", other.ID), clerk, nil), http.StatusForbidden, nil)
+	expect(t, s.do("GET", fmt.Sprintf("/api/transactions/store/%d", store.ID), clerk, nil), http.StatusOK, &list)
+	if list.Total != 1 {
+		t.Fatalf("store ledger %+v", list)
+	}
+
+	// Cursor pages walk the ledger once, oldest first
+	var ids []int64
+	cursor := ""
+	for {
+		var page models.TransactionPage
+		expect(t, s.do("GET", "/api/transactions?order=asc&limit=1&cursor="+cursor, root, nil), http.StatusOK, &page)
+		for _, tx := range page.Transactions {
+			ids = append(ids, tx.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(ids) != 2 || ids[0] >= ids[1] {
+		t.Fatalf("cursor pages %v", ids)
+	}
+}
+
+func TestConcurrentMovementsKeepStockConsistent(t *testing.T) {
+	s := newTestServer(t)
+	_, root := s.login("root", "ADMIN", allPermissions, nil)
+
+	var store models.Store
+	expect(t, s.do("POST", "/api/stores", root, models.StoreRequest{Code: "C", Name: "Central"}), http.StatusOK, &store)
+	var product models.Product
+	expect(t, s.do("POST", "/api/products", root, models.CreateProductRequest{
+		SKU: "PEN", Name: "Pen", Price: 2, Cost: 1, Stock: 100,
+	}), http.StatusCreated, &product)
+
+	const workers = 50
+	var wg sync.WaitGroup
+	codes := make(chan int, 2*workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			codes <- s.do("POST", "/api/transactions", root, models.TransactionRequest{
+				TransactionType: "INCREASE", ProductID: product.ID, Quantity: 1, UnitPrice: 1,
+			}).Code
+		}()
+		go func() {
+			defer wg.Done()
+			codes <- s.do("POST", "/api/transactions", root, models.TransactionRequest{
+				TransactionType: "DECREASE", ProductID: product.ID, StoreID: &store.ID, Quantity: 2, UnitPrice: 2,
+			}).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("concurrent movement got %d", code)
+		}
+	}
+
+	expect(t, s.do("GET", fmt.Sprintf("/api/products/%d", product.ID), root, nil), http.StatusOK, &product)
+	if want := 100 + workers - 2*workers; product.Stock != want {
+		t.Fatalf("stock %d, want %d", product.Stock, want)
+	}
+
+	var list models.TransactionListResponse
+	expect(t, s.do("GET", fmt.Sprintf("/api/transactions/product/%d?limit=1", product.ID), root, nil), http.StatusOK, &list)
+	if list.Total != 2*workers+1 {
+		t.Fatalf("ledger has %d movements, want %d", list.Total, 2*workers+1)
+	}
+}
@@ -6,18 +6,34 @@ import (
 	"log"
 	"time"
 
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
 	_ "github.com/sijms/go-ora/v2"
 	"pos-backoffice/internal/config"
 )
 
 var DB *sql.DB
 
-// InitDB initializes Oracle database connection
+// InitDB opens the database selected by DB_DRIVER. PostgreSQL and SQLite
+// connections translate the repositories' Oracle SQL (see Dialect). The
+// schema is created and upgraded by MigrateUp.
 func InitDB() error {
 	dsn := config.AppConfig.GetDSN()
-	
+	dialect = Dialect(config.AppConfig.DBDriver)
+
 	var err error
-	DB, err = sql.Open("oracle", dsn)
+	switch dialect {
+	case Postgres:
+		var connector *pq.Connector
+		if connector, err = pq.NewConnector(dsn); err == nil {
+			DB = sql.OpenDB(newConnector(dialect, connector))
+		}
+	case SQLite:
+		DB = sql.OpenDB(newConnector(dialect, dsnConnector{dsn: dsn, driver: &sqlite3.SQLiteDriver{}}))
+	default:
+		dialect = Oracle
+		DB, err = sql.Open("oracle", dsn)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -33,7 +49,7 @@ func InitDB() error {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("✓ Database connection established")
+	log.Printf("✓ Database connection established (%s)", dialect)
 	return nil
 }
 
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL dialect of a storage backend. The repositories are
+// written in Oracle SQL; on the other backends each statement is translated
+// on its way to the driver:
+//
+//   - :1, :2, ... binds are renumbered in order of appearance ($n or ?n),
+//     matching go-ora, which binds positionally
+//   - RETURNING ... INTO :n drops the INTO list; the returned row is written
+//     to the sql.Out arguments
+//   - NVL becomes COALESCE and FROM dual is dropped
+//   - PostgreSQL locks whole rows: FOR UPDATE OF t.col becomes FOR UPDATE OF t
+//   - SQLite has no row locks, so FOR UPDATE is dropped (transactions begin
+//     IMMEDIATE instead), OFFSET/FETCH becomes LIMIT/OFFSET and timestamps
+//     are stored as UTC text with millisecond precision
+//
+// Queries whose meaning differs rather than their spelling, such as MERGE
+// and date bucketing, check Current and supply their own SQL per dialect.
+type Dialect string
+
+const (
+	Oracle   Dialect = "oracle"
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+var dialect = Oracle
+
+// Current returns the dialect of the database opened by InitDB
+func Current() Dialect {
+	return dialect
+}
+
+var (
+	bindPattern       = regexp.MustCompile(`:(\d+)`)
+	nvlPattern        = regexp.MustCompile(`(?i)\bNVL\(`)
+	dualPattern       = regexp.MustCompile(`(?i)\s+FROM\s+dual\b`)
+	returningPattern  = regexp.MustCompile(`(?is)\bRETURNING\s+(.+?)\s+INTO\s+:\d+(?:\s*,\s*:\d+)*`)
+	lockColumnPattern = regexp.MustCompile(`(?i)\bFOR\s+UPDATE\s+OF\s+(\w+)\.\w+`)
+	forUpdatePattern  = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE(?:\s+OF\s+[\w.]+)?`)
+	offsetPattern     = regexp.MustCompile(`(?i)\bOFFSET\s+(\d+)\s+ROWS\s+FETCH\s+NEXT\s+(\d+)\s+ROWS\s+ONLY\b`)
+	fetchFirstPattern = regexp.MustCompile(`(?i)\bFETCH\s+FIRST\s+(\d+)\s+ROWS\s+ONLY\b`)
+	timestampPattern  = regexp.MustCompile(`(?i)\b(?:CURRENT_TIMESTAMP|SYSTIMESTAMP)\b`)
+)
+
+// sqliteNow is CURRENT_TIMESTAMP in the text format SQLite timestamps are
+// stored in, so values written by SQL and by Go compare correctly
+const sqliteNow = `strftime('%Y-%m-%d %H:%M:%f', 'now')`
+
+// Translate rewrites an Oracle statement for d. String literals and quoted
+// identifiers are left alone.
+func (d Dialect) Translate(query string) string {
+	if d == Oracle {
+		return query
+	}
+
+	var b strings.Builder
+	bind := 0
+	for len(query) > 0 {
+		// Split off the code before the next quoted section
+		end := strings.IndexAny(query, `'"`)
+		if end < 0 {
+			end = len(query)
+		}
+		b.WriteString(d.translateCode(query[:end], &bind))
+		query = query[end:]
+		if query == "" {
+			break
+		}
+
+		// Copy the quoted section; '' and "" escape the quote
+		quote := query[0]
+		i := 1
+		for i < len(query) {
+			if query[i] == quote {
+				if i+1 < len(query) && query[i+1] == quote {
+					i += 2
+					continue
+				}
+				i++
+				break
+			}
+			i++
+		}
+		b.WriteString(query[:i])
+		query = query[i:]
+	}
+	return b.String()
+}
+
+// translateCode rewrites a stretch of SQL that contains no quotes. bind
+// counts the placeholders seen so far in the statement.
+func (d Dialect) translateCode(code string, bind *int) string {
+	code = returningPattern.ReplaceAllString(code, "RETURNING $1")
+	code = nvlPattern.ReplaceAllString(code, "COALESCE(")
+	code = dualPattern.ReplaceAllString(code, "")
+
+	switch d {
+	case Postgres:
+		code = lockColumnPattern.ReplaceAllString(code, "FOR UPDATE OF $1")
+	case SQLite:
+		code = forUpdatePattern.ReplaceAllString(code, "")
+		code = offsetPattern.ReplaceAllString(code, "LIMIT $2 OFFSET $1")
+		code = fetchFirstPattern.ReplaceAllString(code, "LIMIT $1")
+	}
+
+	prefix := "$"
+	if d == SQLite {
+		prefix = "?"
+	}
+	code = bindPattern.ReplaceAllStringFunc(code, func(string) string {
+		*bind++
+		return prefix + strconv.Itoa(*bind)
+	})
+
+	// Last, as it introduces quotes of its own
+	if d == SQLite {
+		code = timestampPattern.ReplaceAllString(code, sqliteNow)
+	}
+	return code
+}
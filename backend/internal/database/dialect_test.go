@@ -0,0 +1,163 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+// The queries below are taken from the repositories, which are written in
+// Oracle SQL. Whitespace is compared loosely.
+var translateTests = []struct {
+	name     string
+	query    string
+	postgres string
+	sqlite   string
+}{
+	{
+		name: "insert returning into",
+		query: `INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by)
+			VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9)
+			RETURNING id, version INTO :10, :11`,
+		postgres: `INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING id, version`,
+		sqlite: `INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+			RETURNING id, version`,
+	},
+	{
+		name: "update returning into with timestamp",
+		query: `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = :1 AND revoked_at IS NULL
+			RETURNING revoked_at INTO :2`,
+		postgres: `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL
+			RETURNING revoked_at`,
+		sqlite: `UPDATE api_keys SET revoked_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = ?1 AND revoked_at IS NULL
+			RETURNING revoked_at`,
+	},
+	{
+		name: "update returning several columns",
+		query: `UPDATE oidc_logins SET state_hash = NULL
+			WHERE state_hash = :1 AND expires_at > :2
+			RETURNING id, nonce, code_verifier INTO :3, :4, :5`,
+		postgres: `UPDATE oidc_logins SET state_hash = NULL
+			WHERE state_hash = $1 AND expires_at > $2
+			RETURNING id, nonce, code_verifier`,
+		sqlite: `UPDATE oidc_logins SET state_hash = NULL
+			WHERE state_hash = ?1 AND expires_at > ?2
+			RETURNING id, nonce, code_verifier`,
+	},
+	{
+		name: "nvl in insert select",
+		query: `INSERT INTO product_revisions (product_id, revision, sku, name, description, price, cost, status, changes, reverted_from, created_by)
+			SELECT :1, NVL(MAX(revision), 0) + 1, :2, :3, :4, :5, :6, :7, :8, :9, :10
+			FROM product_revisions
+			WHERE product_id = :11`,
+		postgres: `INSERT INTO product_revisions (product_id, revision, sku, name, description, price, cost, status, changes, reverted_from, created_by)
+			SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+			FROM product_revisions
+			WHERE product_id = $11`,
+		sqlite: `INSERT INTO product_revisions (product_id, revision, sku, name, description, price, cost, status, changes, reverted_from, created_by)
+			SELECT ?1, COALESCE(MAX(revision), 0) + 1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10
+			FROM product_revisions
+			WHERE product_id = ?11`,
+	},
+	{
+		name:     "nvl with a string literal",
+		query:    `SELECT NVL(TO_CHAR(t.store_id), '-') AS report_key, NVL(s.name, 'Warehouse') AS report_label FROM transactions t WHERE t.product_id = :1`,
+		postgres: `SELECT COALESCE(TO_CHAR(t.store_id), '-') AS report_key, COALESCE(s.name, 'Warehouse') AS report_label FROM transactions t WHERE t.product_id = $1`,
+		sqlite:   `SELECT COALESCE(TO_CHAR(t.store_id), '-') AS report_key, COALESCE(s.name, 'Warehouse') AS report_label FROM transactions t WHERE t.product_id = ?1`,
+	},
+	{
+		name: "from dual",
+		query: `MERGE INTO login_throttles t
+			USING (SELECT :1 AS throttle_key FROM dual) s
+			ON (t.throttle_key = s.throttle_key)`,
+		postgres: `MERGE INTO login_throttles t
+			USING (SELECT $1 AS throttle_key) s
+			ON (t.throttle_key = s.throttle_key)`,
+		sqlite: `MERGE INTO login_throttles t
+			USING (SELECT ?1 AS throttle_key) s
+			ON (t.throttle_key = s.throttle_key)`,
+	},
+	{
+		name:     "for update of a column",
+		query:    `SELECT ap.id, ap.status FROM accounting_periods ap WHERE ap.period = :1 FOR UPDATE OF ap.status`,
+		postgres: `SELECT ap.id, ap.status FROM accounting_periods ap WHERE ap.period = $1 FOR UPDATE OF ap`,
+		sqlite:   `SELECT ap.id, ap.status FROM accounting_periods ap WHERE ap.period = ?1`,
+	},
+	{
+		name: "for update",
+		query: `SELECT id, code, name, address, phone, status
+			FROM stores
+			WHERE id = :1
+			FOR UPDATE`,
+		postgres: `SELECT id, code, name, address, phone, status
+			FROM stores
+			WHERE id = $1
+			FOR UPDATE`,
+		sqlite: `SELECT id, code, name, address, phone, status
+			FROM stores
+			WHERE id = ?1`,
+	},
+	{
+		name: "offset fetch",
+		query: `SELECT a.id, a.changes FROM audit_log a
+			WHERE a.entity_type = :1 AND a.created_at >= :2
+			ORDER BY a.created_at DESC, a.id DESC
+			OFFSET 40 ROWS FETCH NEXT 20 ROWS ONLY`,
+		postgres: `SELECT a.id, a.changes FROM audit_log a
+			WHERE a.entity_type = $1 AND a.created_at >= $2
+			ORDER BY a.created_at DESC, a.id DESC
+			OFFSET 40 ROWS FETCH NEXT 20 ROWS ONLY`,
+		sqlite: `SELECT a.id, a.changes FROM audit_log a
+			WHERE a.entity_type = ?1 AND a.created_at >= ?2
+			ORDER BY a.created_at DESC, a.id DESC
+			LIMIT 20 OFFSET 40`,
+	},
+	{
+		name: "fetch first",
+		query: `SELECT r.cost FROM product_revisions r
+			WHERE r.product_id = p.id AND r.created_at <= :1
+			ORDER BY r.revision DESC
+			FETCH FIRST 1 ROWS ONLY`,
+		postgres: `SELECT r.cost FROM product_revisions r
+			WHERE r.product_id = p.id AND r.created_at <= $1
+			ORDER BY r.revision DESC
+			FETCH FIRST 1 ROWS ONLY`,
+		sqlite: `SELECT r.cost FROM product_revisions r
+			WHERE r.product_id = p.id AND r.created_at <= ?1
+			ORDER BY r.revision DESC
+			LIMIT 1`,
+	},
+	{
+		name:     "quoted text is left alone",
+		query:    `SELECT TO_CHAR(TRUNC(t.transaction_date, 'IW'), 'IYYY-"W"IW'), 'it''s :1 FROM dual' FROM transactions t WHERE t.id = :1`,
+		postgres: `SELECT TO_CHAR(TRUNC(t.transaction_date, 'IW'), 'IYYY-"W"IW'), 'it''s :1 FROM dual' FROM transactions t WHERE t.id = $1`,
+		sqlite:   `SELECT TO_CHAR(TRUNC(t.transaction_date, 'IW'), 'IYYY-"W"IW'), 'it''s :1 FROM dual' FROM transactions t WHERE t.id = ?1`,
+	},
+	{
+		name:     "binds are renumbered in order of appearance",
+		query:    `UPDATE api_keys SET last_used_at = :1 WHERE id = :2 AND (last_used_at IS NULL OR last_used_at < :3)`,
+		postgres: `UPDATE api_keys SET last_used_at = $1 WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)`,
+		sqlite:   `UPDATE api_keys SET last_used_at = ?1 WHERE id = ?2 AND (last_used_at IS NULL OR last_used_at < ?3)`,
+	},
+}
+
+func TestTranslate(t *testing.T) {
+	for _, tt := range translateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Oracle.Translate(tt.query); got != tt.query {
+				t.Errorf("Oracle changed the query:\n%s", got)
+			}
+			for _, want := range []struct {
+				dialect Dialect
+				query   string
+			}{{Postgres, tt.postgres}, {SQLite, tt.sqlite}} {
+				got := want.dialect.Translate(tt.query)
+				if strings.Join(strings.Fields(got), " ") != strings.Join(strings.Fields(want.query), " ") {
+					t.Errorf("%s:\n got %s\nwant %s", want.dialect, got, want.query)
+				}
+			}
+		})
+	}
+}
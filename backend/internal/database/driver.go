@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// sqliteTimeLayout is how timestamps are stored in SQLite. Text in a fixed
+// UTC layout sorts and compares in time order, which SQLite needs since it
+// has no timestamp type.
+const sqliteTimeLayout = "2006-01-02 15:04:05.000"
+
+// sqliteTimeText matches timestamps SQLite returns without a declared
+// column type, such as MAX(created_at)
+var sqliteTimeText = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?$`)
+
+// connector hands out connections that translate statements to a dialect
+type connector struct {
+	dialect Dialect
+	base    driver.Connector
+}
+
+func newConnector(d Dialect, base driver.Connector) *connector {
+	return &connector{dialect: d, base: base}
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	base, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok1 := base.(driver.ExecerContext)
+	queryer, ok2 := base.(driver.QueryerContext)
+	beginner, ok3 := base.(driver.ConnBeginTx)
+	if !ok1 || !ok2 || !ok3 {
+		base.Close()
+		return nil, fmt.Errorf("%s driver does not support contexts", c.dialect)
+	}
+	return &conn{Conn: base, dialect: c.dialect, execer: execer, queryer: queryer, beginner: beginner}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// dsnConnector is a driver.Connector for drivers that only implement Open
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// conn translates each statement before passing it to the driver's
+// connection and emulates Oracle's RETURNING ... INTO
+type conn struct {
+	driver.Conn
+	dialect  Dialect
+	execer   driver.ExecerContext
+	queryer  driver.QueryerContext
+	beginner driver.ConnBeginTx
+}
+
+// CheckNamedValue lets sql.Out through to ExecContext and leaves every other
+// argument to the default conversion
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(sql.Out); ok {
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, c.dialect.Translate(query))
+	}
+	return c.Conn.Prepare(c.dialect.Translate(query))
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.beginner.BeginTx(ctx, opts)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	in, outs := c.bindArgs(args)
+	query = c.dialect.Translate(query)
+	if len(outs) == 0 {
+		return c.execer.ExecContext(ctx, query, in)
+	}
+
+	// The statement has a RETURNING clause: run it as a query and copy the
+	// returned row into the sql.Out destinations
+	rows, err := c.query(ctx, query, in)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]driver.Value, len(rows.Columns()))
+	if len(values) != len(outs) {
+		return nil, fmt.Errorf("RETURNING returns %d columns for %d out arguments", len(values), len(outs))
+	}
+
+	var affected int64
+	for {
+		err := rows.Next(values)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			for i, out := range outs {
+				if err := assignOut(out.Dest, values[i]); err != nil {
+					return nil, fmt.Errorf("RETURNING column %d: %w", i+1, err)
+				}
+			}
+		}
+		affected++
+	}
+	return driver.RowsAffected(affected), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	in, _ := c.bindArgs(args)
+	return c.query(ctx, c.dialect.Translate(query), in)
+}
+
+// query runs a translated statement with bound arguments
+func (c *conn) query(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.queryer.QueryContext(ctx, query, args)
+	if err != nil || c.dialect != SQLite {
+		return rows, err
+	}
+	return newSQLiteRows(rows), nil
+}
+
+// bindArgs separates the sql.Out arguments, numbers the rest in order and
+// converts them the way Oracle would store them: an empty string is NULL.
+// SQLite timestamps are bound as UTC text.
+func (c *conn) bindArgs(args []driver.NamedValue) ([]driver.NamedValue, []sql.Out) {
+	in := make([]driver.NamedValue, 0, len(args))
+	var outs []sql.Out
+	for _, arg := range args {
+		switch v := arg.Value.(type) {
+		case sql.Out:
+			outs = append(outs, v)
+			continue
+		case string:
+			if v == "" {
+				arg.Value = nil
+			}
+		case time.Time:
+			if c.dialect == SQLite {
+				arg.Value = v.UTC().Format(sqliteTimeLayout)
+			}
+		}
+		arg.Ordinal = len(in) + 1
+		arg.Name = ""
+		in = append(in, arg)
+	}
+	return in, outs
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// sqliteRows turns timestamp text in columns without a declared type into
+// time.Time, as columns declared TIMESTAMP already are
+type sqliteRows struct {
+	driver.Rows
+	untyped []bool
+}
+
+func newSQLiteRows(rows driver.Rows) *sqliteRows {
+	untyped := make([]bool, len(rows.Columns()))
+	if typed, ok := rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		for i := range untyped {
+			untyped[i] = typed.ColumnTypeDatabaseTypeName(i) == ""
+		}
+	}
+	return &sqliteRows{Rows: rows, untyped: untyped}
+}
+
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		return err
+	}
+	for i, v := range dest {
+		if s, ok := v.(string); ok && r.untyped[i] && sqliteTimeText.MatchString(s) {
+			if t, err := time.Parse("2006-01-02 15:04:05.999999999", s); err == nil {
+				dest[i] = t
+			}
+		}
+	}
+	return nil
+}
+
+// assignOut stores a returned value in an sql.Out destination: a
+// sql.Scanner, a pointer to a basic type or time.Time, or a pointer to such
+// a pointer, which is left nil for NULL
+func assignOut(dest interface{}, src driver.Value) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("destination %T is not a non-nil pointer", dest)
+	}
+	dv = dv.Elem()
+
+	if src == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+	if dv.Kind() == reflect.Pointer {
+		p := reflect.New(dv.Type().Elem())
+		if err := assignOut(p.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(p)
+		return nil
+	}
+
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+	}
+	sv := reflect.ValueOf(src)
+
+	switch dv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch s := src.(type) {
+		case int64:
+			dv.SetInt(s)
+			return nil
+		case float64:
+			dv.SetInt(int64(s))
+			return nil
+		case string:
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return err
+			}
+			dv.SetInt(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch s := src.(type) {
+		case int64:
+			dv.SetFloat(float64(s))
+			return nil
+		case float64:
+			dv.SetFloat(s)
+			return nil
+		case string:
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			dv.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		if s, ok := src.(string); ok {
+			dv.SetString(s)
+			return nil
+		}
+	default:
+		if sv.Type().AssignableTo(dv.Type()) {
+			dv.Set(sv)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot store %T in %T", src, dest)
+}
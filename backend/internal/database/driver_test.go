@@ -0,0 +1,56 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// openSQLite opens a private in-memory SQLite database through the
+// translating connector and makes it the package's database
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(newConnector(SQLite, dsnConnector{dsn: "file::memory:?_foreign_keys=on", driver: &sqlite3.SQLiteDriver{}}))
+	// Each connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	prevDB, prevDialect := DB, dialect
+	DB, dialect = db, SQLite
+	t.Cleanup(func() { DB, dialect = prevDB, prevDialect })
+	return db
+}
+
+func TestSQLiteConn(t *testing.T) {
+	db := openSQLite(t)
+	if _, err := db.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body VARCHAR(50), created_at TIMESTAMP)`); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2026, 3, 1, 9, 30, 15, 250e6, time.FixedZone("ICT", 7*60*60))
+	var id int64
+	var createdAt time.Time
+	_, err := db.Exec(`INSERT INTO notes (body, created_at) VALUES (:1, :2) RETURNING id, created_at INTO :3, :4`,
+		"", at, sql.Out{Dest: &id}, sql.Out{Dest: &createdAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 || !createdAt.Equal(at) {
+		t.Errorf("RETURNING gave id %d and %v, want 1 and %v", id, createdAt, at)
+	}
+
+	// An empty string is stored as NULL, as on Oracle
+	var body sql.NullString
+	var latest time.Time
+	if err := db.QueryRow(`SELECT body, (SELECT MAX(created_at) FROM notes) FROM notes WHERE id = :1`, id).Scan(&body, &latest); err != nil {
+		t.Fatal(err)
+	}
+	if body.Valid {
+		t.Errorf("body = %q, want NULL", body.String)
+	}
+	if !latest.Equal(at) {
+		t.Errorf("MAX(created_at) = %v, want %v", latest, at)
+	}
+}
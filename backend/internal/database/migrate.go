@@ -0,0 +1,418 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the schema migrations of each dialect as
+// migrations/<dialect>/NNNN_name.up.sql and NNNN_name.down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrSchemaBehind is returned by CheckSchema when migrations are pending
+var ErrSchemaBehind = errors.New("database schema is behind")
+
+// ErrLegacySchema is returned by MigrateUp for a database created before
+// migrations existed that is older than migration 0001
+var ErrLegacySchema = errors.New("database schema predates migration 0001")
+
+// legacySchemaMarker is the newest table of 0001_initial_schema. A
+// pre-migration database that has it is adopted as being at version 1.
+const legacySchemaMarker = "gl_exports"
+
+// Migration is a numbered schema change and the scripts that apply and
+// revert it
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationState is a migration and when it was applied, nil if pending.
+// Versions applied by a newer binary have Known set to false.
+type MigrationState struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	Known     bool
+}
+
+var (
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	plsqlBlockPattern    = regexp.MustCompile(`(?i)^(BEGIN|DECLARE|CREATE\s+(OR\s+REPLACE\s+)?(TRIGGER|PROCEDURE|FUNCTION|PACKAGE))\b`)
+)
+
+// migrationTableDDL creates the schema version table, per dialect
+var migrationTableDDL = map[Dialect]string{
+	Oracle: `CREATE TABLE schema_migrations (
+		version NUMBER PRIMARY KEY,
+		name VARCHAR2(100) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+	)`,
+	Postgres: `CREATE TABLE schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		applied_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+	)`,
+	SQLite: `CREATE TABLE schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		applied_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')) NOT NULL
+	)`,
+}
+
+// tableExistsQueries counts the tables named :1, per dialect
+var tableExistsQueries = map[Dialect]string{
+	Oracle:   `SELECT COUNT(*) FROM user_tables WHERE table_name = UPPER(:1)`,
+	Postgres: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = :1`,
+	SQLite:   `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = :1`,
+}
+
+// Migrations returns the migrations embedded for d in version order
+func Migrations(d Dialect) ([]Migration, error) {
+	migrations, err := readMigrations(migrationFiles, "migrations/"+string(d))
+	if err != nil {
+		return nil, fmt.Errorf("migrations for %s: %w", d, err)
+	}
+	return migrations, nil
+}
+
+// readMigrations pairs the up and down scripts in dir of fsys
+func readMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s/%s", dir, entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		script, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(script)
+		} else {
+			m.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in version order and returns
+// the ones it applied.
+//
+// A database created by database/init/setup.sql before migrations existed
+// has the tables but no version table; if it is as new as 0001 it is
+// recorded as being at version 1 instead of having the initial schema
+// applied again, and otherwise ErrLegacySchema is returned.
+func MigrateUp() ([]Migration, error) {
+	migrations, err := Migrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationTable(DB, dialect); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(DB)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := runMigration(DB, dialect, m, m.up, true); err != nil {
+			return done, err
+		}
+		log.Printf("✓ Applied migration %04d_%s", m.Version, m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first, and
+// returns the ones it reverted
+func MigrateDown(steps int) ([]Migration, error) {
+	migrations, err := Migrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := tableExists(DB, dialect, "schema_migrations")
+	if err != nil || !exists {
+		return nil, err
+	}
+	applied, err := appliedMigrations(DB)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	var done []Migration
+	for _, version := range versions {
+		if len(done) == steps {
+			break
+		}
+		m, ok := known[version]
+		if !ok {
+			return done, fmt.Errorf("migration %04d_%s was applied by a newer version of the backend and cannot be reverted by this one", version, applied[version].Name)
+		}
+		if err := runMigration(DB, dialect, m, m.down, false); err != nil {
+			return done, err
+		}
+		log.Printf("✓ Reverted migration %04d_%s", m.Version, m.Name)
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrationStatus lists the embedded migrations with when each was applied,
+// followed by any applied versions this binary does not know
+func MigrationStatus() ([]MigrationState, error) {
+	migrations, err := Migrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]MigrationState)
+	exists, err := tableExists(DB, dialect, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if applied, err = appliedMigrations(DB); err != nil {
+			return nil, err
+		}
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := MigrationState{Version: m.Version, Name: m.Name, Known: true}
+		if a, ok := applied[m.Version]; ok {
+			state.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		states = append(states, state)
+	}
+	unknown := make([]MigrationState, 0, len(applied))
+	for _, a := range applied {
+		unknown = append(unknown, a)
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+	return append(states, unknown...), nil
+}
+
+// CheckSchema returns ErrSchemaBehind if any embedded migration has not
+// been applied. A schema ahead of this binary, as during a rolling deploy,
+// is only logged.
+func CheckSchema() error {
+	states, err := MigrationStatus()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	var pending, unknown []string
+	for _, s := range states {
+		name := fmt.Sprintf("%04d_%s", s.Version, s.Name)
+		switch {
+		case !s.Known:
+			unknown = append(unknown, name)
+		case s.AppliedAt == nil:
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s; run `migrate up`", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	if len(unknown) > 0 {
+		log.Printf("⚠ Database has migrations this binary does not know: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// ensureMigrationTable creates the schema version table if it is missing,
+// recording an existing pre-migration schema as version 1. Only a schema
+// that already has the newest table of 0001 is adopted; an older one would
+// pass CheckSchema and then fail on the tables it lacks.
+func ensureMigrationTable(db *sql.DB, d Dialect) error {
+	exists, err := tableExists(db, d, "schema_migrations")
+	if err != nil || exists {
+		return err
+	}
+	legacy, err := tableExists(db, d, "users")
+	if err != nil {
+		return err
+	}
+	if legacy {
+		current, err := tableExists(db, d, legacySchemaMarker)
+		if err != nil {
+			return err
+		}
+		if !current {
+			return fmt.Errorf("%w: the database has tables from before schema migrations but no %s table; "+
+				"bring it up to 0001_initial_schema by hand, or recreate it, before running `migrate up`", ErrLegacySchema, legacySchemaMarker)
+		}
+	}
+
+	if _, err := db.Exec(migrationTableDDL[d]); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	if legacy {
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (1, 'initial_schema')`); err != nil {
+			return fmt.Errorf("failed to record existing schema: %w", err)
+		}
+		log.Println("✓ Existing schema recorded as migration 0001_initial_schema")
+	}
+	return nil
+}
+
+func tableExists(db *sql.DB, d Dialect, table string) (bool, error) {
+	var count int
+	if err := db.QueryRow(tableExistsQueries[d], table).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	return count > 0, nil
+}
+
+func appliedMigrations(db *sql.DB) (map[int]MigrationState, error) {
+	rows, err := db.Query(`SELECT version, name, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]MigrationState)
+	for rows.Next() {
+		var s MigrationState
+		var at time.Time
+		if err := rows.Scan(&s.Version, &s.Name, &at); err != nil {
+			return nil, err
+		}
+		s.AppliedAt = &at
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
+
+// runMigration runs one direction of a migration and records it in
+// schema_migrations. PostgreSQL and SQLite run the script and the record
+// in one transaction. Oracle commits each DDL statement as it runs, so its
+// scripts are split into statements and a failure leaves the statements
+// before it applied.
+func runMigration(db *sql.DB, d Dialect, m Migration, script string, up bool) error {
+	record := `DELETE FROM schema_migrations WHERE version = :1`
+	args := []interface{}{m.Version}
+	if up {
+		record = `INSERT INTO schema_migrations (version, name) VALUES (:1, :2)`
+		args = append(args, m.Name)
+	}
+
+	if d == Oracle {
+		for i, stmt := range splitStatements(script) {
+			if _, err := db.Exec(stmt); err != nil {
+				return fmt.Errorf("migration %04d_%s failed at statement %d, earlier statements were applied: %w", m.Version, m.Name, i+1, err)
+			}
+		}
+		if _, err := db.Exec(record, args...); err != nil {
+			return fmt.Errorf("failed to record migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	return tx.Commit()
+}
+
+// splitStatements splits an Oracle script into statements, which go-ora
+// runs one at a time. A statement ends with a ';' at the end of a line and
+// is sent without it; a PL/SQL block keeps its semicolons and ends with a
+// line holding only '/'.
+func splitStatements(script string) []string {
+	var statements, lines []string
+	block := false
+	flush := func(trim bool) {
+		stmt := strings.TrimSpace(strings.Join(lines, "\n"))
+		if trim {
+			stmt = strings.TrimSuffix(stmt, ";")
+		}
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		lines, block = nil, false
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "/":
+			flush(false)
+		case len(lines) == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")):
+			// Blank lines and comments between statements
+		default:
+			if len(lines) == 0 {
+				block = plsqlBlockPattern.MatchString(trimmed)
+			}
+			lines = append(lines, line)
+			if !block && strings.HasSuffix(trimmed, ";") {
+				flush(true)
+			}
+		}
+	}
+	flush(!block)
+	return statements
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- ============================================
+-- 0001 INITIAL SCHEMA
+-- ============================================
+
+CREATE TABLE roles (
+    code VARCHAR2(50) PRIMARY KEY,
+    description VARCHAR2(255)
+);
+
+INSERT INTO roles (code, description) VALUES ('ADMIN', 'Full access; all stores');
+-- A comment between statements
+CREATE OR REPLACE TRIGGER trg_roles
+BEFORE INSERT ON roles
+FOR EACH ROW
+BEGIN
+    :NEW.code := UPPER(:NEW.code);
+END;
+/
+
+BEGIN
+    EXECUTE IMMEDIATE 'DROP TABLE legacy';
+EXCEPTION
+    WHEN OTHERS THEN NULL;
+END;
+/
+COMMIT`
+
+	want := []string{
+		"CREATE TABLE roles (\n    code VARCHAR2(50) PRIMARY KEY,\n    description VARCHAR2(255)\n)",
+		"INSERT INTO roles (code, description) VALUES ('ADMIN', 'Full access; all stores')",
+		"CREATE OR REPLACE TRIGGER trg_roles\nBEFORE INSERT ON roles\nFOR EACH ROW\nBEGIN\n    :NEW.code := UPPER(:NEW.code);\nEND;",
+		"BEGIN\n    EXECUTE IMMEDIATE 'DROP TABLE legacy';\nEXCEPTION\n    WHEN OTHERS THEN NULL;\nEND;",
+		"COMMIT",
+	}
+	if got := splitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestReadMigrations(t *testing.T) {
+	file := func(script string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(script)} }
+
+	fsys := fstest.MapFS{
+		"ok/0002_add_notes.up.sql":          file("up 2"),
+		"ok/0002_add_notes.down.sql":        file("down 2"),
+		"ok/0001_initial_schema.up.sql":     file("up 1"),
+		"ok/0001_initial_schema.down.sql":   file("down 1"),
+		"missing/0001_initial.up.sql":       file("up"),
+		"renamed/0001_initial.up.sql":       file("up"),
+		"renamed/0001_first.down.sql":       file("down"),
+		"stray/0001_initial.up.sql":         file("up"),
+		"stray/0001_initial.down.sql":       file("down"),
+		"stray/README.md":                   file("notes"),
+		"unnumbered/initial_schema.up.sql":  file("up"),
+		"unnumbered/initial_schema.dn.sql":  file("down"),
+		"empty_down/0001_initial.up.sql":    file("up"),
+		"empty_down/0001_initial.down.sql":  file(""),
+		"padded/0010_later.up.sql":          file("up 10"),
+		"padded/0010_later.down.sql":        file("down 10"),
+		"padded/0009_earlier.up.sql":        file("up 9"),
+		"padded/0009_earlier.down.sql":      file("down 9"),
+		"nothing/.keep":                     file(""),
+		"nothing/0001_initial.down.sql.bak": file(""),
+	}
+
+	migrations, err := readMigrations(fsys, "ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "initial_schema", up: "up 1", down: "down 1"},
+		{Version: 2, Name: "add_notes", up: "up 2", down: "down 2"},
+	}
+	if !reflect.DeepEqual(migrations, want) {
+		t.Errorf("ok: got %+v, want %+v", migrations, want)
+	}
+
+	migrations, err = readMigrations(fsys, "padded")
+	if err != nil || len(migrations) != 2 || migrations[0].Version != 9 || migrations[1].Version != 10 {
+		t.Errorf("padded: got %+v, %v; want versions 9 then 10", migrations, err)
+	}
+
+	for dir, want := range map[string]string{
+		"missing":    "needs both an up and a down script",
+		"renamed":    "has two names",
+		"stray":      "unexpected migration file stray/README.md",
+		"unnumbered": "unexpected migration file",
+		"empty_down": "needs both an up and a down script",
+		"nothing":    "unexpected migration file",
+		"absent":     "does not exist",
+	} {
+		if _, err := readMigrations(fsys, dir); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %v, want %q", dir, err, want)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	var versions [][]int
+	for _, d := range []Dialect{Oracle, Postgres, SQLite} {
+		migrations, err := Migrations(d)
+		if err != nil {
+			t.Fatalf("%s: %v", d, err)
+		}
+		var v []int
+		for _, m := range migrations {
+			v = append(v, m.Version)
+		}
+		versions = append(versions, v)
+	}
+	if !reflect.DeepEqual(versions[0], versions[1]) || !reflect.DeepEqual(versions[0], versions[2]) {
+		t.Errorf("dialects have different migrations: oracle %v, postgres %v, sqlite %v", versions[0], versions[1], versions[2])
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	db := openSQLite(t)
+	migrations, err := Migrations(SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckSchema(); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("CheckSchema on an empty database = %v, want ErrSchemaBehind", err)
+	}
+
+	applied, err := MigrateUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("MigrateUp applied %d of %d migrations", len(applied), len(migrations))
+	}
+	if err := CheckSchema(); err != nil {
+		t.Fatalf("CheckSchema after MigrateUp: %v", err)
+	}
+	if applied, err := MigrateUp(); err != nil || len(applied) != 0 {
+		t.Fatalf("second MigrateUp applied %d migrations, %v", len(applied), err)
+	}
+
+	states, err := MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range states {
+		if !s.Known || s.AppliedAt == nil {
+			t.Errorf("after MigrateUp %04d_%s is %+v", s.Version, s.Name, s)
+		}
+	}
+
+	// A version applied by a newer binary is listed and tolerated
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (9999, 'from_the_future')`); err != nil {
+		t.Fatal(err)
+	}
+	states, err = MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := states[len(states)-1]; last.Version != 9999 || last.Known {
+		t.Errorf("unknown migration listed as %+v", last)
+	}
+	if err := CheckSchema(); err != nil {
+		t.Errorf("CheckSchema with a newer migration: %v", err)
+	}
+	if _, err := MigrateDown(1); err == nil {
+		t.Error("MigrateDown reverted a migration it does not know")
+	}
+	if _, err := db.Exec(`DELETE FROM schema_migrations WHERE version = 9999`); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := MigrateDown(len(migrations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != len(migrations) || reverted[0].Version != migrations[len(migrations)-1].Version {
+		t.Errorf("MigrateDown reverted %+v", reverted)
+	}
+	for _, table := range []string{"users", "products", legacySchemaMarker} {
+		if exists, err := tableExists(db, SQLite, table); err != nil || exists {
+			t.Errorf("%s still exists after MigrateDown (%v)", table, err)
+		}
+	}
+	states, err = MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range states {
+		if s.AppliedAt != nil {
+			t.Errorf("after MigrateDown %04d_%s is still applied", s.Version, s.Name)
+		}
+	}
+
+	// The schema can be built again after being torn down
+	if _, err := MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp after MigrateDown: %v", err)
+	}
+}
+
+func TestMigrateSQLiteLegacySchema(t *testing.T) {
+	db := openSQLite(t)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Older than 0001: refused, and nothing is recorded
+	if _, err := MigrateUp(); !errors.Is(err, ErrLegacySchema) {
+		t.Fatalf("MigrateUp on an old schema = %v, want ErrLegacySchema", err)
+	}
+	if exists, err := tableExists(db, SQLite, "schema_migrations"); err != nil || exists {
+		t.Fatalf("schema_migrations was created for a refused schema (%v)", err)
+	}
+	if err := CheckSchema(); !errors.Is(err, ErrSchemaBehind) {
+		t.Fatalf("CheckSchema on an old schema = %v, want ErrSchemaBehind", err)
+	}
+
+	// As new as 0001: adopted as version 1
+	if _, err := db.Exec(`CREATE TABLE ` + legacySchemaMarker + ` (id INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := MigrateUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range applied {
+		if m.Version == 1 {
+			t.Error("MigrateUp applied 0001 to an existing schema")
+		}
+	}
+	states, err := MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if states[0].Version != 1 || states[0].AppliedAt == nil {
+		t.Errorf("existing schema recorded as %+v, want version 1 applied", states[0])
+	}
+}
+
+func TestSeedLedgerMatchesStock(t *testing.T) {
+	db := openSQLite(t)
+	if _, err := MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same sum the stock reconciliation takes
+	rows, err := db.Query(`
+		SELECT p.sku, p.stock, NVL(SUM(CASE t.transaction_type WHEN 'DECREASE' THEN -t.quantity ELSE t.quantity END), 0)
+		FROM products p
+		LEFT JOIN transactions t ON t.product_id = p.id
+		GROUP BY p.sku, p.stock
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sku string
+		var stock, ledger int
+		if err := rows.Scan(&sku, &stock, &ledger); err != nil {
+			t.Fatal(err)
+		}
+		if stock != ledger {
+			t.Errorf("%s: seeded stock %d, ledger %d", sku, stock, ledger)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
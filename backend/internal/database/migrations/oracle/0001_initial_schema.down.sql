@@ -0,0 +1,60 @@
+-- ============================================
+-- 0001 INITIAL SCHEMA (Oracle), reverted
+-- ============================================
+
+DROP TABLE gl_export_lines CASCADE CONSTRAINTS PURGE;
+DROP TABLE gl_exports CASCADE CONSTRAINTS PURGE;
+DROP TABLE period_valuations CASCADE CONSTRAINTS PURGE;
+DROP TABLE accounting_periods CASCADE CONSTRAINTS PURGE;
+DROP TABLE stock_reconciliation_items CASCADE CONSTRAINTS PURGE;
+DROP TABLE stock_reconciliations CASCADE CONSTRAINTS PURGE;
+DROP TABLE inventory_snapshots CASCADE CONSTRAINTS PURGE;
+DROP TABLE idempotency_keys CASCADE CONSTRAINTS PURGE;
+DROP TABLE product_revisions CASCADE CONSTRAINTS PURGE;
+DROP TABLE audit_log CASCADE CONSTRAINTS PURGE;
+DROP TABLE impersonation_actions CASCADE CONSTRAINTS PURGE;
+DROP TABLE password_reset_tokens CASCADE CONSTRAINTS PURGE;
+DROP TABLE oidc_logins CASCADE CONSTRAINTS PURGE;
+DROP TABLE user_identities CASCADE CONSTRAINTS PURGE;
+DROP TABLE jwt_signing_keys CASCADE CONSTRAINTS PURGE;
+DROP TABLE user_recovery_codes CASCADE CONSTRAINTS PURGE;
+DROP TABLE user_mfa CASCADE CONSTRAINTS PURGE;
+DROP TABLE api_key_stores CASCADE CONSTRAINTS PURGE;
+DROP TABLE api_key_permissions CASCADE CONSTRAINTS PURGE;
+DROP TABLE api_keys CASCADE CONSTRAINTS PURGE;
+DROP TABLE user_stores CASCADE CONSTRAINTS PURGE;
+DROP TABLE login_history CASCADE CONSTRAINTS PURGE;
+DROP TABLE login_throttles CASCADE CONSTRAINTS PURGE;
+DROP TABLE revoked_tokens CASCADE CONSTRAINTS PURGE;
+DROP TABLE refresh_tokens CASCADE CONSTRAINTS PURGE;
+DROP TABLE auth_sessions CASCADE CONSTRAINTS PURGE;
+DROP TABLE transactions CASCADE CONSTRAINTS PURGE;
+DROP TABLE products CASCADE CONSTRAINTS PURGE;
+DROP TABLE stores CASCADE CONSTRAINTS PURGE;
+DROP TABLE users CASCADE CONSTRAINTS PURGE;
+DROP TABLE role_permissions CASCADE CONSTRAINTS PURGE;
+DROP TABLE permissions CASCADE CONSTRAINTS PURGE;
+DROP TABLE roles CASCADE CONSTRAINTS PURGE;
+
+DROP SEQUENCE user_seq;
+DROP SEQUENCE product_seq;
+DROP SEQUENCE store_seq;
+DROP SEQUENCE transaction_seq;
+DROP SEQUENCE refresh_token_seq;
+DROP SEQUENCE login_history_seq;
+DROP SEQUENCE role_seq;
+DROP SEQUENCE api_key_seq;
+DROP SEQUENCE recovery_code_seq;
+DROP SEQUENCE oidc_login_seq;
+DROP SEQUENCE password_reset_seq;
+DROP SEQUENCE impersonation_action_seq;
+DROP SEQUENCE audit_log_seq;
+DROP SEQUENCE product_revision_seq;
+DROP SEQUENCE idempotency_key_seq;
+DROP SEQUENCE inventory_snapshot_seq;
+DROP SEQUENCE stock_reconciliation_seq;
+DROP SEQUENCE stock_reconciliation_item_seq;
+DROP SEQUENCE accounting_period_seq;
+DROP SEQUENCE period_valuation_seq;
+DROP SEQUENCE gl_export_seq;
+DROP SEQUENCE gl_export_line_seq;
@@ -0,0 +1,522 @@
+-- ============================================
+-- 0001 INITIAL SCHEMA (Oracle)
+-- Tables, sequences and seed data. Statements end with ';';
+-- PL/SQL blocks end with a line holding only '/'.
+-- ============================================
+
+-- Create Sequences
+CREATE SEQUENCE user_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE product_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE store_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE transaction_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE refresh_token_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE login_history_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE role_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE api_key_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE recovery_code_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE oidc_login_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE password_reset_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE impersonation_action_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE audit_log_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE product_revision_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE idempotency_key_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE inventory_snapshot_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE stock_reconciliation_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE stock_reconciliation_item_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE accounting_period_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE period_valuation_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE gl_export_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+CREATE SEQUENCE gl_export_line_seq START WITH 1 INCREMENT BY 1 NOCACHE;
+
+-- Create Tables
+CREATE TABLE roles (
+    id NUMBER DEFAULT role_seq.NEXTVAL PRIMARY KEY,
+    code VARCHAR2(20) UNIQUE NOT NULL,
+    name VARCHAR2(100) NOT NULL,
+    description VARCHAR2(255),
+    is_system NUMBER(1) DEFAULT 0 NOT NULL CHECK (is_system IN (0, 1)),
+    require_mfa NUMBER(1) DEFAULT 0 NOT NULL CHECK (require_mfa IN (0, 1)),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE permissions (
+    code VARCHAR2(50) PRIMARY KEY,
+    description VARCHAR2(255)
+);
+
+CREATE TABLE role_permissions (
+    role_id NUMBER NOT NULL,
+    permission_code VARCHAR2(50) NOT NULL,
+    PRIMARY KEY (role_id, permission_code),
+    FOREIGN KEY (role_id) REFERENCES roles(id),
+    FOREIGN KEY (permission_code) REFERENCES permissions(code)
+);
+
+CREATE TABLE users (
+    id NUMBER DEFAULT user_seq.NEXTVAL PRIMARY KEY,
+    username VARCHAR2(50) UNIQUE NOT NULL,
+    password_hash VARCHAR2(255) NOT NULL,
+    full_name VARCHAR2(100) NOT NULL,
+    email VARCHAR2(255),
+    role VARCHAR2(20) NOT NULL,
+    status VARCHAR2(20) DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'INACTIVE')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (role) REFERENCES roles(code)
+);
+
+CREATE TABLE stores (
+    id NUMBER DEFAULT store_seq.NEXTVAL PRIMARY KEY,
+    code VARCHAR2(20) UNIQUE NOT NULL,
+    name VARCHAR2(100) NOT NULL,
+    address VARCHAR2(255),
+    phone VARCHAR2(20),
+    status VARCHAR2(20) DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'INACTIVE')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by NUMBER,
+    updated_by NUMBER,
+    version NUMBER DEFAULT 1 NOT NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    FOREIGN KEY (updated_by) REFERENCES users(id)
+);
+
+CREATE TABLE products (
+    id NUMBER DEFAULT product_seq.NEXTVAL PRIMARY KEY,
+    sku VARCHAR2(50) UNIQUE NOT NULL,
+    name VARCHAR2(100) NOT NULL,
+    description VARCHAR2(255),
+    price NUMBER(10,2) NOT NULL,
+    cost NUMBER(10,2) NOT NULL,
+    stock NUMBER DEFAULT 0,
+    status VARCHAR2(20) DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'INACTIVE')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by NUMBER,
+    updated_by NUMBER,
+    version NUMBER DEFAULT 1 NOT NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    FOREIGN KEY (updated_by) REFERENCES users(id)
+);
+
+CREATE TABLE transactions (
+    id NUMBER DEFAULT transaction_seq.NEXTVAL PRIMARY KEY,
+    transaction_type VARCHAR2(20) NOT NULL CHECK (transaction_type IN ('INCREASE', 'DECREASE', 'ADJUSTMENT', 'OPENING_BALANCE')),
+    product_id NUMBER NOT NULL,
+    store_id NUMBER,
+    quantity NUMBER NOT NULL,
+    unit_price NUMBER(10,2) NOT NULL,
+    total_amount NUMBER(10,2) NOT NULL,
+    notes VARCHAR2(255),
+    transaction_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by NUMBER NOT NULL,
+    impersonated_by NUMBER,
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id),
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    FOREIGN KEY (impersonated_by) REFERENCES users(id)
+);
+
+-- Keyset pagination walks these in (date, id) order
+CREATE INDEX idx_transactions_date ON transactions(transaction_date, id);
+CREATE INDEX idx_products_created ON products(created_at, id);
+
+CREATE TABLE auth_sessions (
+    id VARCHAR2(64) PRIMARY KEY,
+    user_id NUMBER NOT NULL,
+    ip_address VARCHAR2(64),
+    user_agent VARCHAR2(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_reason VARCHAR2(50),
+    impersonator_id NUMBER,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (impersonator_id) REFERENCES users(id)
+);
+CREATE INDEX idx_auth_sessions_user ON auth_sessions(user_id);
+
+CREATE TABLE refresh_tokens (
+    id NUMBER DEFAULT refresh_token_seq.NEXTVAL PRIMARY KEY,
+    session_id VARCHAR2(64) NOT NULL,
+    user_id NUMBER NOT NULL,
+    token_hash VARCHAR2(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES auth_sessions(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE revoked_tokens (
+    jti VARCHAR2(64) PRIMARY KEY,
+    user_id NUMBER NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE login_throttles (
+    throttle_key VARCHAR2(150) PRIMARY KEY,
+    failed_count NUMBER DEFAULT 0 NOT NULL,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE login_history (
+    id NUMBER DEFAULT login_history_seq.NEXTVAL PRIMARY KEY,
+    username VARCHAR2(100) NOT NULL,
+    user_id NUMBER,
+    success NUMBER(1) NOT NULL CHECK (success IN (0, 1)),
+    failure_reason VARCHAR2(50),
+    ip_address VARCHAR2(64),
+    user_agent VARCHAR2(255),
+    request_id VARCHAR2(64),
+    attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_login_history_username ON login_history(username, attempted_at);
+CREATE INDEX idx_login_history_ip ON login_history(ip_address, attempted_at);
+
+CREATE TABLE user_stores (
+    user_id NUMBER NOT NULL,
+    store_id NUMBER NOT NULL,
+    PRIMARY KEY (user_id, store_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id)
+);
+
+CREATE TABLE api_keys (
+    id NUMBER DEFAULT api_key_seq.NEXTVAL PRIMARY KEY,
+    name VARCHAR2(100) NOT NULL,
+    key_prefix VARCHAR2(20) NOT NULL,
+    key_hash VARCHAR2(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_by NUMBER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE api_key_permissions (
+    api_key_id NUMBER NOT NULL,
+    permission_code VARCHAR2(50) NOT NULL,
+    PRIMARY KEY (api_key_id, permission_code),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id),
+    FOREIGN KEY (permission_code) REFERENCES permissions(code)
+);
+
+CREATE TABLE api_key_stores (
+    api_key_id NUMBER NOT NULL,
+    store_id NUMBER NOT NULL,
+    PRIMARY KEY (api_key_id, store_id),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id)
+);
+
+CREATE TABLE user_mfa (
+    user_id NUMBER PRIMARY KEY,
+    secret VARCHAR2(64) NOT NULL,
+    enabled_at TIMESTAMP,
+    last_used_step NUMBER DEFAULT 0 NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE user_recovery_codes (
+    id NUMBER DEFAULT recovery_code_seq.NEXTVAL PRIMARY KEY,
+    user_id NUMBER NOT NULL,
+    code_hash VARCHAR2(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_recovery_codes_user ON user_recovery_codes(user_id, code_hash);
+
+CREATE TABLE jwt_signing_keys (
+    kid VARCHAR2(64) PRIMARY KEY,
+    algorithm VARCHAR2(10) NOT NULL CHECK (algorithm IN ('RS256', 'ES256')),
+    private_key VARCHAR2(4000) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    retired_at TIMESTAMP
+);
+
+CREATE TABLE user_identities (
+    issuer VARCHAR2(255) NOT NULL,
+    subject VARCHAR2(255) NOT NULL,
+    user_id NUMBER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP,
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
+
+CREATE TABLE oidc_logins (
+    id NUMBER DEFAULT oidc_login_seq.NEXTVAL PRIMARY KEY,
+    state_hash VARCHAR2(64) UNIQUE,
+    nonce VARCHAR2(64) NOT NULL,
+    code_verifier VARCHAR2(128) NOT NULL,
+    handoff_hash VARCHAR2(64) UNIQUE,
+    user_id NUMBER,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE password_reset_tokens (
+    id NUMBER DEFAULT password_reset_seq.NEXTVAL PRIMARY KEY,
+    user_id NUMBER NOT NULL,
+    token_hash VARCHAR2(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    requested_ip VARCHAR2(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_password_reset_user ON password_reset_tokens(user_id);
+
+CREATE TABLE impersonation_actions (
+    id NUMBER DEFAULT impersonation_action_seq.NEXTVAL PRIMARY KEY,
+    session_id VARCHAR2(64) NOT NULL,
+    impersonator_id NUMBER NOT NULL,
+    user_id NUMBER NOT NULL,
+    method VARCHAR2(10) NOT NULL,
+    path VARCHAR2(255) NOT NULL,
+    status_code NUMBER NOT NULL,
+    ip_address VARCHAR2(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES auth_sessions(id),
+    FOREIGN KEY (impersonator_id) REFERENCES users(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_impersonation_session ON impersonation_actions(session_id);
+
+CREATE TABLE audit_log (
+    id NUMBER DEFAULT audit_log_seq.NEXTVAL PRIMARY KEY,
+    actor_id NUMBER,
+    impersonator_id NUMBER,
+    api_key_id NUMBER,
+    entity_type VARCHAR2(30) NOT NULL,
+    entity_id VARCHAR2(64),
+    action VARCHAR2(30) NOT NULL,
+    changes CLOB CHECK (changes IS JSON),
+    request_id VARCHAR2(64),
+    ip_address VARCHAR2(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    FOREIGN KEY (actor_id) REFERENCES users(id),
+    FOREIGN KEY (impersonator_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id, created_at);
+CREATE INDEX idx_audit_log_created ON audit_log(created_at);
+CREATE INDEX idx_audit_log_request ON audit_log(request_id);
+
+-- Entries are never changed or removed
+CREATE OR REPLACE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE ON audit_log
+BEGIN
+    RAISE_APPLICATION_ERROR(-20001, 'audit_log is append-only');
+END;
+/
+
+CREATE TABLE product_revisions (
+    id NUMBER DEFAULT product_revision_seq.NEXTVAL PRIMARY KEY,
+    product_id NUMBER NOT NULL,
+    revision NUMBER NOT NULL,
+    sku VARCHAR2(50) NOT NULL,
+    name VARCHAR2(100) NOT NULL,
+    description VARCHAR2(255),
+    price NUMBER(10,2) NOT NULL,
+    cost NUMBER(10,2) NOT NULL,
+    status VARCHAR2(20) NOT NULL,
+    changes CLOB CHECK (changes IS JSON),
+    reverted_from NUMBER,
+    created_by NUMBER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT uq_product_revision UNIQUE (product_id, revision),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE idempotency_keys (
+    id NUMBER DEFAULT idempotency_key_seq.NEXTVAL PRIMARY KEY,
+    principal VARCHAR2(50) NOT NULL,
+    key_hash VARCHAR2(64) NOT NULL,
+    request_hash VARCHAR2(64) NOT NULL,
+    status_code NUMBER,
+    response_body CLOB,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_idempotency_key UNIQUE (principal, key_hash)
+);
+
+CREATE INDEX idx_idempotency_expires ON idempotency_keys(expires_at);
+
+CREATE TABLE inventory_snapshots (
+    id NUMBER DEFAULT inventory_snapshot_seq.NEXTVAL PRIMARY KEY,
+    snapshot_at TIMESTAMP NOT NULL,
+    product_id NUMBER NOT NULL,
+    quantity NUMBER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_inventory_snapshot UNIQUE (snapshot_at, product_id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE TABLE stock_reconciliations (
+    id NUMBER DEFAULT stock_reconciliation_seq.NEXTVAL PRIMARY KEY,
+    fix NUMBER(1) DEFAULT 0 NOT NULL CHECK (fix IN (0, 1)),
+    products_checked NUMBER DEFAULT 0 NOT NULL,
+    mismatches NUMBER DEFAULT 0 NOT NULL,
+    run_by NUMBER,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    FOREIGN KEY (run_by) REFERENCES users(id)
+);
+
+CREATE TABLE stock_reconciliation_items (
+    id NUMBER DEFAULT stock_reconciliation_item_seq.NEXTVAL PRIMARY KEY,
+    reconciliation_id NUMBER NOT NULL,
+    product_id NUMBER NOT NULL,
+    stock NUMBER NOT NULL,
+    ledger_stock NUMBER NOT NULL,
+    difference NUMBER NOT NULL,
+    adjustment_id NUMBER,
+    FOREIGN KEY (reconciliation_id) REFERENCES stock_reconciliations(id),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (adjustment_id) REFERENCES transactions(id)
+);
+
+CREATE INDEX idx_reconciliation_items_run ON stock_reconciliation_items(reconciliation_id);
+
+CREATE TABLE accounting_periods (
+    id NUMBER DEFAULT accounting_period_seq.NEXTVAL PRIMARY KEY,
+    period VARCHAR2(7) NOT NULL UNIQUE,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR2(20) DEFAULT 'OPEN' NOT NULL CHECK (status IN ('OPEN', 'CLOSED')),
+    stock_value NUMBER(14,2),
+    closed_by NUMBER,
+    closed_at TIMESTAMP,
+    reopened_by NUMBER,
+    reopened_at TIMESTAMP,
+    reopen_reason VARCHAR2(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (closed_by) REFERENCES users(id),
+    FOREIGN KEY (reopened_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_accounting_periods_range ON accounting_periods(period_start, period_end);
+
+CREATE TABLE period_valuations (
+    id NUMBER DEFAULT period_valuation_seq.NEXTVAL PRIMARY KEY,
+    period_id NUMBER NOT NULL,
+    product_id NUMBER NOT NULL,
+    quantity NUMBER NOT NULL,
+    unit_cost NUMBER(10,2) NOT NULL,
+    value NUMBER(14,2) NOT NULL,
+    CONSTRAINT uq_period_valuation UNIQUE (period_id, product_id),
+    FOREIGN KEY (period_id) REFERENCES accounting_periods(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE TABLE gl_exports (
+    id NUMBER DEFAULT gl_export_seq.NEXTVAL PRIMARY KEY,
+    period VARCHAR2(7) NOT NULL UNIQUE,
+    period_closed_at TIMESTAMP NOT NULL,
+    journal_count NUMBER NOT NULL,
+    line_count NUMBER NOT NULL,
+    total_debit NUMBER(14,2) NOT NULL,
+    total_credit NUMBER(14,2) NOT NULL,
+    checksum VARCHAR2(64) NOT NULL,
+    exported_by NUMBER NOT NULL,
+    exported_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (exported_by) REFERENCES users(id)
+);
+
+CREATE TABLE gl_export_lines (
+    id NUMBER DEFAULT gl_export_line_seq.NEXTVAL PRIMARY KEY,
+    export_id NUMBER NOT NULL,
+    line_no NUMBER NOT NULL,
+    journal VARCHAR2(20) NOT NULL,
+    store_id NUMBER,
+    account VARCHAR2(50) NOT NULL,
+    account_role VARCHAR2(30) NOT NULL,
+    description VARCHAR2(200) NOT NULL,
+    debit NUMBER(14,2) DEFAULT 0 NOT NULL,
+    credit NUMBER(14,2) DEFAULT 0 NOT NULL,
+    CONSTRAINT uq_gl_export_line UNIQUE (export_id, line_no),
+    FOREIGN KEY (export_id) REFERENCES gl_exports(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id)
+);
+
+-- Seed Data
+
+-- Roles and permissions
+INSERT INTO permissions (code, description) VALUES ('product.write', 'Create, update and delete products');
+INSERT INTO permissions (code, description) VALUES ('product.price', 'Change product price and cost');
+INSERT INTO permissions (code, description) VALUES ('stock.adjust', 'Post stock movements');
+INSERT INTO permissions (code, description) VALUES ('store.write', 'Create, update and delete stores');
+INSERT INTO permissions (code, description) VALUES ('report.view_cost', 'See cost and margin figures in reports');
+INSERT INTO permissions (code, description) VALUES ('user.manage', 'Manage users, lockouts and login history');
+INSERT INTO permissions (code, description) VALUES ('role.manage', 'Manage roles and their permissions');
+INSERT INTO permissions (code, description) VALUES ('api_key.manage', 'Issue and revoke API keys');
+INSERT INTO permissions (code, description) VALUES ('signing_key.manage', 'Rotate token signing keys');
+INSERT INTO permissions (code, description) VALUES ('user.impersonate', 'Sign in as another user to see what they see');
+INSERT INTO permissions (code, description) VALUES ('audit.view', 'View the audit log');
+INSERT INTO permissions (code, description) VALUES ('store.all_access', 'See and post against every store, not just assigned ones');
+INSERT INTO permissions (code, description) VALUES ('inventory.reconcile', 'Reconcile product stock against the transaction ledger');
+INSERT INTO permissions (code, description) VALUES ('gl.export', 'Export closed periods as general-ledger journals');
+INSERT INTO permissions (code, description) VALUES ('period.manage', 'Close and reopen accounting periods');
+
+INSERT INTO roles (code, name, description, is_system) VALUES ('ADMIN', 'Administrator', 'Full access', 1);
+INSERT INTO roles (code, name, description, is_system) VALUES ('STAFF', 'Staff', 'Day-to-day stock movements', 1);
+INSERT INTO roles (code, name, description, is_system) VALUES ('MANAGER', 'Store Manager', 'Adjust stock and maintain products, but not prices', 0);
+
+INSERT INTO role_permissions (role_id, permission_code) SELECT r.id, p.code FROM roles r CROSS JOIN permissions p WHERE r.code = 'ADMIN';
+INSERT INTO role_permissions (role_id, permission_code) SELECT id, 'stock.adjust' FROM roles WHERE code = 'STAFF';
+INSERT INTO role_permissions (role_id, permission_code) SELECT id, 'product.write' FROM roles WHERE code = 'MANAGER';
+INSERT INTO role_permissions (role_id, permission_code) SELECT id, 'stock.adjust' FROM roles WHERE code = 'MANAGER';
+
+-- Users
+INSERT INTO users (username, password_hash, full_name, email, role, status) VALUES ('admin', 'admin123', 'System Administrator', 'admin@example.com', 'ADMIN', 'ACTIVE');
+INSERT INTO users (username, password_hash, full_name, email, role, status) VALUES ('staff', 'staff123', 'Staff User', 'staff@example.com', 'STAFF', 'ACTIVE');
+
+-- Stores
+INSERT INTO stores (code, name, address, phone, status, created_by, updated_by) VALUES ('MB001', 'Main Branch', '123 Main Street, Bangkok', '02-123-4567', 'ACTIVE', 1, 1);
+INSERT INTO stores (code, name, address, phone, status, created_by, updated_by) VALUES ('CP002', 'Central Plaza', '456 Central Plaza, Bangkok', '02-234-5678', 'ACTIVE', 1, 1);
+INSERT INTO stores (code, name, address, phone, status, created_by, updated_by) VALUES ('MM003', 'Mega Mall', '789 Mega Mall, Bangkok', '02-345-6789', 'ACTIVE', 1, 1);
+
+-- Products
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU001', 'Coca Cola 330ml', 'Carbonated soft drink', 15.00, 10.00, 500, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU002', 'Pepsi 330ml', 'Carbonated soft drink', 15.00, 10.00, 450, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU003', 'Lays Chips 50g', 'Potato chips', 20.00, 12.00, 300, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU004', 'Snickers Bar 50g', 'Chocolate bar', 25.00, 15.00, 400, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU005', 'Mineral Water 600ml', 'Drinking water', 10.00, 5.00, 600, 'ACTIVE', 1, 1);
+
+-- Transactions (OPENING_BALANCE for the stock the products had before the
+-- sales below, so the ledger sums to the seeded stock)
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 1, NULL, 580, 10.00, 5800.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 2, NULL, 490, 10.00, 4900.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 3, NULL, 325, 12.00, 3900.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 4, NULL, 400, 15.00, 6000.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 5, NULL, 600, 5.00, 3000.00, 'Opening balance', 1);
+
+-- Transactions (DECREASE)
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 1, 1, 50, 15.00, 750.00, 'Sold to Main Branch', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 1, 2, 30, 15.00, 450.00, 'Sold to Central Plaza', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 2, 1, 40, 15.00, 600.00, 'Sold to Main Branch', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 3, 3, 25, 20.00, 500.00, 'Sold to Mega Mall', 1);
+
+-- Store assignments (staff works at Main Branch and Central Plaza)
+INSERT INTO user_stores (user_id, store_id) VALUES (2, 1);
+INSERT INTO user_stores (user_id, store_id) VALUES (2, 2);
+
+-- Every product starts at revision 1
+INSERT INTO product_revisions (product_id, revision, sku, name, description, price, cost, status, created_by)
+SELECT id, 1, sku, name, description, price, cost, status, created_by FROM products;
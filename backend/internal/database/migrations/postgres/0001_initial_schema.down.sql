@@ -0,0 +1,39 @@
+-- ============================================
+-- 0001 INITIAL SCHEMA (PostgreSQL), reverted
+-- ============================================
+
+DROP TABLE gl_export_lines CASCADE;
+DROP TABLE gl_exports CASCADE;
+DROP TABLE period_valuations CASCADE;
+DROP TABLE accounting_periods CASCADE;
+DROP TABLE stock_reconciliation_items CASCADE;
+DROP TABLE stock_reconciliations CASCADE;
+DROP TABLE inventory_snapshots CASCADE;
+DROP TABLE idempotency_keys CASCADE;
+DROP TABLE product_revisions CASCADE;
+DROP TABLE audit_log CASCADE;
+DROP TABLE impersonation_actions CASCADE;
+DROP TABLE password_reset_tokens CASCADE;
+DROP TABLE oidc_logins CASCADE;
+DROP TABLE user_identities CASCADE;
+DROP TABLE jwt_signing_keys CASCADE;
+DROP TABLE user_recovery_codes CASCADE;
+DROP TABLE user_mfa CASCADE;
+DROP TABLE api_key_stores CASCADE;
+DROP TABLE api_key_permissions CASCADE;
+DROP TABLE api_keys CASCADE;
+DROP TABLE user_stores CASCADE;
+DROP TABLE login_history CASCADE;
+DROP TABLE login_throttles CASCADE;
+DROP TABLE revoked_tokens CASCADE;
+DROP TABLE refresh_tokens CASCADE;
+DROP TABLE auth_sessions CASCADE;
+DROP TABLE transactions CASCADE;
+DROP TABLE products CASCADE;
+DROP TABLE stores CASCADE;
+DROP TABLE users CASCADE;
+DROP TABLE role_permissions CASCADE;
+DROP TABLE permissions CASCADE;
+DROP TABLE roles CASCADE;
+
+DROP FUNCTION audit_log_append_only();
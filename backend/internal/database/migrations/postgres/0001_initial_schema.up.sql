@@ -0,0 +1,500 @@
+-- ============================================
+-- 0001 INITIAL SCHEMA (PostgreSQL)
+-- Tables and seed data. Requires PostgreSQL 16 or newer.
+-- ============================================
+
+-- Create Tables
+CREATE TABLE roles (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255),
+    is_system SMALLINT DEFAULT 0 NOT NULL CHECK (is_system IN (0, 1)),
+    require_mfa SMALLINT DEFAULT 0 NOT NULL CHECK (require_mfa IN (0, 1)),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE permissions (
+    code VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255)
+);
+
+CREATE TABLE role_permissions (
+    role_id BIGINT NOT NULL,
+    permission_code VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_id, permission_code),
+    FOREIGN KEY (role_id) REFERENCES roles(id),
+    FOREIGN KEY (permission_code) REFERENCES permissions(code)
+);
+
+CREATE TABLE users (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    full_name VARCHAR(100) NOT NULL,
+    email VARCHAR(255),
+    role VARCHAR(20) NOT NULL,
+    status VARCHAR(20) DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'INACTIVE')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (role) REFERENCES roles(code)
+);
+
+CREATE TABLE stores (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    address VARCHAR(255),
+    phone VARCHAR(20),
+    status VARCHAR(20) DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'INACTIVE')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    updated_by BIGINT,
+    version BIGINT DEFAULT 1 NOT NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    FOREIGN KEY (updated_by) REFERENCES users(id)
+);
+
+CREATE TABLE products (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    sku VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255),
+    price NUMERIC(10,2) NOT NULL,
+    cost NUMERIC(10,2) NOT NULL,
+    stock BIGINT DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'INACTIVE')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    updated_by BIGINT,
+    version BIGINT DEFAULT 1 NOT NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    FOREIGN KEY (updated_by) REFERENCES users(id)
+);
+
+CREATE TABLE transactions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    transaction_type VARCHAR(20) NOT NULL CHECK (transaction_type IN ('INCREASE', 'DECREASE', 'ADJUSTMENT', 'OPENING_BALANCE')),
+    product_id BIGINT NOT NULL,
+    store_id BIGINT,
+    quantity BIGINT NOT NULL,
+    unit_price NUMERIC(10,2) NOT NULL,
+    total_amount NUMERIC(10,2) NOT NULL,
+    notes VARCHAR(255),
+    transaction_date TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT NOT NULL,
+    impersonated_by BIGINT,
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id),
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    FOREIGN KEY (impersonated_by) REFERENCES users(id)
+);
+
+-- Keyset pagination walks these in (date, id) order
+CREATE INDEX idx_transactions_date ON transactions(transaction_date, id);
+CREATE INDEX idx_products_created ON products(created_at, id);
+
+CREATE TABLE auth_sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    ip_address VARCHAR(64),
+    user_agent VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMPTZ,
+    revoked_reason VARCHAR(50),
+    impersonator_id BIGINT,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (impersonator_id) REFERENCES users(id)
+);
+CREATE INDEX idx_auth_sessions_user ON auth_sessions(user_id);
+
+CREATE TABLE refresh_tokens (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    session_id VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES auth_sessions(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE login_throttles (
+    throttle_key VARCHAR(150) PRIMARY KEY,
+    failed_count BIGINT DEFAULT 0 NOT NULL,
+    last_failed_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE TABLE login_history (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    username VARCHAR(100) NOT NULL,
+    user_id BIGINT,
+    success SMALLINT NOT NULL CHECK (success IN (0, 1)),
+    failure_reason VARCHAR(50),
+    ip_address VARCHAR(64),
+    user_agent VARCHAR(255),
+    request_id VARCHAR(64),
+    attempted_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_login_history_username ON login_history(username, attempted_at);
+CREATE INDEX idx_login_history_ip ON login_history(ip_address, attempted_at);
+
+CREATE TABLE user_stores (
+    user_id BIGINT NOT NULL,
+    store_id BIGINT NOT NULL,
+    PRIMARY KEY (user_id, store_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id)
+);
+
+CREATE TABLE api_keys (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE api_key_permissions (
+    api_key_id BIGINT NOT NULL,
+    permission_code VARCHAR(50) NOT NULL,
+    PRIMARY KEY (api_key_id, permission_code),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id),
+    FOREIGN KEY (permission_code) REFERENCES permissions(code)
+);
+
+CREATE TABLE api_key_stores (
+    api_key_id BIGINT NOT NULL,
+    store_id BIGINT NOT NULL,
+    PRIMARY KEY (api_key_id, store_id),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id)
+);
+
+CREATE TABLE user_mfa (
+    user_id BIGINT PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT DEFAULT 0 NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE user_recovery_codes (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_recovery_codes_user ON user_recovery_codes(user_id, code_hash);
+
+CREATE TABLE jwt_signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(10) NOT NULL CHECK (algorithm IN ('RS256', 'ES256')),
+    private_key VARCHAR(4000) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    retired_at TIMESTAMPTZ
+);
+
+CREATE TABLE user_identities (
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMPTZ,
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
+
+CREATE TABLE oidc_logins (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    state_hash VARCHAR(64) UNIQUE,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    handoff_hash VARCHAR(64) UNIQUE,
+    user_id BIGINT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE password_reset_tokens (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    requested_ip VARCHAR(45),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_password_reset_user ON password_reset_tokens(user_id);
+
+CREATE TABLE impersonation_actions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    session_id VARCHAR(64) NOT NULL,
+    impersonator_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    status_code BIGINT NOT NULL,
+    ip_address VARCHAR(64),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES auth_sessions(id),
+    FOREIGN KEY (impersonator_id) REFERENCES users(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_impersonation_session ON impersonation_actions(session_id);
+
+CREATE TABLE audit_log (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    actor_id BIGINT,
+    impersonator_id BIGINT,
+    api_key_id BIGINT,
+    entity_type VARCHAR(30) NOT NULL,
+    entity_id VARCHAR(64),
+    action VARCHAR(30) NOT NULL,
+    changes TEXT CHECK (changes IS JSON),
+    request_id VARCHAR(64),
+    ip_address VARCHAR(64),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    FOREIGN KEY (actor_id) REFERENCES users(id),
+    FOREIGN KEY (impersonator_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id, created_at);
+CREATE INDEX idx_audit_log_created ON audit_log(created_at);
+CREATE INDEX idx_audit_log_request ON audit_log(request_id);
+
+-- Entries are never changed or removed
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TABLE product_revisions (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    product_id BIGINT NOT NULL,
+    revision BIGINT NOT NULL,
+    sku VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255),
+    price NUMERIC(10,2) NOT NULL,
+    cost NUMERIC(10,2) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    changes TEXT CHECK (changes IS JSON),
+    reverted_from BIGINT,
+    created_by BIGINT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT uq_product_revision UNIQUE (product_id, revision),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE idempotency_keys (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    principal VARCHAR(50) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code BIGINT,
+    response_body TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_idempotency_key UNIQUE (principal, key_hash)
+);
+
+CREATE INDEX idx_idempotency_expires ON idempotency_keys(expires_at);
+
+CREATE TABLE inventory_snapshots (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    snapshot_at TIMESTAMPTZ NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_inventory_snapshot UNIQUE (snapshot_at, product_id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE TABLE stock_reconciliations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    fix SMALLINT DEFAULT 0 NOT NULL CHECK (fix IN (0, 1)),
+    products_checked BIGINT DEFAULT 0 NOT NULL,
+    mismatches BIGINT DEFAULT 0 NOT NULL,
+    run_by BIGINT,
+    started_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ,
+    FOREIGN KEY (run_by) REFERENCES users(id)
+);
+
+CREATE TABLE stock_reconciliation_items (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    reconciliation_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    stock BIGINT NOT NULL,
+    ledger_stock BIGINT NOT NULL,
+    difference BIGINT NOT NULL,
+    adjustment_id BIGINT,
+    FOREIGN KEY (reconciliation_id) REFERENCES stock_reconciliations(id),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (adjustment_id) REFERENCES transactions(id)
+);
+
+CREATE INDEX idx_reconciliation_items_run ON stock_reconciliation_items(reconciliation_id);
+
+CREATE TABLE accounting_periods (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    period VARCHAR(7) NOT NULL UNIQUE,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) DEFAULT 'OPEN' NOT NULL CHECK (status IN ('OPEN', 'CLOSED')),
+    stock_value NUMERIC(14,2),
+    closed_by BIGINT,
+    closed_at TIMESTAMPTZ,
+    reopened_by BIGINT,
+    reopened_at TIMESTAMPTZ,
+    reopen_reason VARCHAR(500),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (closed_by) REFERENCES users(id),
+    FOREIGN KEY (reopened_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_accounting_periods_range ON accounting_periods(period_start, period_end);
+
+CREATE TABLE period_valuations (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    period_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity BIGINT NOT NULL,
+    unit_cost NUMERIC(10,2) NOT NULL,
+    value NUMERIC(14,2) NOT NULL,
+    CONSTRAINT uq_period_valuation UNIQUE (period_id, product_id),
+    FOREIGN KEY (period_id) REFERENCES accounting_periods(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE TABLE gl_exports (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    period VARCHAR(7) NOT NULL UNIQUE,
+    period_closed_at TIMESTAMPTZ NOT NULL,
+    journal_count BIGINT NOT NULL,
+    line_count BIGINT NOT NULL,
+    total_debit NUMERIC(14,2) NOT NULL,
+    total_credit NUMERIC(14,2) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    exported_by BIGINT NOT NULL,
+    exported_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (exported_by) REFERENCES users(id)
+);
+
+CREATE TABLE gl_export_lines (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    export_id BIGINT NOT NULL,
+    line_no BIGINT NOT NULL,
+    journal VARCHAR(20) NOT NULL,
+    store_id BIGINT,
+    account VARCHAR(50) NOT NULL,
+    account_role VARCHAR(30) NOT NULL,
+    description VARCHAR(200) NOT NULL,
+    debit NUMERIC(14,2) DEFAULT 0 NOT NULL,
+    credit NUMERIC(14,2) DEFAULT 0 NOT NULL,
+    CONSTRAINT uq_gl_export_line UNIQUE (export_id, line_no),
+    FOREIGN KEY (export_id) REFERENCES gl_exports(id),
+    FOREIGN KEY (store_id) REFERENCES stores(id)
+);
+
+-- Seed Data
+
+-- Roles and permissions
+INSERT INTO permissions (code, description) VALUES ('product.write', 'Create, update and delete products');
+INSERT INTO permissions (code, description) VALUES ('product.price', 'Change product price and cost');
+INSERT INTO permissions (code, description) VALUES ('stock.adjust', 'Post stock movements');
+INSERT INTO permissions (code, description) VALUES ('store.write', 'Create, update and delete stores');
+INSERT INTO permissions (code, description) VALUES ('report.view_cost', 'See cost and margin figures in reports');
+INSERT INTO permissions (code, description) VALUES ('user.manage', 'Manage users, lockouts and login history');
+INSERT INTO permissions (code, description) VALUES ('role.manage', 'Manage roles and their permissions');
+INSERT INTO permissions (code, description) VALUES ('api_key.manage', 'Issue and revoke API keys');
+INSERT INTO permissions (code, description) VALUES ('signing_key.manage', 'Rotate token signing keys');
+INSERT INTO permissions (code, description) VALUES ('user.impersonate', 'Sign in as another user to see what they see');
+INSERT INTO permissions (code, description) VALUES ('audit.view', 'View the audit log');
+INSERT INTO permissions (code, description) VALUES ('store.all_access', 'See and post against every store, not just assigned ones');
+INSERT INTO permissions (code, description) VALUES ('inventory.reconcile', 'Reconcile product stock against the transaction ledger');
+INSERT INTO permissions (code, description) VALUES ('gl.export', 'Export closed periods as general-ledger journals');
+INSERT INTO permissions (code, description) VALUES ('period.manage', 'Close and reopen accounting periods');
+
+INSERT INTO roles (code, name, description, is_system) VALUES ('ADMIN', 'Administrator', 'Full access', 1);
+INSERT INTO roles (code, name, description, is_system) VALUES ('STAFF', 'Staff', 'Day-to-day stock movements', 1);
+INSERT INTO roles (code, name, description, is_system) VALUES ('MANAGER', 'Store Manager', 'Adjust stock and maintain products, but not prices', 0);
+
+INSERT INTO role_permissions (role_id, permission_code) SELECT r.id, p.code FROM roles r CROSS JOIN permissions p WHERE r.code = 'ADMIN';
+INSERT INTO role_permissions (role_id, permission_code) SELECT id, 'stock.adjust' FROM roles WHERE code = 'STAFF';
+INSERT INTO role_permissions (role_id, permission_code) SELECT id, 'product.write' FROM roles WHERE code = 'MANAGER';
+INSERT INTO role_permissions (role_id, permission_code) SELECT id, 'stock.adjust' FROM roles WHERE code = 'MANAGER';
+
+-- Users
+INSERT INTO users (username, password_hash, full_name, email, role, status) VALUES ('admin', 'admin123', 'System Administrator', 'admin@example.com', 'ADMIN', 'ACTIVE');
+INSERT INTO users (username, password_hash, full_name, email, role, status) VALUES ('staff', 'staff123', 'Staff User', 'staff@example.com', 'STAFF', 'ACTIVE');
+
+-- Stores
+INSERT INTO stores (code, name, address, phone, status, created_by, updated_by) VALUES ('MB001', 'Main Branch', '123 Main Street, Bangkok', '02-123-4567', 'ACTIVE', 1, 1);
+INSERT INTO stores (code, name, address, phone, status, created_by, updated_by) VALUES ('CP002', 'Central Plaza', '456 Central Plaza, Bangkok', '02-234-5678', 'ACTIVE', 1, 1);
+INSERT INTO stores (code, name, address, phone, status, created_by, updated_by) VALUES ('MM003', 'Mega Mall', '789 Mega Mall, Bangkok', '02-345-6789', 'ACTIVE', 1, 1);
+
+-- Products
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU001', 'Coca Cola 330ml', 'Carbonated soft drink', 15.00, 10.00, 500, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU002', 'Pepsi 330ml', 'Carbonated soft drink', 15.00, 10.00, 450, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU003', 'Lays Chips 50g', 'Potato chips', 20.00, 12.00, 300, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU004', 'Snickers Bar 50g', 'Chocolate bar', 25.00, 15.00, 400, 'ACTIVE', 1, 1);
+INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by) VALUES ('SKU005', 'Mineral Water 600ml', 'Drinking water', 10.00, 5.00, 600, 'ACTIVE', 1, 1);
+
+-- Transactions (OPENING_BALANCE for the stock the products had before the
+-- sales below, so the ledger sums to the seeded stock)
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 1, NULL, 580, 10.00, 5800.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 2, NULL, 490, 10.00, 4900.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 3, NULL, 325, 12.00, 3900.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 4, NULL, 400, 15.00, 6000.00, 'Opening balance', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('OPENING_BALANCE', 5, NULL, 600, 5.00, 3000.00, 'Opening balance', 1);
+
+-- Transactions (DECREASE)
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 1, 1, 50, 15.00, 750.00, 'Sold to Main Branch', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 1, 2, 30, 15.00, 450.00, 'Sold to Central Plaza', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 2, 1, 40, 15.00, 600.00, 'Sold to Main Branch', 1);
+INSERT INTO transactions (transaction_type, product_id, store_id, quantity, unit_price, total_amount, notes, created_by) VALUES ('DECREASE', 3, 3, 25, 20.00, 500.00, 'Sold to Mega Mall', 1);
+
+-- Store assignments (staff works at Main Branch and Central Plaza)
+INSERT INTO user_stores (user_id, store_id) VALUES (2, 1);
+INSERT INTO user_stores (user_id, store_id) VALUES (2, 2);
+
+-- Every product starts at revision 1
+INSERT INTO product_revisions (product_id, revision, sku, name, description, price, cost, status, created_by)
+SELECT id, 1, sku, name, description, price, cost, status, created_by FROM products;
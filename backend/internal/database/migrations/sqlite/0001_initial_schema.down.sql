@@ -0,0 +1,38 @@
+-- ============================================
+-- 0001 INITIAL SCHEMA (SQLite), reverted
+-- Children are dropped before the tables they reference
+-- ============================================
+
+DROP TABLE gl_export_lines;
+DROP TABLE gl_exports;
+DROP TABLE period_valuations;
+DROP TABLE accounting_periods;
+DROP TABLE stock_reconciliation_items;
+DROP TABLE stock_reconciliations;
+DROP TABLE inventory_snapshots;
+DROP TABLE idempotency_keys;
+DROP TABLE product_revisions;
+DROP TABLE audit_log;
+DROP TABLE impersonation_actions;
+DROP TABLE password_reset_tokens;
+DROP TABLE oidc_logins;
+DROP TABLE user_identities;
+DROP TABLE jwt_signing_keys;
+DROP TABLE user_recovery_codes;
+DROP TABLE user_mfa;
+DROP TABLE api_key_stores;
+DROP TABLE api_key_permissions;
+DROP TABLE api_keys;
+DROP TABLE user_stores;
+DROP TABLE login_history;
+DROP TABLE login_throttles;
+DROP TABLE revoked_tokens;
+DROP TABLE refresh_tokens;
+DROP TABLE auth_sessions;
+DROP TABLE transactions;
+DROP TABLE products;
+DROP TABLE stores;
+DROP TABLE users;
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
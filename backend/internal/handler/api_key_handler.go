@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// GetAPIKeys lists API keys
+// @Summary List API keys
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.APIKey}
+// @Router /api/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.GetAPIKeys()
+	if err != nil {
+		response.InternalServerError(c, "Failed to get API keys", err)
+		return
+	}
+
+	response.Success(c, "API keys retrieved successfully", keys)
+}
+
+// CreateAPIKey issues an API key
+// @Summary Create API key
+// @Description The plain key is only returned in this response
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body models.CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} response.Response{data=models.CreateAPIKeyResponse}
+// @Router /api/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	created, err := h.apiKeyService.CreateAPIKey(&req, middleware.GetUserID(c), middleware.GetPermissions(c), middleware.GetStoreScope(c), middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "API key created successfully", created)
+}
+
+// RevokeAPIKey revokes an API key
+// @Summary Revoke API key
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} response.Response
+// @Router /api/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid API key ID", err)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(id, middleware.GetAuditContext(c)); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "API key revoked successfully", nil)
+}
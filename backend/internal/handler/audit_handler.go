@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// GetAuditLog lists audit log entries
+// @Summary Audit log
+// @Description List creates, updates, deletes and logins with their before/after diff (requires audit.view)
+// @Tags audit
+// @Produce json
+// @Param actor_id query int false "User who made the change"
+// @Param entity_type query string false "Entity type, e.g. product or user"
+// @Param entity_id query string false "Entity ID"
+// @Param action query string false "Action, e.g. CREATE or LOGIN_FAILED"
+// @Param request_id query string false "Request ID"
+// @Param from query string false "Start time, RFC 3339 or YYYY-MM-DD (inclusive)"
+// @Param to query string false "End time, RFC 3339 or YYYY-MM-DD (a date includes the whole day)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} response.Response{data=models.AuditListResponse}
+// @Router /api/audit [get]
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := &models.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Action:     strings.ToUpper(c.Query("action")),
+		RequestID:  c.Query("request_id"),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid actor_id filter", err)
+			return
+		}
+		filter.ActorID = id
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, _, err := parseTimeQuery(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid from filter", err)
+			return
+		}
+		filter.From = &from
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, dateOnly, err := parseTimeQuery(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid to filter", err)
+			return
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	result, err := h.auditService.GetEntries(filter)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get audit log", err)
+		return
+	}
+
+	response.Success(c, "Audit log retrieved successfully", result)
+}
+
+// parseTimeQuery accepts an RFC 3339 timestamp or a plain date, reporting
+// which one it got
+func parseTimeQuery(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("expected RFC 3339 time or YYYY-MM-DD, got %q", v)
+}
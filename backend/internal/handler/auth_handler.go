@@ -1,7 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
 	"pos-backoffice/internal/models"
 	"pos-backoffice/internal/service"
 	"pos-backoffice/pkg/response"
@@ -19,7 +25,7 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 
 // Login handles user login
 // @Summary User login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return an access token and refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -27,6 +33,7 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 // @Success 200 {object} response.Response{data=models.LoginResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Router /api/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
@@ -35,11 +42,104 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	loginResp, err := h.authService.Login(&req)
+	loginResp, err := h.authService.Login(&req, middleware.GetAuditContext(c))
+	if err != nil {
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", fmt.Sprint(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, err.Error(), nil)
+			return
+		}
+		response.Unauthorized(c, err.Error())
+		return
+	}
+
+	if loginResp.MFARequired {
+		response.Success(c, "Two-factor authentication required", loginResp)
+		return
+	}
+
+	response.Success(c, "Login successful", loginResp)
+}
+
+// LoginMFA completes a login with a second factor
+// @Summary Two-factor login
+// @Description Exchange the MFA token from /api/auth/login and a TOTP or recovery code for a token pair
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFALoginRequest true "MFA token and code"
+// @Success 200 {object} response.Response{data=models.LoginResponse}
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /api/auth/login/mfa [post]
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req models.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	loginResp, err := h.authService.LoginMFA(&req, middleware.GetAuditContext(c))
 	if err != nil {
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", fmt.Sprint(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, err.Error(), nil)
+			return
+		}
 		response.Unauthorized(c, err.Error())
 		return
 	}
 
 	response.Success(c, "Login successful", loginResp)
 }
+
+// Refresh exchanges a refresh token for a new token pair
+// @Summary Refresh access token
+// @Description Rotate the refresh token and issue a new access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} response.Response{data=models.LoginResponse}
+// @Failure 401 {object} response.Response
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	loginResp, err := h.authService.Refresh(&req)
+	if err != nil {
+		response.Unauthorized(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Token refreshed successfully", loginResp)
+}
+
+// Logout revokes the current session
+// @Summary User logout
+// @Description Revoke the current session and access token (not available to API keys)
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /api/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	err := h.authService.Logout(
+		middleware.GetUserID(c),
+		c.GetString("session_id"),
+		c.GetString("token_id"),
+		c.GetTime("token_expires_at"),
+		middleware.GetAuditContext(c),
+	)
+	if err != nil {
+		response.InternalServerError(c, "Failed to logout", err)
+		return
+	}
+
+	response.Success(c, "Logout successful", nil)
+}
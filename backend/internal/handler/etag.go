@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/pkg/response"
+)
+
+// setETag sends a row version as a strong ETag
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion reads the version named by the If-Match header. Writes to
+// versioned resources must be conditional, so a missing header is answered
+// with 428 and one that names no version of ours with 412; ok is false in
+// both cases. "*" matches any version and yields zero.
+func ifMatchVersion(c *gin.Context) (version int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		response.Error(c, http.StatusPreconditionRequired, "If-Match header required; send the ETag from the last read", nil)
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// Weak validators are accepted too; the version is the same either way
+	if unquoted, err := strconv.Unquote(strings.TrimPrefix(header, "W/")); err == nil {
+		if v, err := strconv.Atoi(unquoted); err == nil && v > 0 {
+			return v, true
+		}
+	}
+
+	response.Error(c, http.StatusPreconditionFailed, "If-Match does not match the current version", nil)
+	return 0, false
+}
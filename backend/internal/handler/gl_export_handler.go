@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type GLExportHandler struct {
+	glService *service.GLExportService
+}
+
+func NewGLExportHandler(glService *service.GLExportService) *GLExportHandler {
+	return &GLExportHandler{glService: glService}
+}
+
+// GetManifest lists exported periods
+// @Summary GL export manifest
+// @Description Every period exported to the general ledger, with line counts, totals and the checksum of its CSV (requires gl.export)
+// @Tags accounting
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.GLExport}
+// @Router /api/gl-exports [get]
+func (h *GLExportHandler) GetManifest(c *gin.Context) {
+	exports, err := h.glService.GetManifest()
+	if err != nil {
+		response.InternalServerError(c, "Failed to get GL exports", err)
+		return
+	}
+
+	response.Success(c, "GL exports retrieved successfully", exports)
+}
+
+// Export turns a closed period into general-ledger journals
+// @Summary Export period to the general ledger
+// @Description Build balanced journal lines per store for a closed period and record the export (requires gl.export).
+// @Description A period is exported once; exporting it again returns the stored export with 200.
+// @Tags accounting
+// @Produce json
+// @Param period path string true "Month as YYYY-MM"
+// @Success 201 {object} response.Response{data=models.GLExport}
+// @Success 200 {object} response.Response{data=models.GLExport}
+// @Failure 409 {object} response.Response
+// @Router /api/gl-exports/{period} [post]
+func (h *GLExportHandler) Export(c *gin.Context) {
+	export, created, err := h.glService.Export(c.Param("period"), middleware.GetUserID(c), middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrPeriodNotClosed) {
+		response.Error(c, http.StatusConflict, "Only closed accounting periods can be exported", nil)
+		return
+	}
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	if !created {
+		response.Success(c, "Period already exported", export)
+		return
+	}
+	response.Created(c, "Period exported", export)
+}
+
+// GetExport downloads an exported period
+// @Summary Get GL export
+// @Description The journal lines of an exported period, as JSON or as the CSV the checksum was taken over (requires gl.export)
+// @Tags accounting
+// @Produce json,text/csv
+// @Param period path string true "Month as YYYY-MM"
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {object} response.Response{data=models.GLExport}
+// @Failure 404 {object} response.Response
+// @Router /api/gl-exports/{period} [get]
+func (h *GLExportHandler) GetExport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		response.BadRequest(c, "Invalid format, use json or csv", nil)
+		return
+	}
+
+	export, err := h.glService.GetExport(c.Param("period"))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	if format == "json" {
+		response.Success(c, "GL export retrieved successfully", export)
+		return
+	}
+
+	body, err := service.JournalCSV(export)
+	if err != nil {
+		response.InternalServerError(c, "Failed to write GL export", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="gl-journal-%s.csv"`, sanitizeFilename(export.Period)))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", body)
+}
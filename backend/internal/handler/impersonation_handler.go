@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type ImpersonationHandler struct {
+	impersonationService *service.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService *service.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// Impersonate issues a token acting as another user
+// @Summary Impersonate user
+// @Description Returns a short-lived, non-refreshable access token acting as the user (requires user.impersonate). End it with /api/auth/logout.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.Response{data=models.ImpersonationResponse}
+// @Failure 400 {object} response.Response
+// @Router /api/users/{id}/impersonate [post]
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err)
+		return
+	}
+
+	result, err := h.impersonationService.Impersonate(id, middleware.GetUserID(c), middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Impersonation started", result)
+}
+
+// GetActions lists writes made while impersonating
+// @Summary Impersonation log
+// @Description List write requests made with impersonation tokens (requires user.manage)
+// @Tags users
+// @Produce json
+// @Param impersonator_id query int false "Impersonating admin"
+// @Param user_id query int false "Impersonated user"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} response.Response{data=models.ImpersonationActionListResponse}
+// @Router /api/users/impersonation-log [get]
+func (h *ImpersonationHandler) GetActions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := &models.ImpersonationActionFilter{
+		Page:  page,
+		Limit: limit,
+	}
+
+	if v := c.Query("impersonator_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid impersonator_id filter", err)
+			return
+		}
+		filter.ImpersonatorID = id
+	}
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id filter", err)
+			return
+		}
+		filter.UserID = id
+	}
+
+	result, err := h.impersonationService.GetActions(filter)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get impersonation log", err)
+		return
+	}
+
+	response.Success(c, "Impersonation log retrieved successfully", result)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type InventoryHandler struct {
+	inventoryService *service.InventoryService
+}
+
+func NewInventoryHandler(inventoryService *service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{inventoryService: inventoryService}
+}
+
+// GetStockAsOf reconstructs stock at a past date
+// @Summary Stock as of a date
+// @Description Quantity per product at a point in time, replayed from the transaction ledger.
+// @Description Unit cost and value are included for callers with report.view_cost.
+// @Tags reports
+// @Produce json,text/csv
+// @Param date query string true "RFC 3339 time, or YYYY-MM-DD for the end of that day"
+// @Param product_id query int false "Product ID"
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {object} response.Response{data=models.Report}
+// @Router /api/inventory/as-of [get]
+func (h *InventoryHandler) GetStockAsOf(c *gin.Context) {
+	v := c.Query("date")
+	if v == "" {
+		response.BadRequest(c, "date is required", nil)
+		return
+	}
+	asOf, dateOnly, err := parseTimeQuery(v)
+	if err != nil {
+		response.BadRequest(c, "Invalid date", err)
+		return
+	}
+	if dateOnly {
+		asOf = asOf.AddDate(0, 0, 1)
+	}
+
+	var productID int64
+	if v := c.Query("product_id"); v != "" {
+		if productID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			response.BadRequest(c, "Invalid product_id filter", err)
+			return
+		}
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		response.BadRequest(c, "Invalid format, use json or csv", nil)
+		return
+	}
+
+	canViewCost := middleware.HasPermission(c, models.PermReportViewCost)
+	report, err := h.inventoryService.StockAsOf(asOf, productID, canViewCost)
+	if err != nil {
+		response.InternalServerError(c, "Failed to reconstruct stock", err)
+		return
+	}
+
+	if format == "csv" {
+		writeInventoryCSV(c, report, v, canViewCost)
+		return
+	}
+
+	response.Success(c, "Stock reconstructed successfully", report)
+}
+
+// writeInventoryCSV sends a stock report as a spreadsheet download
+func writeInventoryCSV(c *gin.Context, report *models.Report, date string, withCost bool) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="stock-as-of-%s.csv"`, sanitizeFilename(date)))
+
+	w := csv.NewWriter(c.Writer)
+	header := []string{"product_id", "sku", "name", "status", "quantity"}
+	if withCost {
+		header = append(header, "unit_cost", "value")
+	}
+	w.Write(header)
+
+	for _, row := range report.Rows.([]models.InventoryRow) {
+		record := []string{
+			strconv.FormatInt(row.ProductID, 10), row.SKU, row.Name, row.Status, strconv.Itoa(row.Quantity),
+		}
+		if withCost {
+			record = append(record,
+				strconv.FormatFloat(*row.UnitCost, 'f', 2, 64),
+				strconv.FormatFloat(*row.Value, 'f', 2, 64),
+			)
+		}
+		w.Write(record)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		c.Error(err)
+	}
+}
+
+// sanitizeFilename keeps only characters that are safe in a download name
+func sanitizeFilename(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
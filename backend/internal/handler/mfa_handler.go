@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type MFAHandler struct {
+	mfaService *service.MFAService
+}
+
+func NewMFAHandler(mfaService *service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+// GetStatus returns the caller's two-factor status
+// @Summary Two-factor status
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.Response{data=models.MFAStatus}
+// @Router /api/auth/mfa [get]
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	status, err := h.mfaService.GetStatus(middleware.GetUserID(c))
+	if err != nil {
+		response.InternalServerError(c, "Failed to get two-factor status", err)
+		return
+	}
+
+	response.Success(c, "Two-factor status retrieved successfully", status)
+}
+
+// Enroll starts TOTP enrollment for the caller
+// @Summary Start two-factor enrollment
+// @Description Returns a secret and otpauth URI; confirm with /api/auth/mfa/verify
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.Response{data=models.MFAEnrollResponse}
+// @Router /api/auth/mfa/enroll [post]
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.mfaService.BeginEnrollment(middleware.GetUserID(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Two-factor enrollment started", enrollment)
+}
+
+// EnrollWithToken starts a required enrollment during login
+// @Summary Start required two-factor enrollment
+// @Description For users whose role requires two-factor authentication; finish with /api/auth/login/mfa
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFATokenRequest true "MFA token from login"
+// @Success 200 {object} response.Response{data=models.MFAEnrollResponse}
+// @Router /api/auth/login/mfa/enroll [post]
+func (h *MFAHandler) EnrollWithToken(c *gin.Context) {
+	var req models.MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollmentWithToken(req.MFAToken)
+	if err != nil {
+		response.Unauthorized(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Two-factor enrollment started", enrollment)
+}
+
+// Verify confirms the caller's pending enrollment
+// @Summary Confirm two-factor enrollment
+// @Description Enables two-factor authentication and returns recovery codes, shown only once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFACodeRequest true "TOTP code"
+// @Success 200 {object} response.Response{data=models.MFARecoveryCodesResponse}
+// @Router /api/auth/mfa/verify [post]
+func (h *MFAHandler) Verify(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(middleware.GetUserID(c), req.Code, middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Two-factor authentication enabled", models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes
+// @Summary Regenerate recovery codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFACodeRequest true "TOTP code"
+// @Success 200 {object} response.Response{data=models.MFARecoveryCodesResponse}
+// @Router /api/auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(middleware.GetUserID(c), req.Code, middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Recovery codes regenerated", models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable turns off two-factor authentication for the caller
+// @Summary Disable two-factor authentication
+// @Description Not allowed when the caller's role requires two-factor authentication
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} response.Response
+// @Router /api/auth/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	if err := h.mfaService.Disable(middleware.GetUserID(c), req.Code, middleware.GetAuditContext(c)); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Two-factor authentication disabled", nil)
+}
+
+// ResetUserMFA removes another user's two-factor enrollment
+// @Summary Reset user two-factor authentication
+// @Description For lost devices (requires user.manage)
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.Response
+// @Router /api/users/{id}/mfa [delete]
+func (h *MFAHandler) ResetUserMFA(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err)
+		return
+	}
+
+	if err := h.mfaService.Reset(id, middleware.GetAuditContext(c)); err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Two-factor authentication reset successfully", nil)
+}
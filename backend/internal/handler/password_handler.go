@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type PasswordHandler struct {
+	passwordService *service.PasswordService
+}
+
+func NewPasswordHandler(passwordService *service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: passwordService,
+	}
+}
+
+// ChangePassword changes the caller's password
+// @Summary Change password
+// @Description Requires the current password; signs out the caller's other sessions
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/auth/change-password [post]
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	if err := h.passwordService.ChangePassword(middleware.GetUserID(c), c.GetString("session_id"), &req, middleware.GetAuditContext(c)); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Password changed successfully", nil)
+}
+
+// ForgotPassword sends a password reset link
+// @Summary Request password reset
+// @Description Sends a single-use reset link to the user's email address. The response is the same whether or not the user exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Username"
+// @Success 200 {object} response.Response
+// @Router /api/auth/forgot-password [post]
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	if err := h.passwordService.ForgotPassword(&req, c.ClientIP()); err != nil {
+		response.InternalServerError(c, "Failed to request password reset", err)
+		return
+	}
+
+	response.Success(c, "If the account exists and has an email address, a reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password with a reset token
+// @Summary Reset password
+// @Description Consumes the reset token and signs the user out everywhere
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/auth/reset-password [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(&req, middleware.GetAuditContext(c)); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Password reset successfully", nil)
+}
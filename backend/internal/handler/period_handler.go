@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type PeriodHandler struct {
+	periodService *service.PeriodService
+}
+
+func NewPeriodHandler(periodService *service.PeriodService) *PeriodHandler {
+	return &PeriodHandler{periodService: periodService}
+}
+
+// GetPeriods lists accounting periods
+// @Summary List accounting periods
+// @Description Months that have been closed at least once, newest first. Months not listed are open.
+// @Description The closing stock value is included for callers with report.view_cost.
+// @Tags accounting
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.AccountingPeriod}
+// @Router /api/accounting-periods [get]
+func (h *PeriodHandler) GetPeriods(c *gin.Context) {
+	periods, err := h.periodService.GetPeriods(middleware.HasPermission(c, models.PermReportViewCost))
+	if err != nil {
+		response.InternalServerError(c, "Failed to get accounting periods", err)
+		return
+	}
+
+	response.Success(c, "Accounting periods retrieved successfully", periods)
+}
+
+// GetPeriod returns an accounting period
+// @Summary Get accounting period
+// @Description A month's status and, for callers with report.view_cost, the stock valuation taken when it was closed
+// @Tags accounting
+// @Produce json
+// @Param period path string true "Month as YYYY-MM"
+// @Success 200 {object} response.Response{data=models.AccountingPeriod}
+// @Router /api/accounting-periods/{period} [get]
+func (h *PeriodHandler) GetPeriod(c *gin.Context) {
+	period, err := h.periodService.GetPeriod(c.Param("period"), middleware.HasPermission(c, models.PermReportViewCost))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Accounting period retrieved successfully", period)
+}
+
+// ClosePeriod closes a month for posting
+// @Summary Close accounting period
+// @Description Reject every transaction dated in the month from now on and snapshot its closing stock valuation (requires period.manage).
+// @Description The month must have ended.
+// @Tags accounting
+// @Produce json
+// @Param period path string true "Month as YYYY-MM"
+// @Success 200 {object} response.Response{data=models.AccountingPeriod}
+// @Failure 409 {object} response.Response
+// @Router /api/accounting-periods/{period}/close [post]
+func (h *PeriodHandler) ClosePeriod(c *gin.Context) {
+	period, err := h.periodService.ClosePeriod(c.Param("period"), middleware.GetUserID(c), middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrPeriodClosed) {
+		response.Error(c, http.StatusConflict, "Accounting period is already closed", nil)
+		return
+	}
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Accounting period closed", period)
+}
+
+// ReopenPeriod reopens a closed month
+// @Summary Reopen accounting period
+// @Description Allow posting into a closed month again (requires period.manage). The reason is kept on the period and in the audit log.
+// @Tags accounting
+// @Accept json
+// @Produce json
+// @Param period path string true "Month as YYYY-MM"
+// @Param request body models.ReopenPeriodRequest true "Why the period is reopened"
+// @Success 200 {object} response.Response{data=models.AccountingPeriod}
+// @Failure 409 {object} response.Response
+// @Router /api/accounting-periods/{period}/reopen [post]
+func (h *PeriodHandler) ReopenPeriod(c *gin.Context) {
+	var req models.ReopenPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	period, err := h.periodService.ReopenPeriod(c.Param("period"), middleware.GetUserID(c), req.Reason, middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrPeriodNotClosed) {
+		response.Error(c, http.StatusConflict, "Accounting period is not closed", nil)
+		return
+	}
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Accounting period reopened", period)
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"pos-backoffice/internal/middleware"
 	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
 	"pos-backoffice/internal/service"
 	"pos-backoffice/pkg/response"
 )
@@ -22,7 +25,8 @@ func NewProductHandler(productService *service.ProductService) *ProductHandler {
 
 // GetProducts retrieves products with pagination and search
 // @Summary List products
-// @Description Get paginated list of products with optional search
+// @Description Get paginated list of products with optional search.
+// @Description Passing cursor (empty for the first page) switches to keyset pagination, newest first.
 // @Tags products
 // @Accept json
 // @Produce json
@@ -30,6 +34,8 @@ func NewProductHandler(productService *service.ProductService) *ProductHandler {
 // @Param page_size query int false "Page size" default(10)
 // @Param search query string false "Search term"
 // @Param status query string false "Product status" Enums(ACTIVE, INACTIVE)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param include_total query bool false "Count all matches in cursor mode"
 // @Success 200 {object} response.Response{data=models.ProductListResponse}
 // @Router /api/products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
@@ -38,6 +44,22 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	search := c.Query("search")
 	status := c.DefaultQuery("status", "ACTIVE")
 
+	if cursor, keyset := c.GetQuery("cursor"); keyset {
+		includeTotal, _ := strconv.ParseBool(c.Query("include_total"))
+		result, err := h.productService.GetProductPage(pageSize, search, status, cursor, includeTotal)
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			response.BadRequest(c, repository.ErrInvalidCursor.Error(), nil)
+			return
+		}
+		if err != nil {
+			response.InternalServerError(c, "Failed to get products", err)
+			return
+		}
+
+		response.Success(c, "Products retrieved successfully", result)
+		return
+	}
+
 	result, err := h.productService.GetProducts(page, pageSize, search, status)
 	if err != nil {
 		response.InternalServerError(c, "Failed to get products", err)
@@ -69,12 +91,13 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	setETag(c, product.Version)
 	response.Success(c, "Product retrieved successfully", product)
 }
 
 // CreateProduct creates a new product
 // @Summary Create product
-// @Description Create a new product (ADMIN only)
+// @Description Create a new product (requires product.write). Initial stock is posted as an OPENING_BALANCE transaction.
 // @Tags products
 // @Accept json
 // @Produce json
@@ -89,24 +112,32 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	}
 
 	userID := middleware.GetUserID(c)
-	product, err := h.productService.CreateProduct(&req, userID)
+	product, err := h.productService.CreateProduct(&req, userID, middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrPeriodClosed) {
+		response.Error(c, http.StatusConflict, "Opening stock cannot be posted into a closed accounting period", nil)
+		return
+	}
 	if err != nil {
 		response.BadRequest(c, err.Error(), nil)
 		return
 	}
 
+	setETag(c, product.Version)
 	response.Created(c, "Product created successfully", product)
 }
 
 // UpdateProduct updates an existing product
 // @Summary Update product
-// @Description Update product details (ADMIN only)
+// @Description Update product details (requires product.write; changing price or cost also requires product.price)
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-Match header string true "ETag from the last read of the product"
 // @Param request body models.UpdateProductRequest true "Product data"
 // @Success 200 {object} response.Response{data=models.Product}
+// @Failure 412 {object} response.Response
+// @Failure 428 {object} response.Response
 // @Router /api/products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -121,24 +152,42 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	userID := middleware.GetUserID(c)
-	product, err := h.productService.UpdateProduct(id, &req, userID)
+	canChangePrice := middleware.HasPermission(c, models.PermProductPrice)
+	product, err := h.productService.UpdateProduct(id, version, &req, userID, canChangePrice, middleware.GetAuditContext(c))
+	if errors.Is(err, service.ErrPriceChangeNotAllowed) {
+		response.Forbidden(c, err.Error())
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		response.Error(c, http.StatusPreconditionFailed, repository.ErrVersionConflict.Error(), nil)
+		return
+	}
 	if err != nil {
 		response.BadRequest(c, err.Error(), nil)
 		return
 	}
 
+	setETag(c, product.Version)
 	response.Success(c, "Product updated successfully", product)
 }
 
 // DeleteProduct soft deletes a product
 // @Summary Delete product
-// @Description Soft delete a product (ADMIN only)
+// @Description Soft delete a product (requires product.write)
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-Match header string true "ETag from the last read of the product"
 // @Success 200 {object} response.Response
+// @Failure 412 {object} response.Response
+// @Failure 428 {object} response.Response
 // @Router /api/products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -147,8 +196,17 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	userID := middleware.GetUserID(c)
-	err = h.productService.DeleteProduct(id, userID)
+	err = h.productService.DeleteProduct(id, version, userID, middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrVersionConflict) {
+		response.Error(c, http.StatusPreconditionFailed, repository.ErrVersionConflict.Error(), nil)
+		return
+	}
 	if err != nil {
 		response.BadRequest(c, err.Error(), nil)
 		return
@@ -156,3 +214,65 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 
 	response.Success(c, "Product deleted successfully", nil)
 }
+
+// GetProductRevisions lists a product's revision history
+// @Summary Product revisions
+// @Description List numbered revisions of a product with the fields each one changed, newest first
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} response.Response{data=[]models.ProductRevision}
+// @Router /api/products/{id}/revisions [get]
+func (h *ProductHandler) GetProductRevisions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid product ID", err)
+		return
+	}
+
+	revisions, err := h.productService.GetRevisions(id)
+	if err != nil {
+		response.NotFound(c, "Product not found")
+		return
+	}
+
+	response.Success(c, "Product revisions retrieved successfully", revisions)
+}
+
+// RevertProduct restores an older revision of a product
+// @Summary Revert product
+// @Description Restore name, description, price and cost from an older revision as a new revision (requires product.write; restoring a different price or cost also requires product.price)
+// @Tags products
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param rev path int true "Revision number"
+// @Success 200 {object} response.Response{data=models.Product}
+// @Router /api/products/{id}/revisions/{rev}/revert [post]
+func (h *ProductHandler) RevertProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid product ID", err)
+		return
+	}
+
+	revision, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		response.BadRequest(c, "Invalid revision number", err)
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	canChangePrice := middleware.HasPermission(c, models.PermProductPrice)
+	product, err := h.productService.RevertProduct(id, revision, userID, canChangePrice, middleware.GetAuditContext(c))
+	if errors.Is(err, service.ErrPriceChangeNotAllowed) {
+		response.Forbidden(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	setETag(c, product.Version)
+	response.Success(c, "Product reverted successfully", product)
+}
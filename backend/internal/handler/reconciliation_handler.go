@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type ReconciliationHandler struct {
+	reconService *service.ReconciliationService
+}
+
+func NewReconciliationHandler(reconService *service.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{reconService: reconService}
+}
+
+// Reconcile compares stock with the transaction ledger
+// @Summary Reconcile stock
+// @Description Recompute every product's stock from its transactions and report the products whose stock differs (requires inventory.reconcile).
+// @Description With fix, each mismatch is closed by an ADJUSTMENT transaction posted as the caller; product stock is not changed.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param request body models.ReconcileRequest false "Reconciliation options"
+// @Success 201 {object} response.Response{data=models.StockReconciliation}
+// @Router /api/inventory/reconciliations [post]
+func (h *ReconciliationHandler) Reconcile(c *gin.Context) {
+	var req models.ReconcileRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err)
+			return
+		}
+	}
+
+	run, err := h.reconService.Reconcile(middleware.GetUserID(c), req.Fix, middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrPeriodClosed) {
+		response.Error(c, http.StatusConflict, "Adjustments cannot be posted into a closed accounting period", nil)
+		return
+	}
+	if err != nil {
+		response.InternalServerError(c, "Failed to reconcile stock", err)
+		return
+	}
+
+	response.Created(c, "Stock reconciled", run)
+}
+
+// GetReconciliations lists past reconciliation runs
+// @Summary List reconciliations
+// @Description Reconciliation runs, newest first, without their items (requires inventory.reconcile)
+// @Tags inventory
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} response.Response{data=models.StockReconciliationListResponse}
+// @Router /api/inventory/reconciliations [get]
+func (h *ReconciliationHandler) GetReconciliations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := h.reconService.GetReconciliations(page, limit)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get reconciliations", err)
+		return
+	}
+
+	response.Success(c, "Reconciliations retrieved successfully", result)
+}
+
+// GetReconciliation returns a reconciliation run with its mismatches
+// @Summary Get reconciliation
+// @Description A reconciliation run and the products that did not match (requires inventory.reconcile)
+// @Tags inventory
+// @Produce json
+// @Param id path int true "Reconciliation ID"
+// @Success 200 {object} response.Response{data=models.StockReconciliation}
+// @Failure 404 {object} response.Response
+// @Router /api/inventory/reconciliations/{id} [get]
+func (h *ReconciliationHandler) GetReconciliation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid reconciliation ID", err)
+		return
+	}
+
+	run, err := h.reconService.GetReconciliation(id)
+	if err != nil {
+		response.NotFound(c, "Reconciliation not found")
+		return
+	}
+
+	response.Success(c, "Reconciliation retrieved successfully", run)
+}
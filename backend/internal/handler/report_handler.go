@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// GetMovementReport sums stock movements
+// @Summary Movement report
+// @Description Sales and purchase quantities and amounts within the caller's stores, optionally grouped.
+// @Description Cost and margin are included for callers with report.view_cost.
+// @Tags reports
+// @Produce json
+// @Param group_by query string false "store, product, user, type, day, week or month; omit for totals only"
+// @Param from query string false "Start time, RFC 3339 or YYYY-MM-DD (inclusive)"
+// @Param to query string false "End time, RFC 3339 or YYYY-MM-DD (a date includes the whole day)"
+// @Param store_id query int false "Store ID"
+// @Param product_id query int false "Product ID"
+// @Param type query string false "INCREASE, DECREASE, ADJUSTMENT or OPENING_BALANCE"
+// @Success 200 {object} response.Response{data=models.Report}
+// @Router /api/reports/movements [get]
+func (h *ReportHandler) GetMovementReport(c *gin.Context) {
+	filter, ok := parseReportFilter(c)
+	if !ok {
+		return
+	}
+
+	if filter.GroupBy != "" && !repository.ValidReportGroup(filter.GroupBy) {
+		response.BadRequest(c, "Invalid group_by, use store, product, user, type, day, week or month", nil)
+		return
+	}
+
+	report, err := h.reportService.MovementReport(filter, middleware.GetStoreScope(c), middleware.HasPermission(c, models.PermReportViewCost))
+	if err != nil {
+		response.InternalServerError(c, "Failed to build report", err)
+		return
+	}
+
+	response.Success(c, "Report generated successfully", report)
+}
+
+// parseReportFilter reads the parameters shared by the report endpoints,
+// answering 400 or 403 and returning false when one is unusable
+func parseReportFilter(c *gin.Context) (*models.ReportFilter, bool) {
+	filter := &models.ReportFilter{
+		GroupBy: strings.ToLower(c.Query("group_by")),
+		Type:    strings.ToUpper(c.Query("type")),
+	}
+
+	switch filter.Type {
+	case "", "INCREASE", "DECREASE", "ADJUSTMENT", "OPENING_BALANCE":
+	default:
+		response.BadRequest(c, "Invalid type filter, use INCREASE, DECREASE, ADJUSTMENT or OPENING_BALANCE", nil)
+		return nil, false
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, _, err := parseTimeQuery(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid from filter", err)
+			return nil, false
+		}
+		filter.From = &from
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, dateOnly, err := parseTimeQuery(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid to filter", err)
+			return nil, false
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	if v := c.Query("store_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid store_id filter", err)
+			return nil, false
+		}
+		if !middleware.GetStoreScope(c).Allows(id) {
+			response.Error(c, http.StatusForbidden, "You do not have access to this store", nil)
+			return nil, false
+		}
+		filter.StoreID = id
+	}
+
+	if v := c.Query("product_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid product_id filter", err)
+			return nil, false
+		}
+		filter.ProductID = id
+	}
+
+	return filter, true
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type RoleHandler struct {
+	roleService *service.RoleService
+}
+
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+// GetPermissions lists grantable permissions
+// @Summary List permissions
+// @Tags roles
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.Permission}
+// @Router /api/permissions [get]
+func (h *RoleHandler) GetPermissions(c *gin.Context) {
+	permissions, err := h.roleService.GetPermissions()
+	if err != nil {
+		response.InternalServerError(c, "Failed to get permissions", err)
+		return
+	}
+
+	response.Success(c, "Permissions retrieved successfully", permissions)
+}
+
+// GetRoles lists roles
+// @Summary List roles
+// @Tags roles
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.Role}
+// @Router /api/roles [get]
+func (h *RoleHandler) GetRoles(c *gin.Context) {
+	roles, err := h.roleService.GetRoles()
+	if err != nil {
+		response.InternalServerError(c, "Failed to get roles", err)
+		return
+	}
+
+	response.Success(c, "Roles retrieved successfully", roles)
+}
+
+// GetRole retrieves a role by ID
+// @Summary Get role
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} response.Response{data=models.Role}
+// @Router /api/roles/{id} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID", err)
+		return
+	}
+
+	role, err := h.roleService.GetRoleByID(id)
+	if err != nil {
+		response.NotFound(c, "Role not found")
+		return
+	}
+
+	response.Success(c, "Role retrieved successfully", role)
+}
+
+// CreateRole creates a role
+// @Summary Create role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param request body models.CreateRoleRequest true "Role data"
+// @Success 201 {object} response.Response{data=models.Role}
+// @Router /api/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	role, err := h.roleService.CreateRole(&req, middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Role created successfully", role)
+}
+
+// UpdateRole updates a role and its permissions
+// @Summary Update role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Param request body models.UpdateRoleRequest true "Role data"
+// @Success 200 {object} response.Response{data=models.Role}
+// @Router /api/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID", err)
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(id, &req, middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Role updated successfully", role)
+}
+
+// DeleteRole deletes a custom role
+// @Summary Delete role
+// @Tags roles
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} response.Response
+// @Router /api/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID", err)
+		return
+	}
+
+	if err := h.roleService.DeleteRole(id, middleware.GetAuditContext(c)); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Role deleted successfully", nil)
+}
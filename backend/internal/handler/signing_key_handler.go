@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/jwt"
+	"pos-backoffice/pkg/response"
+)
+
+type SigningKeyHandler struct {
+	signingKeyService *service.SigningKeyService
+}
+
+func NewSigningKeyHandler(signingKeyService *service.SigningKeyService) *SigningKeyHandler {
+	return &SigningKeyHandler{
+		signingKeyService: signingKeyService,
+	}
+}
+
+// JWKS publishes the public keys that validate access tokens
+// @Summary JSON Web Key Set
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwt.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *SigningKeyHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwt.PublicKeys())
+}
+
+// GetSigningKeys lists signing keys
+// @Summary List signing keys
+// @Tags signing-keys
+// @Produce json
+// @Success 200 {object} response.Response{data=[]models.SigningKey}
+// @Router /api/signing-keys [get]
+func (h *SigningKeyHandler) GetSigningKeys(c *gin.Context) {
+	keys, err := h.signingKeyService.GetSigningKeys()
+	if err != nil {
+		response.InternalServerError(c, "Failed to get signing keys", err)
+		return
+	}
+
+	response.Success(c, "Signing keys retrieved successfully", keys)
+}
+
+// RotateSigningKey generates a new active signing key
+// @Summary Rotate signing key
+// @Description The previous key keeps validating tokens for JWT_KEY_GRACE_PERIOD
+// @Tags signing-keys
+// @Accept json
+// @Produce json
+// @Param request body models.RotateSigningKeyRequest false "Algorithm, defaults to JWT_ALGORITHM"
+// @Success 201 {object} response.Response{data=models.SigningKey}
+// @Router /api/signing-keys/rotate [post]
+func (h *SigningKeyHandler) RotateSigningKey(c *gin.Context) {
+	var req models.RotateSigningKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request body", err)
+			return
+		}
+	}
+
+	key, err := h.signingKeyService.Rotate(&req, middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Signing key rotated successfully", key)
+}
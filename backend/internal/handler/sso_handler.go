@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+// ssoStateCookie ties a callback to the browser that started the login
+const (
+	ssoStateCookie = "oidc_state"
+	ssoCookiePath  = "/api/auth/oidc"
+)
+
+type SSOHandler struct {
+	ssoService *service.SSOService
+}
+
+func NewSSOHandler(ssoService *service.SSOService) *SSOHandler {
+	return &SSOHandler{
+		ssoService: ssoService,
+	}
+}
+
+// Login redirects the browser to the identity provider and remembers the
+// login's state in a short-lived HttpOnly cookie
+// @Summary Start single sign-on
+// @Tags auth
+// @Success 302
+// @Router /api/auth/oidc/login [get]
+func (h *SSOHandler) Login(c *gin.Context) {
+	if !h.ssoService.Enabled() {
+		response.NotFound(c, "Single sign-on is not configured")
+		return
+	}
+
+	authURL, state, err := h.ssoService.BeginLogin(c.Request.Context())
+	if err != nil {
+		response.InternalServerError(c, "Failed to start single sign-on", err)
+		return
+	}
+
+	// Lax, as the provider sends the browser back with a cross-site redirect
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, state, int(service.SSOLoginTTL.Seconds()), ssoCookiePath, "", secureRequest(c), true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback handles the identity provider's redirect. The state must match the
+// cookie set by Login. With OIDC_FRONTEND_URL set the browser is sent there
+// with a one-time code; otherwise the tokens are returned directly.
+// @Summary Single sign-on callback
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} response.Response{data=models.LoginResponse}
+// @Success 302
+// @Router /api/auth/oidc/callback [get]
+func (h *SSOHandler) Callback(c *gin.Context) {
+	// The state is single-use either way
+	browserState, _ := c.Cookie(ssoStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, "", -1, ssoCookiePath, "", secureRequest(c), true)
+
+	if providerError := c.Query("error"); providerError != "" {
+		h.fail(c, "Identity provider returned "+providerError)
+		return
+	}
+
+	handoff, err := h.ssoService.HandleCallback(c.Request.Context(), c.Query("code"), c.Query("state"), browserState, middleware.GetAuditContext(c))
+	if err != nil {
+		h.fail(c, err.Error())
+		return
+	}
+
+	if frontendURL := config.AppConfig.OIDCFrontendURL; frontendURL != "" {
+		c.Redirect(http.StatusFound, frontendURL+"?code="+url.QueryEscape(handoff))
+		return
+	}
+
+	loginResp, err := h.ssoService.ExchangeHandoff(handoff, middleware.GetAuditContext(c))
+	h.respond(c, loginResp, err)
+}
+
+// Exchange trades the one-time SSO code for tokens, or for an MFA token when
+// the user must also pass two-factor authentication
+// @Summary Complete single sign-on
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.SSOExchangeRequest true "Code from the callback redirect"
+// @Success 200 {object} response.Response{data=models.LoginResponse}
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /api/auth/oidc/exchange [post]
+func (h *SSOHandler) Exchange(c *gin.Context) {
+	var req models.SSOExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	loginResp, err := h.ssoService.ExchangeHandoff(req.Code, middleware.GetAuditContext(c))
+	h.respond(c, loginResp, err)
+}
+
+// respond answers a handoff exchange the way /api/auth/login answers
+func (h *SSOHandler) respond(c *gin.Context, loginResp *models.LoginResponse, err error) {
+	if err != nil {
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", fmt.Sprint(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			response.Error(c, http.StatusTooManyRequests, err.Error(), nil)
+			return
+		}
+		response.Unauthorized(c, err.Error())
+		return
+	}
+
+	if loginResp.MFARequired {
+		response.Success(c, "Two-factor authentication required", loginResp)
+		return
+	}
+
+	response.Success(c, "Login successful", loginResp)
+}
+
+func (h *SSOHandler) fail(c *gin.Context, message string) {
+	if frontendURL := config.AppConfig.OIDCFrontendURL; frontendURL != "" {
+		c.Redirect(http.StatusFound, frontendURL+"?error="+url.QueryEscape(message))
+		return
+	}
+	response.Unauthorized(c, message)
+}
+
+// secureRequest reports whether the browser reached us over HTTPS, directly
+// or through a TLS-terminating proxy
+func secureRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"pos-backoffice/internal/middleware"
 	"pos-backoffice/internal/models"
 	"pos-backoffice/internal/repository"
 	"pos-backoffice/pkg/response"
@@ -12,17 +14,17 @@ import (
 )
 
 type StoreHandler struct {
-	storeRepo *repository.StoreRepository
+	storeRepo repository.StoreRepository
 }
 
-func NewStoreHandler(storeRepo *repository.StoreRepository) *StoreHandler {
+func NewStoreHandler(storeRepo repository.StoreRepository) *StoreHandler {
 	return &StoreHandler{storeRepo: storeRepo}
 }
 
-// GetStores returns all stores
+// GetStores returns all stores the caller has access to
 func (h *StoreHandler) GetStores(c *gin.Context) {
 	search := c.Query("search")
-	stores, err := h.storeRepo.GetAll(search)
+	stores, err := h.storeRepo.GetAll(search, middleware.GetStoreScope(c))
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to fetch stores", err)
 		return
@@ -39,12 +41,19 @@ func (h *StoreHandler) GetStore(c *gin.Context) {
 		return
 	}
 
+	// Stores outside the caller's scope are reported as missing
+	if !middleware.GetStoreScope(c).Allows(id) {
+		response.Error(c, http.StatusNotFound, "Store not found", nil)
+		return
+	}
+
 	store, err := h.storeRepo.GetByID(id)
 	if err != nil {
 		response.Error(c, http.StatusNotFound, "Store not found", err)
 		return
 	}
 
+	setETag(c, store.Version)
 	response.Success(c, "Store retrieved successfully", store)
 }
 
@@ -72,12 +81,13 @@ func (h *StoreHandler) CreateStore(c *gin.Context) {
 		store.Status = "ACTIVE"
 	}
 
-	err := h.storeRepo.Create(store)
+	err := h.storeRepo.Create(store, middleware.GetAuditContext(c))
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to create store", err)
 		return
 	}
 
+	setETag(c, store.Version)
 	response.Success(c, "Store created successfully", store)
 }
 
@@ -95,6 +105,16 @@ func (h *StoreHandler) UpdateStore(c *gin.Context) {
 		return
 	}
 
+	if !middleware.GetStoreScope(c).Allows(id) {
+		response.Error(c, http.StatusNotFound, "Store not found", nil)
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	userID := c.GetInt64("user_id")
 
 	store := &models.Store{
@@ -105,14 +125,20 @@ func (h *StoreHandler) UpdateStore(c *gin.Context) {
 		Phone:     req.Phone,
 		Status:    req.Status,
 		UpdatedBy: userID,
+		Version:   version,
 	}
 
-	err = h.storeRepo.Update(store)
+	err = h.storeRepo.Update(store, middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrVersionConflict) {
+		response.Error(c, http.StatusPreconditionFailed, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to update store", err)
 		return
 	}
 
+	setETag(c, store.Version)
 	response.Success(c, "Store updated successfully", store)
 }
 
@@ -124,7 +150,21 @@ func (h *StoreHandler) DeleteStore(c *gin.Context) {
 		return
 	}
 
-	err = h.storeRepo.Delete(id)
+	if !middleware.GetStoreScope(c).Allows(id) {
+		response.Error(c, http.StatusNotFound, "Store not found", nil)
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = h.storeRepo.Delete(id, c.GetInt64("user_id"), version, middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrVersionConflict) {
+		response.Error(c, http.StatusPreconditionFailed, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to delete store", err)
 		return
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"pos-backoffice/internal/middleware"
 	"pos-backoffice/internal/models"
 	"pos-backoffice/internal/repository"
 	"pos-backoffice/pkg/response"
@@ -12,11 +15,11 @@ import (
 )
 
 type TransactionHandler struct {
-	transactionRepo *repository.TransactionRepository
-	productRepo     *repository.ProductRepository
+	transactionRepo repository.TransactionRepository
+	productRepo     repository.ProductRepository
 }
 
-func NewTransactionHandler(transactionRepo *repository.TransactionRepository, productRepo *repository.ProductRepository) *TransactionHandler {
+func NewTransactionHandler(transactionRepo repository.TransactionRepository, productRepo repository.ProductRepository) *TransactionHandler {
 	return &TransactionHandler{
 		transactionRepo: transactionRepo,
 		productRepo:     productRepo,
@@ -43,6 +46,13 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
+	// Validate: store must be one the caller is assigned to. INCREASE is a
+	// warehouse receipt with no store, which stock.adjust alone allows.
+	if req.StoreID != nil && !middleware.GetStoreScope(c).Allows(*req.StoreID) {
+		response.Error(c, http.StatusForbidden, "You do not have access to this store", nil)
+		return
+	}
+
 	// Check if product exists and has enough stock for DECREASE
 	if req.TransactionType == "DECREASE" {
 		product, err := h.productRepo.FindByID(req.ProductID)
@@ -68,9 +78,14 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		TotalAmount:     req.UnitPrice * float64(req.Quantity),
 		Notes:           req.Notes,
 		CreatedBy:       userID,
+		ImpersonatedBy:  middleware.GetImpersonatorID(c),
 	}
 
-	err := h.transactionRepo.Create(transaction)
+	err := h.transactionRepo.Create(transaction, middleware.GetAuditContext(c))
+	if errors.Is(err, repository.ErrPeriodClosed) {
+		response.Error(c, http.StatusConflict, "Transactions cannot be posted into a closed accounting period", nil)
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to create transaction", err)
 		return
@@ -79,30 +94,44 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	response.Success(c, "Transaction created successfully", transaction)
 }
 
-// GetTransactions returns all transactions with pagination
+// GetTransactions returns transactions for the caller's stores with pagination
+// @Summary List transactions
+// @Description Filtered, sorted and paginated stock movements within the caller's stores.
+// @Description Passing cursor (empty for the first page) switches to keyset pagination in date order.
+// @Tags transactions
+// @Produce json
+// @Param from query string false "Start time, RFC 3339 or YYYY-MM-DD (inclusive)"
+// @Param to query string false "End time, RFC 3339 or YYYY-MM-DD (a date includes the whole day)"
+// @Param type query string false "INCREASE, DECREASE, ADJUSTMENT or OPENING_BALANCE"
+// @Param product_id query int false "Product ID"
+// @Param store_id query int false "Store ID"
+// @Param user_id query int false "User who posted the transaction"
+// @Param min_amount query number false "Minimum total amount"
+// @Param max_amount query number false "Maximum total amount"
+// @Param sort query string false "date, amount, quantity, product, store or type" default(date)
+// @Param order query string false "asc or desc" default(desc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param include_total query bool false "Count all matches in cursor mode"
+// @Success 200 {object} response.Response{data=models.TransactionListResponse}
+// @Router /api/transactions [get]
 func (h *TransactionHandler) GetTransactions(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-
-	transactions, total, err := h.transactionRepo.GetAll(page, limit)
-	if err != nil {
-		// Log the actual error for debugging
-		println("Error fetching transactions:", err.Error())
-		response.Error(c, http.StatusInternalServerError, "Failed to fetch transactions", err)
+	filter, ok := parseTransactionFilter(c, 20)
+	if !ok {
 		return
 	}
 
-	result := map[string]interface{}{
-		"transactions": transactions,
-		"total":        total,
-		"page":         page,
-		"limit":        limit,
+	if filter.StoreID != 0 && !middleware.GetStoreScope(c).Allows(filter.StoreID) {
+		response.Error(c, http.StatusForbidden, "You do not have access to this store", nil)
+		return
 	}
 
-	response.Success(c, "Transactions retrieved successfully", result)
+	h.listTransactions(c, filter, middleware.GetStoreScope(c))
 }
 
-// GetTransactionsByProduct returns transactions for a specific product
+// GetTransactionsByProduct returns transactions for a specific product. It
+// takes the same filters and pagination as GetTransactions.
 func (h *TransactionHandler) GetTransactionsByProduct(c *gin.Context) {
 	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
 	if err != nil {
@@ -110,18 +139,17 @@ func (h *TransactionHandler) GetTransactionsByProduct(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-
-	transactions, err := h.transactionRepo.GetByProductID(productID, limit)
-	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to fetch transactions", err)
+	filter, ok := parseTransactionFilter(c, 50)
+	if !ok {
 		return
 	}
+	filter.ProductID = productID
 
-	response.Success(c, "Transactions retrieved successfully", transactions)
+	h.listTransactions(c, filter, middleware.GetStoreScope(c))
 }
 
-// GetTransactionsByStore returns transactions for a specific store
+// GetTransactionsByStore returns transactions for a specific store. It takes
+// the same filters and pagination as GetTransactions.
 func (h *TransactionHandler) GetTransactionsByStore(c *gin.Context) {
 	storeID, err := strconv.ParseInt(c.Param("store_id"), 10, 64)
 	if err != nil {
@@ -129,13 +157,161 @@ func (h *TransactionHandler) GetTransactionsByStore(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if !middleware.GetStoreScope(c).Allows(storeID) {
+		response.Error(c, http.StatusForbidden, "You do not have access to this store", nil)
+		return
+	}
+
+	filter, ok := parseTransactionFilter(c, 50)
+	if !ok {
+		return
+	}
+	filter.StoreID = storeID
+
+	h.listTransactions(c, filter, nil)
+}
+
+// listTransactions answers with a cursor page when the request has a cursor
+// parameter and with a numbered page otherwise
+func (h *TransactionHandler) listTransactions(c *gin.Context, filter *models.TransactionFilter, scope *models.StoreScope) {
+	cursor, keyset := c.GetQuery("cursor")
+	if !keyset {
+		transactions, total, err := h.transactionRepo.GetAll(filter, scope)
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "Failed to fetch transactions", err)
+			return
+		}
 
-	transactions, err := h.transactionRepo.GetByStoreID(storeID, limit)
+		response.Success(c, "Transactions retrieved successfully", models.TransactionListResponse{
+			Transactions: transactions,
+			Total:        total,
+			Page:         filter.Page,
+			Limit:        filter.Limit,
+		})
+		return
+	}
+
+	if filter.Sort != "date" {
+		response.BadRequest(c, "Cursor pagination only supports sort=date", nil)
+		return
+	}
+	includeTotal, _ := strconv.ParseBool(c.Query("include_total"))
+
+	transactions, next, total, err := h.transactionRepo.GetPage(filter, cursor, includeTotal, scope)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "Failed to fetch transactions", err)
 		return
 	}
 
-	response.Success(c, "Transactions retrieved successfully", transactions)
+	response.Success(c, "Transactions retrieved successfully", models.TransactionPage{
+		Transactions: transactions,
+		NextCursor:   next,
+		Limit:        filter.Limit,
+		Total:        total,
+	})
+}
+
+// parseTransactionFilter reads the ledger filters shared by the transaction
+// list endpoints, answering 400 and returning false when one is malformed
+func parseTransactionFilter(c *gin.Context, defaultLimit int) (*models.TransactionFilter, bool) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = defaultLimit
+	}
+
+	filter := &models.TransactionFilter{
+		Type:  strings.ToUpper(c.Query("type")),
+		Sort:  c.DefaultQuery("sort", "date"),
+		Page:  page,
+		Limit: limit,
+	}
+
+	switch filter.Type {
+	case "", "INCREASE", "DECREASE", "ADJUSTMENT", "OPENING_BALANCE":
+	default:
+		response.BadRequest(c, "Invalid type filter, use INCREASE, DECREASE, ADJUSTMENT or OPENING_BALANCE", nil)
+		return nil, false
+	}
+
+	if !repository.ValidTransactionSort(filter.Sort) {
+		response.BadRequest(c, "Invalid sort, use date, amount, quantity, product, store or type", nil)
+		return nil, false
+	}
+
+	switch strings.ToLower(c.DefaultQuery("order", "desc")) {
+	case "desc":
+		filter.Desc = true
+	case "asc":
+	default:
+		response.BadRequest(c, "Invalid order, use asc or desc", nil)
+		return nil, false
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, _, err := parseTimeQuery(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid from filter", err)
+			return nil, false
+		}
+		filter.From = &from
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, dateOnly, err := parseTimeQuery(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid to filter", err)
+			return nil, false
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	ids := []struct {
+		name string
+		dest *int64
+	}{
+		{"product_id", &filter.ProductID},
+		{"store_id", &filter.StoreID},
+		{"user_id", &filter.UserID},
+	}
+	for _, id := range ids {
+		if v := c.Query(id.name); v != "" {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				response.BadRequest(c, "Invalid "+id.name+" filter", err)
+				return nil, false
+			}
+			*id.dest = parsed
+		}
+	}
+
+	amounts := []struct {
+		name string
+		dest **float64
+	}{
+		{"min_amount", &filter.MinAmount},
+		{"max_amount", &filter.MaxAmount},
+	}
+	for _, amount := range amounts {
+		if v := c.Query(amount.name); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				response.BadRequest(c, "Invalid "+amount.name+" filter", err)
+				return nil, false
+			}
+			*amount.dest = &parsed
+		}
+	}
+
+	return filter, true
 }
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+type UserHandler struct {
+	userService *service.UserService
+}
+
+func NewUserHandler(userService *service.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+// UpdateUserStatus activates or deactivates a user
+// @Summary Update user status
+// @Description Activate or deactivate a user; deactivation revokes all sessions (requires user.manage)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.UpdateUserStatusRequest true "New status"
+// @Success 200 {object} response.Response{data=models.User}
+// @Router /api/users/{id}/status [put]
+func (h *UserHandler) UpdateUserStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err)
+		return
+	}
+
+	var req models.UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	user, err := h.userService.UpdateStatus(id, &req, middleware.GetUserID(c), middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "User status updated successfully", user)
+}
+
+// UpdateUserRole assigns a role to a user
+// @Summary Update user role
+// @Description Assign a role to a user; the caller must hold every permission of the role. The user's sessions are revoked
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.UpdateUserRoleRequest true "Role code"
+// @Success 200 {object} response.Response{data=models.User}
+// @Router /api/users/{id}/role [put]
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err)
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	user, err := h.userService.UpdateRole(id, &req, middleware.GetUserID(c), middleware.GetPermissions(c), middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "User role updated successfully", user)
+}
+
+// GetUserStores lists the stores assigned to a user
+// @Summary Get user stores
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.Response{data=[]int64}
+// @Router /api/users/{id}/stores [get]
+func (h *UserHandler) GetUserStores(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err)
+		return
+	}
+
+	storeIDs, err := h.userService.GetStores(id)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "User stores retrieved successfully", storeIDs)
+}
+
+// UpdateUserStores replaces the stores assigned to a user
+// @Summary Update user stores
+// @Description Replace a user's store assignments; the user's sessions are revoked
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.UpdateUserStoresRequest true "Store IDs"
+// @Success 200 {object} response.Response{data=[]int64}
+// @Router /api/users/{id}/stores [put]
+func (h *UserHandler) UpdateUserStores(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err)
+		return
+	}
+
+	var req models.UpdateUserStoresRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	storeIDs, err := h.userService.UpdateStores(id, &req, middleware.GetAuditContext(c))
+	if err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "User stores updated successfully", storeIDs)
+}
+
+// UnlockUser clears a user's failed login attempts and lockout
+// @Summary Unlock user
+// @Description Clear failed login attempts and lockout for a user (requires user.manage)
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} response.Response
+// @Router /api/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", err)
+		return
+	}
+
+	if err := h.userService.Unlock(id, middleware.GetAuditContext(c)); err != nil {
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "User unlocked successfully", nil)
+}
+
+// GetLoginHistory lists login attempts
+// @Summary Login history
+// @Description List login attempts with optional filters (requires user.manage)
+// @Tags users
+// @Produce json
+// @Param username query string false "Username"
+// @Param ip_address query string false "IP address"
+// @Param success query bool false "Only successful or failed attempts"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size" default(20)
+// @Success 200 {object} response.Response{data=models.LoginHistoryListResponse}
+// @Router /api/users/login-history [get]
+func (h *UserHandler) GetLoginHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	filter := &models.LoginHistoryFilter{
+		Username:  c.Query("username"),
+		IPAddress: c.Query("ip_address"),
+		Page:      page,
+		Limit:     limit,
+	}
+
+	if v := c.Query("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid success filter", err)
+			return
+		}
+		filter.Success = &success
+	}
+
+	result, err := h.userService.GetLoginHistory(filter)
+	if err != nil {
+		response.InternalServerError(c, "Failed to get login history", err)
+		return
+	}
+
+	response.Success(c, "Login history retrieved successfully", result)
+}
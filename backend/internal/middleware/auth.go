@@ -4,13 +4,28 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
 	"pos-backoffice/pkg/jwt"
 	"pos-backoffice/pkg/response"
 )
 
-// AuthMiddleware validates JWT token
-func AuthMiddleware() gin.HandlerFunc {
+// RevocationChecker reports whether an access token, or the session it was
+// issued for, has been revoked. repository.SessionRepository implements it.
+type RevocationChecker interface {
+	IsTokenRevoked(tokenID string, sessionID string) (bool, error)
+}
+
+// AuthMiddleware authenticates the request with either a Bearer JWT or an
+// API key ("Authorization: ApiKey <key>" or the X-API-Key header) and rejects
+// revoked credentials
+func AuthMiddleware(revocations RevocationChecker, apiKeyService *service.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(c, apiKeyService, apiKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			response.Unauthorized(c, "Authorization header required")
@@ -18,14 +33,19 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Extract token from "Bearer <token>"
+		// Extract token from "Bearer <token>" or "ApiKey <key>"
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "ApiKey") {
 			response.Unauthorized(c, "Invalid authorization header format")
 			c.Abort()
 			return
 		}
 
+		if parts[0] == "ApiKey" {
+			authenticateAPIKey(c, apiKeyService, parts[1])
+			return
+		}
+
 		tokenString := parts[1]
 		claims, err := jwt.ValidateToken(tokenString)
 		if err != nil {
@@ -34,15 +54,79 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		revoked, err := revocations.IsTokenRevoked(claims.ID, claims.SessionID)
+		if err != nil {
+			response.InternalServerError(c, "Failed to verify token", err)
+			c.Abort()
+			return
+		}
+		if revoked {
+			response.Unauthorized(c, "Token has been revoked")
+			c.Abort()
+			return
+		}
+
 		// Store user info in context
+		c.Set("principal_type", models.PrincipalUser)
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("permissions", claims.Permissions)
+		c.Set("store_ids", claims.StoreIDs)
+		c.Set("token_id", claims.ID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+
+		if claims.ImpersonatorID != 0 {
+			c.Set("impersonator_id", claims.ImpersonatorID)
+			c.Set("impersonator_username", claims.ImpersonatorUsername)
+			c.Header("X-Impersonated-By", claims.ImpersonatorUsername)
+		}
 
 		c.Next()
 	}
 }
 
+// authenticateAPIKey validates an API key and stores its grants in context.
+// Actions taken with a key are attributed to the user who created it.
+func authenticateAPIKey(c *gin.Context, apiKeyService *service.APIKeyService, plain string) {
+	key, err := apiKeyService.Authenticate(plain)
+	if err != nil {
+		response.Unauthorized(c, err.Error())
+		c.Abort()
+		return
+	}
+
+	c.Set("principal_type", models.PrincipalAPIKey)
+	c.Set("api_key_id", key.ID)
+	c.Set("user_id", key.CreatedBy)
+	c.Set("username", key.Name)
+	c.Set("role", "")
+	c.Set("permissions", key.Permissions)
+	c.Set("store_ids", key.StoreIDs)
+
+	c.Next()
+}
+
+// RequirePrincipal restricts a route to one kind of principal, e.g. to keep
+// API keys away from endpoints that act on a user's own account
+func RequirePrincipal(principalType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetPrincipalType(c) != principalType {
+			response.Forbidden(c, "Not available for this type of credential")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetPrincipalType reports whether the caller is a user or an API key
+func GetPrincipalType(c *gin.Context) string {
+	return c.GetString("principal_type")
+}
+
 // RequireRole checks if user has required role
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -66,6 +150,49 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
+// RequirePermission checks that the user holds every listed permission
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, permission := range permissions {
+			if !HasPermission(c, permission) {
+				response.Forbidden(c, "Missing permission: "+permission)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// HasPermission reports whether the user holds a permission
+func HasPermission(c *gin.Context, permission string) bool {
+	for _, p := range GetPermissions(c) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPermissions retrieves the user's permissions from context
+func GetPermissions(c *gin.Context) []string {
+	permissions, exists := c.Get("permissions")
+	if !exists {
+		return nil
+	}
+	return permissions.([]string)
+}
+
+// GetStoreScope returns the stores the caller may access
+func GetStoreScope(c *gin.Context) *models.StoreScope {
+	scope := &models.StoreScope{All: HasPermission(c, models.PermStoreAllAccess)}
+	if storeIDs, exists := c.Get("store_ids"); exists {
+		scope.StoreIDs = storeIDs.([]int64)
+	}
+	return scope
+}
+
 // GetUserID retrieves user ID from context
 func GetUserID(c *gin.Context) int64 {
 	userID, exists := c.Get("user_id")
@@ -10,8 +10,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	config := cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Request-ID", "If-Match", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "X-Impersonated-By", "X-Request-ID", "ETag", "Idempotent-Replayed"},
 		AllowCredentials: true,
 	}
 
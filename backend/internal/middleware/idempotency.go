@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+// IdempotencyKeyHeader names the client-chosen key that makes a POST safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency replays the stored response when a POST is retried with the
+// same Idempotency-Key and body, and rejects a key reused for a different
+// request. Requests without the header are processed normally. It must run
+// after authentication because keys are scoped to the caller.
+func Idempotency(idempotencyService *service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			response.BadRequest(c, "Idempotency-Key must be at most 255 characters", nil)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.BadRequest(c, "Failed to read request body", err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		id, replay, err := idempotencyService.Begin(principalKey(c), key, requestHash(c.Request, body))
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyReused), errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			response.Error(c, http.StatusConflict, err.Error(), nil)
+			c.Abort()
+			return
+		case err != nil:
+			response.InternalServerError(c, "Failed to check Idempotency-Key", err)
+			c.Abort()
+			return
+		case replay != nil:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(replay.StatusCode, "application/json; charset=utf-8", []byte(replay.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors and rate limits say nothing about the request itself,
+		// so the key is given up and a retry runs again
+		if status := recorder.Status(); status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			if err := idempotencyService.Release(id); err != nil {
+				c.Error(err)
+			}
+			return
+		}
+		if err := idempotencyService.Complete(id, recorder.Status(), recorder.body.String()); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// principalKey identifies the caller that owns an idempotency key
+func principalKey(c *gin.Context) string {
+	if GetPrincipalType(c) == models.PrincipalAPIKey {
+		return fmt.Sprintf("api_key:%d", c.GetInt64("api_key_id"))
+	}
+	return fmt.Sprintf("user:%d", GetUserID(c))
+}
+
+// requestHash fingerprints what a retry must repeat exactly
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body while writing it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
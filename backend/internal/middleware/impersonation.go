@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/response"
+)
+
+// RecordImpersonation records every write request made with an impersonation
+// token, attributing it to both the impersonator and the impersonated user
+func RecordImpersonation(impersonationService *service.ImpersonationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		impersonatorID := GetImpersonatorID(c)
+		if impersonatorID == nil {
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+
+		impersonationService.RecordAction(&models.ImpersonationAction{
+			SessionID:      c.GetString("session_id"),
+			ImpersonatorID: *impersonatorID,
+			UserID:         GetUserID(c),
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			StatusCode:     c.Writer.Status(),
+			IPAddress:      c.ClientIP(),
+		})
+	}
+}
+
+// RejectImpersonation keeps impersonation tokens away from endpoints that
+// change the impersonated user's own credentials
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetImpersonatorID(c) != nil {
+			response.Forbidden(c, "Not available while impersonating another user")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetImpersonatorID returns the admin acting as the caller, or nil when the
+// caller is not being impersonated
+func GetImpersonatorID(c *gin.Context) *int64 {
+	id, exists := c.Get("impersonator_id")
+	if !exists {
+		return nil
+	}
+	impersonatorID := id.(int64)
+	return &impersonatorID
+}
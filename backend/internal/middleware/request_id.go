@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/pkg/jwt"
+)
+
+// RequestIDHeader carries the ID that ties log and audit entries to a request
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID accepts a well-formed X-Request-ID from the client or generates
+// one, stores it in context and echoes it on the response
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			// a failure leaves the request without an ID rather than failing it
+			requestID, _ = jwt.NewTokenID()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// validRequestID limits client-supplied IDs to a short, printable token so
+// they are safe to store and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// GetRequestID returns the ID of the current request
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+// GetAuditContext describes the caller for the audit log. It works on public
+// routes too, where the actor is unknown.
+func GetAuditContext(c *gin.Context) *models.AuditContext {
+	audit := &models.AuditContext{
+		ImpersonatorID: GetImpersonatorID(c),
+		RequestID:      GetRequestID(c),
+		IPAddress:      c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+	}
+
+	if userID := GetUserID(c); userID != 0 {
+		audit.ActorID = &userID
+	}
+	if id, exists := c.Get("api_key_id"); exists {
+		apiKeyID := id.(int64)
+		audit.APIKeyID = &apiKeyID
+	}
+
+	return audit
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Accounting period states. A month with no row is open.
+const (
+	PeriodOpen   = "OPEN"
+	PeriodClosed = "CLOSED"
+)
+
+// AccountingPeriod is a calendar month of the ledger. No transaction can be
+// posted with a date inside a closed period.
+type AccountingPeriod struct {
+	ID             int64             `json:"id,omitempty"`
+	Period         string            `json:"period"` // YYYY-MM
+	Start          time.Time         `json:"start"`  // inclusive
+	End            time.Time         `json:"end"`    // exclusive
+	Status         string            `json:"status"`
+	StockValue     *float64          `json:"stock_value,omitempty"` // stock valuation at End, taken when closed
+	ClosedBy       *int64            `json:"closed_by,omitempty"`
+	ClosedByName   string            `json:"closed_by_name,omitempty"`
+	ClosedAt       *time.Time        `json:"closed_at,omitempty"`
+	ReopenedBy     *int64            `json:"reopened_by,omitempty"`
+	ReopenedByName string            `json:"reopened_by_name,omitempty"`
+	ReopenedAt     *time.Time        `json:"reopened_at,omitempty"`
+	ReopenReason   string            `json:"reopen_reason,omitempty"`
+	Valuation      []PeriodValuation `json:"valuation,omitempty"`
+}
+
+// PeriodValuation is a product's stock and value at the end of a period
+type PeriodValuation struct {
+	ProductID int64   `json:"product_id"`
+	SKU       string  `json:"sku"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitCost  float64 `json:"unit_cost"`
+	Value     float64 `json:"value"`
+}
+
+// ReopenPeriodRequest reopens a closed period
+type ReopenPeriodRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Principal types stored in the request context by AuthMiddleware
+const (
+	PrincipalUser   = "USER"
+	PrincipalAPIKey = "API_KEY"
+)
+
+// APIKey authenticates a POS terminal or integration client. Only a hash of
+// the key is stored; the plain key is shown once at creation.
+type APIKey struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	KeyPrefix   string     `json:"key_prefix"` // first characters of the key, for identification
+	KeyHash     string     `json:"-"`
+	Permissions []string   `json:"permissions"`
+	StoreIDs    []int64    `json:"store_ids"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy   int64      `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Permissions []string   `json:"permissions" binding:"required,min=1"`
+	StoreIDs    []int64    `json:"store_ids"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey APIKey `json:"api_key"`
+	Key    string `json:"key"` // plain key, returned only once
+}
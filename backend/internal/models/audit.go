@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions. Creates, updates and deletes use the first three; the rest
+// describe changes that are not plain edits of an entity's fields.
+const (
+	AuditCreate         = "CREATE"
+	AuditUpdate         = "UPDATE"
+	AuditDelete         = "DELETE"
+	AuditLogin          = "LOGIN"
+	AuditLoginFailed    = "LOGIN_FAILED"
+	AuditLogout         = "LOGOUT"
+	AuditRevoke         = "REVOKE"
+	AuditRotate         = "ROTATE"
+	AuditUnlock         = "UNLOCK"
+	AuditImpersonate    = "IMPERSONATE"
+	AuditPasswordChange = "PASSWORD_CHANGE"
+	AuditPasswordReset  = "PASSWORD_RESET"
+	AuditClose          = "CLOSE"
+	AuditReopen         = "REOPEN"
+)
+
+// Audited entity types
+const (
+	EntityProduct     = "product"
+	EntityStore       = "store"
+	EntityTransaction = "transaction"
+	EntityUser        = "user"
+	EntityRole        = "role"
+	EntityAPIKey      = "api_key"
+	EntitySigningKey  = "signing_key"
+	EntitySession     = "session"
+	EntityPeriod      = "accounting_period"
+	EntityGLExport    = "gl_export"
+)
+
+// AuditContext identifies who is making a change and from where. It travels
+// from the handler down to the repository, which writes the audit entry in
+// the same transaction as the change.
+type AuditContext struct {
+	ActorID        *int64 // nil for anonymous requests and system changes
+	ImpersonatorID *int64
+	APIKeyID       *int64
+	RequestID      string
+	IPAddress      string
+	UserAgent      string
+}
+
+// AuditEntry is one row of the append-only audit log. Changes maps each
+// changed field to its old and new value.
+type AuditEntry struct {
+	ID             int64           `json:"id"`
+	ActorID        *int64          `json:"actor_id"`
+	ActorUsername  string          `json:"actor_username,omitempty"`
+	ImpersonatorID *int64          `json:"impersonator_id,omitempty"`
+	APIKeyID       *int64          `json:"api_key_id,omitempty"`
+	EntityType     string          `json:"entity_type"`
+	EntityID       string          `json:"entity_id"`
+	Action         string          `json:"action"`
+	Changes        json.RawMessage `json:"changes,omitempty"`
+	RequestID      string          `json:"request_id,omitempty"`
+	IPAddress      string          `json:"ip_address,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// FieldChange is the value of a field before and after a change; Old is
+// absent for creates and New for deletes
+type FieldChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+type AuditFilter struct {
+	ActorID    int64
+	EntityType string
+	EntityID   string
+	Action     string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
+}
+
+type AuditListResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Total   int          `json:"total"`
+	Page    int          `json:"page"`
+	Limit   int          `json:"limit"`
+}
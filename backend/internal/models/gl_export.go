@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// GL account roles a movement is posted to. config.GLAccounts maps each to
+// an account number in the chart of accounts.
+const (
+	GLInventory     = "inventory"
+	GLPayable       = "payable"
+	GLReceivable    = "receivable"
+	GLRevenue       = "revenue"
+	GLCOGS          = "cogs"
+	GLShrinkage     = "shrinkage"
+	GLOpeningEquity = "opening_equity"
+)
+
+// GLMovement is the ledger of one store for a period, summed per movement
+// type and direction (-1 for negative ADJUSTMENT quantities, 1 otherwise)
+type GLMovement struct {
+	StoreID         *int64
+	StoreCode       string
+	TransactionType string
+	Direction       int
+	Count           int
+	Amount          float64 // total_amount, always positive
+	Cost            float64 // quantity at the unit cost when posted, always positive
+}
+
+// JournalLine is one debit or credit of a general-ledger journal. There is
+// a journal per store, plus the warehouse journal for movements without one.
+type JournalLine struct {
+	LineNo      int     `json:"line_no"`
+	Journal     string  `json:"journal"`
+	StoreID     *int64  `json:"store_id"`
+	Account     string  `json:"account"`
+	AccountRole string  `json:"account_role"`
+	Description string  `json:"description"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+}
+
+// GLExport is the manifest entry for an exported period. Lines are only
+// filled when the export itself is requested.
+type GLExport struct {
+	ID             int64         `json:"id"`
+	Period         string        `json:"period"`
+	PeriodClosedAt time.Time     `json:"period_closed_at"`
+	JournalCount   int           `json:"journal_count"`
+	LineCount      int           `json:"line_count"`
+	TotalDebit     float64       `json:"total_debit"`
+	TotalCredit    float64       `json:"total_credit"`
+	Checksum       string        `json:"checksum"` // SHA-256 of the CSV export
+	ExportedBy     int64         `json:"exported_by"`
+	ExportedByName string        `json:"exported_by_name,omitempty"`
+	ExportedAt     time.Time     `json:"exported_at"`
+	Lines          []JournalLine `json:"lines,omitempty"`
+}
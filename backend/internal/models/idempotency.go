@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers the response to a POST sent with an
+// Idempotency-Key so that retries of the same request can be replayed
+type IdempotencyRecord struct {
+	ID           int64
+	Principal    string // "user:<id>" or "api_key:<id>"; keys are scoped to the caller
+	KeyHash      string
+	RequestHash  string // method, path and body of the first request
+	StatusCode   int    // zero while the first request is still being processed
+	ResponseBody string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// ImpersonationResponse carries a short-lived access token that acts as the
+// target user. There is no refresh token; impersonation ends when the token
+// expires or is logged out.
+type ImpersonationResponse struct {
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	User         User      `json:"user"`
+	Impersonator User      `json:"impersonator"`
+	Permissions  []string  `json:"permissions"`
+	StoreIDs     []int64   `json:"store_ids"`
+}
+
+// ImpersonationAction records a write request made during impersonation, so
+// it is attributed to both the impersonator and the impersonated user
+type ImpersonationAction struct {
+	ID                   int64     `json:"id"`
+	SessionID            string    `json:"session_id"`
+	ImpersonatorID       int64     `json:"impersonator_id"`
+	ImpersonatorUsername string    `json:"impersonator_username,omitempty"`
+	UserID               int64     `json:"user_id"`
+	Username             string    `json:"username,omitempty"`
+	Method               string    `json:"method"`
+	Path                 string    `json:"path"`
+	StatusCode           int       `json:"status_code"`
+	IPAddress            string    `json:"ip_address,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+type ImpersonationActionFilter struct {
+	ImpersonatorID int64
+	UserID         int64
+	Page           int
+	Limit          int
+}
+
+type ImpersonationActionListResponse struct {
+	Actions []ImpersonationAction `json:"actions"`
+	Total   int                   `json:"total"`
+	Page    int                   `json:"page"`
+	Limit   int                   `json:"limit"`
+}
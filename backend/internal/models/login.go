@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// LoginThrottle tracks consecutive failed logins for a username or IP address
+type LoginThrottle struct {
+	Key          string     `json:"key"` // "user:<username>" or "ip:<address>"
+	FailedCount  int        `json:"failed_count"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+// LoginHistory records a single login attempt, successful or not
+type LoginHistory struct {
+	ID            int64     `json:"id"`
+	Username      string    `json:"username"`
+	UserID        *int64    `json:"user_id"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	RequestID     string    `json:"request_id,omitempty"`
+	AttemptedAt   time.Time `json:"attempted_at"`
+}
+
+type LoginHistoryFilter struct {
+	Username  string
+	IPAddress string
+	Success   *bool
+	Page      int
+	Limit     int
+}
+
+type LoginHistoryListResponse struct {
+	History []LoginHistory `json:"history"`
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// UserMFA holds a user's TOTP enrollment. EnabledAt is nil while an
+// enrollment has been started but not yet confirmed with a code.
+type UserMFA struct {
+	UserID       int64
+	Secret       string
+	EnabledAt    *time.Time
+	LastUsedStep int64 // last accepted TOTP time step, to reject replayed codes
+	CreatedAt    time.Time
+}
+
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"` // enforced by the user's role
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFACodeRequest carries a 6-digit TOTP code or, where accepted, a recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFALoginRequest completes a login started with a password
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
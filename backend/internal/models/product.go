@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Product struct {
 	ID          int64     `json:"id"`
@@ -15,6 +18,7 @@ type Product struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	CreatedBy   int64     `json:"created_by"`
 	UpdatedBy   int64     `json:"updated_by"`
+	Version     int       `json:"version"` // incremented by every edit; served as the ETag
 }
 
 type CreateProductRequest struct {
@@ -40,3 +44,31 @@ type ProductListResponse struct {
 	PageSize   int       `json:"page_size"`
 	TotalPages int       `json:"total_pages"`
 }
+
+// ProductPage is one page of a cursor-paginated product list. Total is only
+// present when the client asked for it.
+type ProductPage struct {
+	Products   []Product `json:"products"`
+	NextCursor string    `json:"next_cursor"` // empty on the last page
+	PageSize   int       `json:"page_size"`
+	Total      *int64    `json:"total,omitempty"`
+}
+
+// ProductRevision is a numbered snapshot of a product's editable fields.
+// Changes holds the field-level diff against the previous revision.
+type ProductRevision struct {
+	ID            int64           `json:"id"`
+	ProductID     int64           `json:"product_id"`
+	Revision      int             `json:"revision"`
+	SKU           string          `json:"sku"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Price         float64         `json:"price"`
+	Cost          float64         `json:"cost"`
+	Status        string          `json:"status"`
+	Changes       json.RawMessage `json:"changes,omitempty"`
+	RevertedFrom  *int            `json:"reverted_from,omitempty"` // revision restored by this one
+	CreatedBy     int64           `json:"created_by"`
+	CreatedByName string          `json:"created_by_name,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// StockReconciliation is one run of the ledger-versus-balance check
+type StockReconciliation struct {
+	ID              int64           `json:"id"`
+	Fix             bool            `json:"fix"` // correcting adjustments were posted
+	ProductsChecked int             `json:"products_checked"`
+	Mismatches      int             `json:"mismatches"`
+	RunBy           *int64          `json:"run_by"` // NULL for scheduled runs
+	RunByName       string          `json:"run_by_name,omitempty"`
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      *time.Time      `json:"finished_at"`
+	Items           []StockMismatch `json:"items,omitempty"`
+}
+
+// StockMismatch is a product whose stock differs from the sum of its ledger
+type StockMismatch struct {
+	ProductID    int64  `json:"product_id"`
+	SKU          string `json:"sku"`
+	ProductName  string `json:"product_name"`
+	Stock        int    `json:"stock"`        // products.stock
+	LedgerStock  int    `json:"ledger_stock"` // sum of the product's transactions
+	Difference   int    `json:"difference"`   // stock - ledger_stock
+	AdjustmentID *int64 `json:"adjustment_id,omitempty"`
+}
+
+// ReconcileRequest starts a reconciliation run
+type ReconcileRequest struct {
+	Fix bool `json:"fix"` // post an ADJUSTMENT for every mismatch
+}
+
+// StockReconciliationListResponse for paginated reconciliation runs
+type StockReconciliationListResponse struct {
+	Reconciliations []StockReconciliation `json:"reconciliations"`
+	Total           int                   `json:"total"`
+	Page            int                   `json:"page"`
+	Limit           int                   `json:"limit"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Groupings accepted by the movement report
+const (
+	ReportGroupStore   = "store"
+	ReportGroupProduct = "product"
+	ReportGroupUser    = "user"
+	ReportGroupType    = "type"
+	ReportGroupDay     = "day"
+	ReportGroupWeek    = "week"
+	ReportGroupMonth   = "month"
+)
+
+// ReportFilter holds the parameters of a report. It is echoed back in the
+// report so that a saved or exported report says what it covers.
+type ReportFilter struct {
+	GroupBy   string     `json:"group_by,omitempty"`
+	From      *time.Time `json:"from,omitempty"` // inclusive
+	To        *time.Time `json:"to,omitempty"`   // exclusive
+	StoreID   int64      `json:"store_id,omitempty"`
+	ProductID int64      `json:"product_id,omitempty"`
+	Type      string     `json:"type,omitempty"`
+	AsOf      *time.Time `json:"as_of,omitempty"` // stock reports: the instant the stock is reconstructed for
+}
+
+// Report is the envelope every report endpoint answers with. Rows and
+// Totals hold the report-specific figures.
+type Report struct {
+	Name        string       `json:"name"`
+	GeneratedAt time.Time    `json:"generated_at"`
+	Filter      ReportFilter `json:"filter"`
+	Rows        interface{}  `json:"rows"`
+	Totals      interface{}  `json:"totals"`
+}
+
+// MovementMetrics sums stock movements. Sales are DECREASE transactions and
+// purchases INCREASE ones; ADJUSTMENT quantities are signed. QuantityIn and
+// QuantityOut cover every type, so NetQuantity is the change the ledger
+// makes to stock. Cost is what the goods sold cost at the time of sale;
+// cost and margin are left out for callers without report.view_cost.
+type MovementMetrics struct {
+	TransactionCount       int      `json:"transaction_count"`
+	SalesCount             int      `json:"sales_count"`
+	SalesQuantity          int      `json:"sales_quantity"`
+	SalesAmount            float64  `json:"sales_amount"`
+	PurchaseCount          int      `json:"purchase_count"`
+	PurchaseQuantity       int      `json:"purchase_quantity"`
+	PurchaseAmount         float64  `json:"purchase_amount"`
+	AdjustmentCount        int      `json:"adjustment_count"`
+	AdjustmentQuantity     int      `json:"adjustment_quantity"`
+	OpeningBalanceCount    int      `json:"opening_balance_count"`
+	OpeningBalanceQuantity int      `json:"opening_balance_quantity"`
+	QuantityIn             int      `json:"quantity_in"`
+	QuantityOut            int      `json:"quantity_out"`
+	NetQuantity            int      `json:"net_quantity"`
+	Cost                   *float64 `json:"cost,omitempty"`
+	Margin                 *float64 `json:"margin,omitempty"`
+	MarginPercent          *float64 `json:"margin_percent,omitempty"`
+}
+
+// MovementReportRow is one group of a movement report. Key identifies the
+// group (an ID, a type or the first day of the period) and Label names it.
+type MovementReportRow struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	MovementMetrics
+}
+
+// InventoryRow is one product's stock at a point in time. Unit cost is the
+// product's cost then; cost and value are left out for callers without
+// report.view_cost.
+type InventoryRow struct {
+	ProductID int64    `json:"product_id"`
+	SKU       string   `json:"sku"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Quantity  int      `json:"quantity"`
+	UnitCost  *float64 `json:"unit_cost,omitempty"`
+	Value     *float64 `json:"value,omitempty"`
+}
+
+type InventoryTotals struct {
+	Products int      `json:"products"`
+	Quantity int      `json:"quantity"`
+	Value    *float64 `json:"value,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Permission codes checked by RequirePermission
+const (
+	PermProductWrite       = "product.write"       // create, update and delete products
+	PermProductPrice       = "product.price"       // change product price and cost
+	PermStockAdjust        = "stock.adjust"        // post stock movements
+	PermStoreWrite         = "store.write"         // create, update and delete stores
+	PermStoreAllAccess     = "store.all_access"    // see and post against every store, not just assigned ones
+	PermReportViewCost     = "report.view_cost"    // see cost and margin figures in reports
+	PermUserManage         = "user.manage"         // manage users, lockouts and login history
+	PermUserImpersonate    = "user.impersonate"    // sign in as another user to see what they see
+	PermRoleManage         = "role.manage"         // manage roles and their permissions
+	PermAPIKeyManage       = "api_key.manage"      // create and revoke API keys
+	PermSigningKeyManage   = "signing_key.manage"  // rotate token signing keys
+	PermAuditView          = "audit.view"          // read the audit log
+	PermInventoryReconcile = "inventory.reconcile" // reconcile stock against the ledger and post corrections
+	PermGLExport           = "gl.export"           // export closed periods as general-ledger journals
+	PermPeriodManage       = "period.manage"       // close and reopen accounting periods
+)
+
+type Permission struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type Role struct {
+	ID          int64     `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsSystem    bool      `json:"is_system"`   // system roles cannot be deleted
+	RequireMFA  bool      `json:"require_mfa"` // members must use two-factor authentication
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateRoleRequest struct {
+	Code        string   `json:"code" binding:"required,max=20"`
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	RequireMFA  bool     `json:"require_mfa"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	RequireMFA  bool     `json:"require_mfa"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
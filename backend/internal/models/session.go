@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Session represents a login session; refresh tokens rotate within a session
+type Session struct {
+	ID             string     `json:"id"`
+	UserID         int64      `json:"user_id"`
+	IPAddress      string     `json:"ip_address"`
+	UserAgent      string     `json:"user_agent"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason  string     `json:"revoked_reason,omitempty"`
+	ImpersonatorID *int64     `json:"impersonator_id,omitempty"` // set when an admin is signed in as UserID
+}
+
+// RefreshToken is stored as a SHA-256 hash, never in plain text
+type RefreshToken struct {
+	ID        int64
+	SessionID string
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SSOExchangeRequest redeems the one-time code handed to the frontend after SSO
+type SSOExchangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SigningKey is an asymmetric key used to sign access tokens. The key with no
+// RetiredAt is active; retired keys keep validating tokens for a grace period.
+type SigningKey struct {
+	KID           string     `json:"kid"`
+	Algorithm     string     `json:"algorithm"` // RS256 or ES256
+	PrivateKeyPEM string     `json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RetiredAt     *time.Time `json:"retired_at"`
+}
+
+type RotateSigningKeyRequest struct {
+	Algorithm string `json:"algorithm" binding:"omitempty,oneof=RS256 ES256"`
+}
@@ -13,6 +13,7 @@ type Store struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	CreatedBy int64     `json:"created_by"`
 	UpdatedBy int64     `json:"updated_by"`
+	Version   int       `json:"version"` // incremented by every edit; served as the ETag
 }
 
 type StoreRequest struct {
@@ -22,3 +23,22 @@ type StoreRequest struct {
 	Phone   string `json:"phone"`
 	Status  string `json:"status"`
 }
+
+// StoreScope limits which stores a caller may see and post movements against
+type StoreScope struct {
+	All      bool    // true for callers with the store.all_access permission
+	StoreIDs []int64 // assigned stores when All is false
+}
+
+// Allows reports whether the scope includes a store
+func (s *StoreScope) Allows(storeID int64) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.StoreIDs {
+		if id == storeID {
+			return true
+		}
+	}
+	return false
+}
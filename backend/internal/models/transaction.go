@@ -2,21 +2,25 @@ package models
 
 import "time"
 
-// Transaction represents a stock movement (INCREASE or DECREASE)
+// Transaction represents a stock movement (INCREASE or DECREASE), the
+// OPENING_BALANCE a product was created with, or an ADJUSTMENT posted by
+// reconciliation to bring the ledger in line with stock
 type Transaction struct {
-	ID              int64     `json:"id"`
-	TransactionType string    `json:"transaction_type"` // INCREASE or DECREASE
-	ProductID       int64     `json:"product_id"`
-	ProductName     string    `json:"product_name,omitempty"`
-	StoreID         *int64    `json:"store_id"`         // NULL for INCREASE, NOT NULL for DECREASE
-	StoreName       string    `json:"store_name,omitempty"`
-	Quantity        int       `json:"quantity"`
-	UnitPrice       float64   `json:"unit_price"`
-	TotalAmount     float64   `json:"total_amount"`
-	Notes           string    `json:"notes"`
-	TransactionDate time.Time `json:"transaction_date"`
-	CreatedBy       int64     `json:"created_by"`
-	CreatedByName   string    `json:"created_by_name,omitempty"`
+	ID                 int64     `json:"id"`
+	TransactionType    string    `json:"transaction_type"` // INCREASE, DECREASE, ADJUSTMENT or OPENING_BALANCE
+	ProductID          int64     `json:"product_id"`
+	ProductName        string    `json:"product_name,omitempty"`
+	StoreID            *int64    `json:"store_id"` // NULL for INCREASE, NOT NULL for DECREASE
+	StoreName          string    `json:"store_name,omitempty"`
+	Quantity           int       `json:"quantity"` // signed for ADJUSTMENT
+	UnitPrice          float64   `json:"unit_price"`
+	TotalAmount        float64   `json:"total_amount"`
+	Notes              string    `json:"notes"`
+	TransactionDate    time.Time `json:"transaction_date"`
+	CreatedBy          int64     `json:"created_by"`
+	CreatedByName      string    `json:"created_by_name,omitempty"`
+	ImpersonatedBy     *int64    `json:"impersonated_by,omitempty"` // admin who posted this while signed in as CreatedBy
+	ImpersonatedByName string    `json:"impersonated_by_name,omitempty"`
 }
 
 // TransactionRequest for creating new transactions
@@ -28,3 +32,36 @@ type TransactionRequest struct {
 	UnitPrice       float64 `json:"unit_price" binding:"required,min=0"`
 	Notes           string  `json:"notes"`
 }
+
+// TransactionFilter narrows and orders a transaction ledger query. Zero values
+// leave a field unfiltered.
+type TransactionFilter struct {
+	From      *time.Time // inclusive
+	To        *time.Time // exclusive
+	Type      string     // INCREASE, DECREASE, ADJUSTMENT or OPENING_BALANCE
+	ProductID int64
+	StoreID   int64
+	UserID    int64 // who posted the transaction
+	MinAmount *float64
+	MaxAmount *float64
+	Sort      string // date, amount, quantity, product, store or type
+	Desc      bool
+	Page      int
+	Limit     int
+}
+
+type TransactionListResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	Total        int           `json:"total"`
+	Page         int           `json:"page"`
+	Limit        int           `json:"limit"`
+}
+
+// TransactionPage is one page of a cursor-paginated ledger query. Total is
+// only present when the client asked for it.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor"` // empty on the last page
+	Limit        int           `json:"limit"`
+	Total        *int          `json:"total,omitempty"`
+}
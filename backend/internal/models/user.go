@@ -7,7 +7,8 @@ type User struct {
 	Username     string    `json:"username"`
 	PasswordHash string    `json:"-"` // Never expose password hash in JSON
 	FullName     string    `json:"full_name"`
-	Role         string    `json:"role"` // ADMIN or STAFF
+	Email        string    `json:"email,omitempty"` // where password reset links are sent
+	Role         string    `json:"role"`            // code of a row in roles, e.g. ADMIN or STAFF
 	Status       string    `json:"status"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
@@ -18,7 +19,40 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// LoginResponse carries either a token pair or, when the user has two-factor
+// authentication, an MFA token to exchange at /api/auth/login/mfa. ExpiresAt
+// applies to whichever token was issued.
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token                 string    `json:"token,omitempty"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	User                  User      `json:"user"`
+	Permissions           []string  `json:"permissions"`
+	StoreIDs              []int64   `json:"store_ids"`
+	MFARequired           bool      `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool      `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string    `json:"mfa_token,omitempty"`
+	RecoveryCodes         []string  `json:"recovery_codes,omitempty"` // set when login completed a required enrollment
+}
+
+type UpdateUserStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=ACTIVE INACTIVE"`
+}
+
+type UpdateUserStoresRequest struct {
+	StoreIDs []int64 `json:"store_ids" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create stores a new API key with its permissions and stores
+func (r *APIKeyRepository) Create(key *models.APIKey, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		INSERT INTO api_keys (name, key_prefix, key_hash, expires_at, created_by)
+		VALUES (:1, :2, :3, :4, :5)
+		RETURNING id, created_at INTO :6, :7
+	`
+
+	_, err = dbTx.Exec(query,
+		key.Name, key.KeyPrefix, key.KeyHash, key.ExpiresAt, key.CreatedBy,
+		sql.Out{Dest: &key.ID},
+		sql.Out{Dest: &key.CreatedAt},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	for _, code := range key.Permissions {
+		_, err := dbTx.Exec(`INSERT INTO api_key_permissions (api_key_id, permission_code) VALUES (:1, :2)`, key.ID, code)
+		if err != nil {
+			return fmt.Errorf("failed to grant permission %s: %w", code, err)
+		}
+	}
+
+	for _, storeID := range key.StoreIDs {
+		_, err := dbTx.Exec(`INSERT INTO api_key_stores (api_key_id, store_id) VALUES (:1, :2)`, key.ID, storeID)
+		if err != nil {
+			return fmt.Errorf("failed to assign store %d: %w", storeID, err)
+		}
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityAPIKey, auditID(key.ID), models.AuditCreate, nil, key); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// FindAll lists all API keys, newest first
+func (r *APIKeyRepository) FindAll() ([]models.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, key_hash, expires_at, last_used_at, revoked_at, created_by, created_at
+		FROM api_keys
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	rows.Close()
+
+	for i := range keys {
+		if err := r.loadGrants(&keys[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// FindByHash looks up an API key by the hash of the presented key
+func (r *APIKeyRepository) FindByHash(keyHash string) (*models.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, key_hash, expires_at, last_used_at, revoked_at, created_by, created_at
+		FROM api_keys
+		WHERE key_hash = :1
+	`
+
+	key, err := scanAPIKey(r.db.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("API key not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadGrants(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Revoke disables an API key permanently
+func (r *APIKeyRepository) Revoke(id int64, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	var revokedAt time.Time
+	result, err := dbTx.Exec(`
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = :1 AND revoked_at IS NULL
+		RETURNING revoked_at INTO :2
+	`, id, sql.Out{Dest: &revokedAt})
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("API key not found or already revoked")
+	}
+
+	err = insertAudit(dbTx, audit, models.EntityAPIKey, auditID(id), models.AuditRevoke,
+		map[string]interface{}{"revoked_at": nil},
+		map[string]interface{}{"revoked_at": revokedAt},
+	)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// TouchLastUsed records key usage, writing at most once per interval to keep
+// busy terminals from updating the row on every request
+func (r *APIKeyRepository) TouchLastUsed(id int64, now time.Time, interval time.Duration) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = :1
+		WHERE id = :2 AND (last_used_at IS NULL OR last_used_at < :3)
+	`
+
+	_, err := r.db.Exec(query, now, id, now.Add(-interval))
+	if err != nil {
+		return fmt.Errorf("failed to update API key usage: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) loadGrants(key *models.APIKey) error {
+	rows, err := r.db.Query(`SELECT permission_code FROM api_key_permissions WHERE api_key_id = :1 ORDER BY permission_code`, key.ID)
+	if err != nil {
+		return fmt.Errorf("failed to query API key permissions: %w", err)
+	}
+	defer rows.Close()
+
+	key.Permissions = []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return fmt.Errorf("failed to scan API key permission: %w", err)
+		}
+		key.Permissions = append(key.Permissions, code)
+	}
+	rows.Close()
+
+	storeRows, err := r.db.Query(`SELECT store_id FROM api_key_stores WHERE api_key_id = :1 ORDER BY store_id`, key.ID)
+	if err != nil {
+		return fmt.Errorf("failed to query API key stores: %w", err)
+	}
+	defer storeRows.Close()
+
+	key.StoreIDs = []int64{}
+	for storeRows.Next() {
+		var id int64
+		if err := storeRows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan API key store: %w", err)
+		}
+		key.StoreIDs = append(key.StoreIDs, id)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID, &key.Name, &key.KeyPrefix, &key.KeyHash,
+		&expiresAt, &lastUsedAt, &revokedAt,
+		&key.CreatedBy, &key.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"pos-backoffice/internal/models"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx so audit entries can be
+// written inside the caller's transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx so lookups can run
+// inside the caller's transaction
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// auditIgnoredFields change on every write and carry no information
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// insertAudit appends an audit entry for a change. before and after are the
+// entity's state around the change (nil for creates and deletes respectively)
+// and are reduced to the fields that differ. A nil audit context records a
+// change made by the system itself.
+func insertAudit(ex execer, audit *models.AuditContext, entityType, entityID, action string, before, after interface{}) error {
+	if audit == nil {
+		audit = &models.AuditContext{}
+	}
+
+	changes, err := diffJSON(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to build audit diff: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_id, impersonator_id, api_key_id, entity_type, entity_id, action, changes, request_id, ip_address)
+		VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9)
+	`
+
+	_, err = ex.Exec(query,
+		audit.ActorID, audit.ImpersonatorID, audit.APIKeyID,
+		entityType, entityID, action, changes, audit.RequestID, audit.IPAddress,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// auditID formats a numeric primary key for the audit log
+func auditID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// diffJSON compares the JSON form of two values field by field and returns
+// the changed fields as {"field": {"old": ..., "new": ...}}, or an empty
+// string when nothing changed. Fields hidden from JSON, such as password
+// hashes, never reach the log.
+func diffJSON(before, after interface{}) (string, error) {
+	oldFields, err := toFieldMap(before)
+	if err != nil {
+		return "", err
+	}
+	newFields, err := toFieldMap(after)
+	if err != nil {
+		return "", err
+	}
+
+	changes := map[string]models.FieldChange{}
+	for name, oldValue := range oldFields {
+		if auditIgnoredFields[name] {
+			continue
+		}
+		newValue, exists := newFields[name]
+		if !exists || !reflect.DeepEqual(oldValue, newValue) {
+			changes[name] = models.FieldChange{Old: oldValue, New: newValue}
+		}
+	}
+	for name, newValue := range newFields {
+		if _, exists := oldFields[name]; exists || auditIgnoredFields[name] || newValue == nil {
+			continue
+		}
+		changes[name] = models.FieldChange{New: newValue}
+	}
+
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func toFieldMap(v interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// FindAll returns audit entries matching the filter, newest first
+func (r *AuditRepository) FindAll(filter *models.AuditFilter) ([]models.AuditEntry, int, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.ActorID != 0 {
+		whereClause += fmt.Sprintf(" AND a.actor_id = :%d", argIndex)
+		args = append(args, filter.ActorID)
+		argIndex++
+	}
+
+	if filter.EntityType != "" {
+		whereClause += fmt.Sprintf(" AND a.entity_type = :%d", argIndex)
+		args = append(args, filter.EntityType)
+		argIndex++
+	}
+
+	if filter.EntityID != "" {
+		whereClause += fmt.Sprintf(" AND a.entity_id = :%d", argIndex)
+		args = append(args, filter.EntityID)
+		argIndex++
+	}
+
+	if filter.Action != "" {
+		whereClause += fmt.Sprintf(" AND a.action = :%d", argIndex)
+		args = append(args, filter.Action)
+		argIndex++
+	}
+
+	if filter.RequestID != "" {
+		whereClause += fmt.Sprintf(" AND a.request_id = :%d", argIndex)
+		args = append(args, filter.RequestID)
+		argIndex++
+	}
+
+	if filter.From != nil {
+		whereClause += fmt.Sprintf(" AND a.created_at >= :%d", argIndex)
+		args = append(args, *filter.From)
+		argIndex++
+	}
+
+	if filter.To != nil {
+		whereClause += fmt.Sprintf(" AND a.created_at < :%d", argIndex)
+		args = append(args, *filter.To)
+		argIndex++
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM audit_log a %s", whereClause)
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query := fmt.Sprintf(`
+		SELECT a.id, a.actor_id, u.username, a.impersonator_id, a.api_key_id,
+		       a.entity_type, a.entity_id, a.action, a.changes, a.request_id, a.ip_address, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON a.actor_id = u.id
+		%s
+		ORDER BY a.created_at DESC, a.id DESC
+		OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
+	`, whereClause, offset, filter.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var actorID, impersonatorID, apiKeyID sql.NullInt64
+		var actorUsername, changes, requestID, ipAddress sql.NullString
+
+		err := rows.Scan(
+			&e.ID, &actorID, &actorUsername, &impersonatorID, &apiKeyID,
+			&e.EntityType, &e.EntityID, &e.Action, &changes, &requestID, &ipAddress, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+
+		if actorID.Valid {
+			e.ActorID = &actorID.Int64
+		}
+		if impersonatorID.Valid {
+			e.ImpersonatorID = &impersonatorID.Int64
+		}
+		if apiKeyID.Valid {
+			e.APIKeyID = &apiKeyID.Int64
+		}
+		e.ActorUsername = actorUsername.String
+		if changes.Valid && changes.String != "" {
+			e.Changes = json.RawMessage(changes.String)
+		}
+		e.RequestID = requestID.String
+		e.IPAddress = ipAddress.String
+
+		entries = append(entries, e)
+	}
+
+	return entries, total, nil
+}
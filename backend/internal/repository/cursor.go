@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by us
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// keysetCursor marks the last row of a page by its sort timestamp and ID.
+// Rows after it are found with a range condition instead of OFFSET, so deep
+// pages cost the same as the first and rows inserted meanwhile do not shift
+// later pages.
+type keysetCursor struct {
+	Time time.Time `json:"t"`
+	ID   int64     `json:"id"`
+}
+
+// encodeCursor returns the opaque next_cursor handed to clients
+func encodeCursor(t time.Time, id int64) string {
+	data, _ := json.Marshal(keysetCursor{Time: t, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a client's cursor. An empty cursor starts at the first
+// row and yields nil.
+func decodeCursor(cursor string) (*keysetCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var k keysetCursor
+	if err := json.Unmarshal(data, &k); err != nil || k.ID == 0 || k.Time.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &k, nil
+}
+
+// clause builds the condition for rows past the cursor in (timeColumn,
+// idColumn) order. Bind placeholders are numbered from argIndex.
+func (k *keysetCursor) clause(timeColumn, idColumn string, desc bool, argIndex int) (string, []interface{}) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	clause := fmt.Sprintf(" AND (%s %s :%d OR (%s = :%d AND %s %s :%d))",
+		timeColumn, op, argIndex, timeColumn, argIndex+1, idColumn, op, argIndex+2)
+	return clause, []interface{}{k.Time, k.Time, k.ID}
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"fmt"
+
+	"pos-backoffice/internal/database"
+)
+
+// revisionCost returns a subquery for the cost of a product in its last
+// revision made at or before at, or NULL if there is none. product and at
+// are SQL expressions.
+func revisionCost(product, at string) string {
+	if database.Current() == database.Oracle {
+		return fmt.Sprintf(`(
+			SELECT MAX(r.cost) KEEP (DENSE_RANK LAST ORDER BY r.revision)
+			FROM product_revisions r
+			WHERE r.product_id = %s AND r.created_at <= %s
+		)`, product, at)
+	}
+
+	return fmt.Sprintf(`(
+		SELECT r.cost
+		FROM product_revisions r
+		WHERE r.product_id = %s AND r.created_at <= %s
+		ORDER BY r.revision DESC
+		FETCH FIRST 1 ROWS ONLY
+	)`, product, at)
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+// GLExportRepository reads the ledger in the shape journals are built from
+// and stores each exported period, at most once
+type GLExportRepository struct {
+	db *sql.DB
+}
+
+func NewGLExportRepository(db *sql.DB) *GLExportRepository {
+	return &GLExportRepository{db: db}
+}
+
+// Movements sums the transactions dated from start to end per store,
+// movement type and direction. Cost uses the product's cost in the last
+// revision before each movement, like the movement report.
+func (r *GLExportRepository) Movements(start, end time.Time) ([]models.GLMovement, error) {
+	rows, err := r.db.Query(fmt.Sprintf(`
+		SELECT store_id, store_code, transaction_type, direction, COUNT(*),
+		       NVL(SUM(ROUND(ABS(total_amount), 2)), 0),
+		       NVL(SUM(ROUND(ABS(quantity) * unit_cost, 2)), 0)
+		FROM (
+			SELECT t.store_id, s.code AS store_code, t.transaction_type,
+			       CASE WHEN t.quantity < 0 THEN -1 ELSE 1 END AS direction,
+			       t.quantity, t.total_amount,
+			       NVL(%s, p.cost) AS unit_cost
+			FROM transactions t
+			JOIN products p ON t.product_id = p.id
+			LEFT JOIN stores s ON t.store_id = s.id
+			WHERE t.transaction_date >= :1 AND t.transaction_date < :2
+		)
+		GROUP BY store_id, store_code, transaction_type, direction
+		ORDER BY store_code NULLS FIRST, transaction_type, direction DESC
+	`, revisionCost("t.product_id", "t.transaction_date")), start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := []models.GLMovement{}
+	for rows.Next() {
+		var m models.GLMovement
+		var storeID sql.NullInt64
+		var storeCode sql.NullString
+		if err := rows.Scan(&storeID, &storeCode, &m.TransactionType, &m.Direction, &m.Count, &m.Amount, &m.Cost); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger movement: %w", err)
+		}
+		if storeID.Valid {
+			m.StoreID = &storeID.Int64
+		}
+		m.StoreCode = storeCode.String
+		movements = append(movements, m)
+	}
+
+	return movements, rows.Err()
+}
+
+// Create stores an export and its lines and audits it. When the period has
+// already been exported it stores nothing and returns the earlier export
+// with false.
+func (r *GLExportRepository) Create(export *models.GLExport, audit *models.AuditContext) (*models.GLExport, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO gl_exports (
+			period, period_closed_at, journal_count, line_count,
+			total_debit, total_credit, checksum, exported_by
+		)
+		VALUES (:1, :2, :3, :4, :5, :6, :7, :8)
+		RETURNING id, exported_at INTO :9, :10
+	`, export.Period, export.PeriodClosedAt, export.JournalCount, export.LineCount,
+		export.TotalDebit, export.TotalCredit, export.Checksum, export.ExportedBy,
+		sql.Out{Dest: &export.ID}, sql.Out{Dest: &export.ExportedAt})
+	if isUniqueViolation(err) {
+		tx.Rollback()
+		existing, err := r.FindByPeriod(export.Period)
+		return existing, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create GL export: %w", err)
+	}
+
+	for _, line := range export.Lines {
+		_, err := tx.Exec(`
+			INSERT INTO gl_export_lines (
+				export_id, line_no, journal, store_id, account, account_role, description, debit, credit
+			)
+			VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9)
+		`, export.ID, line.LineNo, line.Journal, line.StoreID, line.Account, line.AccountRole,
+			line.Description, line.Debit, line.Credit)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to store GL export line: %w", err)
+		}
+	}
+
+	// The manifest entry is audited; the lines are covered by its checksum
+	manifest := *export
+	manifest.Lines = nil
+	if err := insertAudit(tx, audit, models.EntityGLExport, auditID(export.ID), models.AuditCreate, nil, &manifest); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit GL export: %w", err)
+	}
+	return export, true, nil
+}
+
+const glExportSelect = `
+	SELECT e.id, e.period, e.period_closed_at, e.journal_count, e.line_count,
+	       e.total_debit, e.total_credit, e.checksum, e.exported_by, u.full_name, e.exported_at
+	FROM gl_exports e
+	JOIN users u ON e.exported_by = u.id
+`
+
+// FindAll returns the manifest of exported periods, newest period first
+func (r *GLExportRepository) FindAll() ([]models.GLExport, error) {
+	rows, err := r.db.Query(glExportSelect + ` ORDER BY e.period DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GL exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []models.GLExport{}
+	for rows.Next() {
+		export, err := scanGLExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *export)
+	}
+
+	return exports, rows.Err()
+}
+
+// FindByPeriod returns a period's export with its lines, or nil when the
+// period has not been exported
+func (r *GLExportRepository) FindByPeriod(period string) (*models.GLExport, error) {
+	export, err := scanGLExport(r.db.QueryRow(glExportSelect+` WHERE e.period = :1`, period))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT line_no, journal, store_id, account, account_role, description, debit, credit
+		FROM gl_export_lines
+		WHERE export_id = :1
+		ORDER BY line_no
+	`, export.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GL export lines: %w", err)
+	}
+	defer rows.Close()
+
+	export.Lines = []models.JournalLine{}
+	for rows.Next() {
+		var line models.JournalLine
+		var storeID sql.NullInt64
+		if err := rows.Scan(&line.LineNo, &line.Journal, &storeID, &line.Account, &line.AccountRole,
+			&line.Description, &line.Debit, &line.Credit); err != nil {
+			return nil, fmt.Errorf("failed to scan GL export line: %w", err)
+		}
+		if storeID.Valid {
+			line.StoreID = &storeID.Int64
+		}
+		export.Lines = append(export.Lines, line)
+	}
+
+	return export, rows.Err()
+}
+
+func scanGLExport(row rowScanner) (*models.GLExport, error) {
+	var export models.GLExport
+	err := row.Scan(&export.ID, &export.Period, &export.PeriodClosedAt, &export.JournalCount, &export.LineCount,
+		&export.TotalDebit, &export.TotalCredit, &export.Checksum, &export.ExportedBy, &export.ExportedByName,
+		&export.ExportedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan GL export: %w", err)
+	}
+	return &export, nil
+}
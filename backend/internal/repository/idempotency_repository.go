@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sijms/go-ora/v2/network"
+	"pos-backoffice/internal/models"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Claim reserves a key for a new request. When the caller already used the
+// key, nothing is inserted and the existing record is returned instead.
+// Callers purge expired keys first so that those can be reused.
+func (r *IdempotencyRepository) Claim(record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO idempotency_keys (principal, key_hash, request_hash, expires_at)
+		VALUES (:1, :2, :3, :4)
+		RETURNING id, created_at INTO :5, :6
+	`, record.Principal, record.KeyHash, record.RequestHash, record.ExpiresAt,
+		sql.Out{Dest: &record.ID}, sql.Out{Dest: &record.CreatedAt})
+	if err == nil {
+		return nil, nil
+	}
+	if !isUniqueViolation(err) {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	existing, err := r.find(record.Principal, record.KeyHash)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		// The holder released it between our insert and the lookup
+		return nil, fmt.Errorf("idempotency key was released concurrently, retry the request")
+	}
+	return existing, nil
+}
+
+func (r *IdempotencyRepository) find(principal, keyHash string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var statusCode sql.NullInt64
+	var responseBody sql.NullString
+
+	err := r.db.QueryRow(`
+		SELECT id, principal, key_hash, request_hash, status_code, response_body, expires_at, created_at
+		FROM idempotency_keys
+		WHERE principal = :1 AND key_hash = :2
+	`, principal, keyHash).Scan(
+		&record.ID, &record.Principal, &record.KeyHash, &record.RequestHash,
+		&statusCode, &responseBody, &record.ExpiresAt, &record.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	record.ResponseBody = responseBody.String
+	return &record, nil
+}
+
+// Complete stores the response of the request that claimed the key
+func (r *IdempotencyRepository) Complete(id int64, statusCode int, responseBody string) error {
+	_, err := r.db.Exec(`
+		UPDATE idempotency_keys SET status_code = :1, response_body = :2
+		WHERE id = :3
+	`, statusCode, responseBody, id)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release forgets a claimed key so a retry is processed from scratch
+func (r *IdempotencyRepository) Release(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE id = :1`, id); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired purges keys past their expiry
+func (r *IdempotencyRepository) DeleteExpired(now time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= :1`, now); err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is ORA-00001, or the PostgreSQL or
+// SQLite equivalent
+func isUniqueViolation(err error) bool {
+	var oraErr *network.OracleError
+	var pqErr *pq.Error
+	var sqliteErr sqlite3.Error
+	switch {
+	case errors.As(err, &oraErr):
+		return oraErr.ErrCode == 1
+	case errors.As(err, &pqErr):
+		return pqErr.Code == "23505"
+	case errors.As(err, &sqliteErr):
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"pos-backoffice/internal/models"
+)
+
+type ImpersonationRepository struct {
+	db *sql.DB
+}
+
+func NewImpersonationRepository(db *sql.DB) *ImpersonationRepository {
+	return &ImpersonationRepository{db: db}
+}
+
+// RecordAction stores a write request made during impersonation
+func (r *ImpersonationRepository) RecordAction(action *models.ImpersonationAction) error {
+	query := `
+		INSERT INTO impersonation_actions (session_id, impersonator_id, user_id, method, path, status_code, ip_address)
+		VALUES (:1, :2, :3, :4, :5, :6, :7)
+		RETURNING id INTO :8
+	`
+
+	_, err := r.db.Exec(query,
+		action.SessionID, action.ImpersonatorID, action.UserID,
+		action.Method, action.Path, action.StatusCode, action.IPAddress,
+		sql.Out{Dest: &action.ID},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record impersonation action: %w", err)
+	}
+
+	return nil
+}
+
+// FindActions returns write requests made during impersonation, newest first
+func (r *ImpersonationRepository) FindActions(filter *models.ImpersonationActionFilter) ([]models.ImpersonationAction, int, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.ImpersonatorID != 0 {
+		whereClause += fmt.Sprintf(" AND a.impersonator_id = :%d", argIndex)
+		args = append(args, filter.ImpersonatorID)
+		argIndex++
+	}
+
+	if filter.UserID != 0 {
+		whereClause += fmt.Sprintf(" AND a.user_id = :%d", argIndex)
+		args = append(args, filter.UserID)
+		argIndex++
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM impersonation_actions a %s", whereClause)
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count impersonation actions: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query := fmt.Sprintf(`
+		SELECT a.id, a.session_id, a.impersonator_id, iu.username, a.user_id, u.username,
+		       a.method, a.path, a.status_code, a.ip_address, a.created_at
+		FROM impersonation_actions a
+		JOIN users iu ON a.impersonator_id = iu.id
+		JOIN users u ON a.user_id = u.id
+		%s
+		ORDER BY a.created_at DESC, a.id DESC
+		OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
+	`, whereClause, offset, filter.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query impersonation actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []models.ImpersonationAction{}
+	for rows.Next() {
+		var a models.ImpersonationAction
+		var ipAddress sql.NullString
+
+		err := rows.Scan(
+			&a.ID, &a.SessionID, &a.ImpersonatorID, &a.ImpersonatorUsername, &a.UserID, &a.Username,
+			&a.Method, &a.Path, &a.StatusCode, &ipAddress, &a.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan impersonation action: %w", err)
+		}
+		a.IPAddress = ipAddress.String
+
+		actions = append(actions, a)
+	}
+
+	return actions, total, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+// signedQuantity is a transaction's effect on stock
+const signedQuantity = "CASE t.transaction_type WHEN 'DECREASE' THEN -t.quantity ELSE t.quantity END"
+
+// InventoryRepository reconstructs stock from the transaction ledger. Stock
+// at a snapshot's time is stored in inventory_snapshots, so a reconstruction
+// only has to add the movements since the last snapshot before it.
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// LatestSnapshot returns the time of the last snapshot taken at or before t,
+// or nil when there is none
+func (r *InventoryRepository) LatestSnapshot(t time.Time) (*time.Time, error) {
+	return latestSnapshot(r.db, t)
+}
+
+func latestSnapshot(q queryRower, t time.Time) (*time.Time, error) {
+	var at sql.NullTime
+	err := q.QueryRow(`SELECT MAX(snapshot_at) FROM inventory_snapshots WHERE snapshot_at <= :1`, t).Scan(&at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find inventory snapshot: %w", err)
+	}
+	if !at.Valid {
+		return nil, nil
+	}
+	return &at.Time, nil
+}
+
+// CreateSnapshot stores every product's stock as of at, computed from the
+// previous snapshot and the movements since. It returns the number of
+// products in the snapshot.
+func (r *InventoryRepository) CreateSnapshot(at time.Time) (int, error) {
+	previous, err := r.LatestSnapshot(at)
+	if err != nil {
+		return 0, err
+	}
+	if previous != nil && previous.Equal(at) {
+		return 0, fmt.Errorf("a snapshot for %s already exists", at.Format(time.RFC3339))
+	}
+
+	base, baseArgs := stockSince(previous, at, 2)
+	query := fmt.Sprintf(`
+		INSERT INTO inventory_snapshots (snapshot_at, product_id, quantity)
+		SELECT :1, product_id, quantity FROM (%s)
+	`, base)
+
+	result, err := r.db.Exec(query, append([]interface{}{at}, baseArgs...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create inventory snapshot: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// StockAsOf returns every product that existed at asOf with its stock and
+// unit cost at that time. A non-zero productID limits it to that product.
+func (r *InventoryRepository) StockAsOf(asOf time.Time, productID int64) ([]models.InventoryRow, error) {
+	previous, err := r.LatestSnapshot(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := stockAsOfQuery(previous, asOf, productID, 1)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.InventoryRow{}
+	for rows.Next() {
+		var row models.InventoryRow
+		var unitCost float64
+		if err := rows.Scan(&row.ProductID, &row.SKU, &row.Name, &row.Status, &row.Quantity, &unitCost); err != nil {
+			return nil, fmt.Errorf("failed to scan stock: %w", err)
+		}
+		row.UnitCost = &unitCost
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// stockAsOfQuery builds a query of (id, sku, name, status, quantity,
+// unit_cost) for every product that existed at asOf, reconstructed from the
+// snapshot taken at previous. Bind placeholders are numbered from argIndex
+// in the order they appear in the text, which is the order drivers bind.
+func stockAsOfQuery(previous *time.Time, asOf time.Time, productID int64, argIndex int) (string, []interface{}) {
+	// Cost comes from the last product revision made before asOf
+	cost := revisionCost("p.id", fmt.Sprintf(":%d", argIndex))
+	args := []interface{}{asOf}
+	argIndex++
+
+	base, baseArgs := stockSince(previous, asOf, argIndex)
+	args = append(args, baseArgs...)
+	argIndex += len(baseArgs)
+
+	whereClause := fmt.Sprintf("WHERE p.created_at < :%d", argIndex)
+	args = append(args, asOf)
+	argIndex++
+
+	if productID != 0 {
+		whereClause += fmt.Sprintf(" AND p.id = :%d", argIndex)
+		args = append(args, productID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT p.id, p.sku, p.name, p.status, NVL(b.quantity, 0) AS quantity,
+		       NVL(%s, p.cost) AS unit_cost
+		FROM products p
+		LEFT JOIN (%s) b ON b.product_id = p.id
+		%s
+		ORDER BY p.sku
+	`, cost, base, whereClause)
+
+	return query, args
+}
+
+// stockSince builds a query of (product_id, quantity) for the stock at end:
+// the snapshot taken at previous, or nothing when it is nil, plus the
+// movements between the two. Bind placeholders are numbered from argIndex.
+func stockSince(previous *time.Time, end time.Time, argIndex int) (string, []interface{}) {
+	if previous == nil {
+		return fmt.Sprintf(`
+			SELECT t.product_id, SUM(%s) AS quantity
+			FROM transactions t
+			WHERE t.transaction_date < :%d
+			GROUP BY t.product_id
+		`, signedQuantity, argIndex), []interface{}{end}
+	}
+
+	return fmt.Sprintf(`
+		SELECT product_id, SUM(quantity) AS quantity FROM (
+			SELECT s.product_id, s.quantity
+			FROM inventory_snapshots s
+			WHERE s.snapshot_at = :%d
+			UNION ALL
+			SELECT t.product_id, %s
+			FROM transactions t
+			WHERE t.transaction_date >= :%d AND t.transaction_date < :%d
+		)
+		GROUP BY product_id
+	`, argIndex, signedQuantity, argIndex+1, argIndex+2), []interface{}{*previous, *previous, end}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/database"
+)
+
+// openTestDB opens a migrated, seeded SQLite database in a temporary
+// directory and makes it the database package's database
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	prevConfig, prevDB := config.AppConfig, database.DB
+	t.Cleanup(func() { config.AppConfig, database.DB = prevConfig, prevDB })
+
+	config.AppConfig = &config.Config{DBDriver: "sqlite", DBPath: filepath.Join(t.TempDir(), "test.db")}
+	if err := database.InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.CloseDB() })
+	if _, err := database.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+	return database.DB
+}
+
+// postMovement records a movement of quantity units of a seeded product at at
+func postMovement(t *testing.T, db *sql.DB, txType string, productID int64, quantity int, at time.Time) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO transactions (transaction_type, product_id, quantity, unit_price, total_amount, created_by, transaction_date)
+		VALUES (:1, :2, :3, 10, :4, 1, :5)
+	`, txType, productID, quantity, float64(quantity*10), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStockAsOfAfterSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewInventoryRepository(db)
+
+	snapshotAt := time.Date(2099, 1, 10, 0, 0, 0, 0, time.UTC)
+	if _, err := repo.CreateSnapshot(snapshotAt); err != nil {
+		t.Fatal(err)
+	}
+	postMovement(t, db, "INCREASE", 2, 3, snapshotAt.Add(24*time.Hour))
+	postMovement(t, db, "ADJUSTMENT", 3, 7, snapshotAt.Add(48*time.Hour))
+	asOf := snapshotAt.Add(72 * time.Hour)
+
+	// The seeded ledger plus the movements since the snapshot
+	want := map[int64]int{1: 500, 2: 453, 3: 307, 4: 400, 5: 600}
+	rows, err := repo.StockAsOf(asOf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("StockAsOf returned %d products, want %d", len(rows), len(want))
+	}
+	for _, row := range rows {
+		if row.Quantity != want[row.ProductID] {
+			t.Errorf("product %d: quantity %d, want %d", row.ProductID, row.Quantity, want[row.ProductID])
+		}
+	}
+
+	rows, err = repo.StockAsOf(asOf, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].ProductID != 2 || rows[0].Quantity != 453 || rows[0].UnitCost == nil || *rows[0].UnitCost != 10 {
+		t.Fatalf("StockAsOf for product 2 = %+v", rows)
+	}
+
+	// Movements after asOf are not counted
+	rows, err = repo.StockAsOf(snapshotAt.Add(36*time.Hour), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Quantity != 300 {
+		t.Fatalf("StockAsOf for product 3 before its adjustment = %+v", rows)
+	}
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/database"
+	"pos-backoffice/internal/models"
+)
+
+type LoginRepository struct {
+	db *sql.DB
+}
+
+func NewLoginRepository(db *sql.DB) *LoginRepository {
+	return &LoginRepository{db: db}
+}
+
+// FindThrottle returns the throttle state for a key, or nil if there is none
+func (r *LoginRepository) FindThrottle(key string) (*models.LoginThrottle, error) {
+	query := `
+		SELECT throttle_key, failed_count, last_failed_at, locked_until
+		FROM login_throttles
+		WHERE throttle_key = :1
+	`
+
+	var t models.LoginThrottle
+	var lockedUntil sql.NullTime
+	err := r.db.QueryRow(query, key).Scan(&t.Key, &t.FailedCount, &t.LastFailedAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query login throttle: %w", err)
+	}
+
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+
+	return &t, nil
+}
+
+// RecordFailure atomically increments the failure counter for a key. Counters
+// whose last failure is older than windowStart start again from one.
+// It returns the updated failure count.
+func (r *LoginRepository) RecordFailure(key string, now, windowStart time.Time) (int, error) {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		MERGE INTO login_throttles t
+		USING (SELECT :1 AS throttle_key FROM dual) s
+		ON (t.throttle_key = s.throttle_key)
+		WHEN MATCHED THEN UPDATE SET
+			failed_count = CASE WHEN t.last_failed_at < :2 THEN 1 ELSE t.failed_count + 1 END,
+			last_failed_at = :3
+		WHEN NOT MATCHED THEN INSERT (throttle_key, failed_count, last_failed_at)
+			VALUES (:4, 1, :5)
+	`
+	args := []interface{}{key, windowStart, now, key, now}
+	if database.Current() != database.Oracle {
+		query = `
+			INSERT INTO login_throttles (throttle_key, failed_count, last_failed_at)
+			VALUES (:1, 1, :2)
+			ON CONFLICT (throttle_key) DO UPDATE SET
+				failed_count = CASE WHEN login_throttles.last_failed_at < :3 THEN 1 ELSE login_throttles.failed_count + 1 END,
+				last_failed_at = excluded.last_failed_at
+		`
+		args = []interface{}{key, now, windowStart}
+	}
+	if _, err := dbTx.Exec(query, args...); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	var count int
+	err = dbTx.QueryRow(`SELECT failed_count FROM login_throttles WHERE throttle_key = :1`, key).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read login failures: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Lock sets the time until which logins for a key are refused
+func (r *LoginRepository) Lock(key string, until time.Time) error {
+	_, err := r.db.Exec(`UPDATE login_throttles SET locked_until = :1 WHERE throttle_key = :2`, until, key)
+	if err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+	return nil
+}
+
+// ResetThrottle clears failures and any lockout for a key
+func (r *LoginRepository) ResetThrottle(key string) error {
+	_, err := r.db.Exec(`DELETE FROM login_throttles WHERE throttle_key = :1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
+
+// Unlock clears failures and any lockout for a user's throttle key on behalf
+// of an administrator, auditing it in the same transaction
+func (r *LoginRepository) Unlock(userID int64, key string, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if _, err := dbTx.Exec(`DELETE FROM login_throttles WHERE throttle_key = :1`, key); err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityUser, auditID(userID), models.AuditUnlock, nil, nil); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// CreateHistory records a login attempt together with its audit log entry.
+// Attempts for unknown usernames are audited against the username itself.
+func (r *LoginRepository) CreateHistory(entry *models.LoginHistory) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		INSERT INTO login_history (username, user_id, success, failure_reason, ip_address, user_agent, request_id)
+		VALUES (:1, :2, :3, :4, :5, :6, :7)
+		RETURNING id, attempted_at INTO :8, :9
+	`
+
+	success := 0
+	if entry.Success {
+		success = 1
+	}
+
+	_, err = dbTx.Exec(query,
+		entry.Username, entry.UserID, success, entry.FailureReason,
+		entry.IPAddress, entry.UserAgent, entry.RequestID,
+		sql.Out{Dest: &entry.ID},
+		sql.Out{Dest: &entry.AttemptedAt},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record login history: %w", err)
+	}
+
+	audit := &models.AuditContext{
+		ActorID:   entry.UserID,
+		RequestID: entry.RequestID,
+		IPAddress: entry.IPAddress,
+	}
+	entityID := entry.Username
+	if entry.UserID != nil {
+		entityID = auditID(*entry.UserID)
+	}
+	action := models.AuditLogin
+	if !entry.Success {
+		action = models.AuditLoginFailed
+	}
+	details := map[string]string{"username": entry.Username, "failure_reason": entry.FailureReason}
+
+	if err := insertAudit(dbTx, audit, models.EntityUser, entityID, action, nil, details); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// FindHistory returns login attempts, newest first
+func (r *LoginRepository) FindHistory(filter *models.LoginHistoryFilter) ([]models.LoginHistory, int, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.Username != "" {
+		whereClause += fmt.Sprintf(" AND username = :%d", argIndex)
+		args = append(args, filter.Username)
+		argIndex++
+	}
+
+	if filter.IPAddress != "" {
+		whereClause += fmt.Sprintf(" AND ip_address = :%d", argIndex)
+		args = append(args, filter.IPAddress)
+		argIndex++
+	}
+
+	if filter.Success != nil {
+		success := 0
+		if *filter.Success {
+			success = 1
+		}
+		whereClause += fmt.Sprintf(" AND success = :%d", argIndex)
+		args = append(args, success)
+		argIndex++
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM login_history %s", whereClause)
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count login history: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query := fmt.Sprintf(`
+		SELECT id, username, user_id, success, failure_reason, ip_address, user_agent, request_id, attempted_at
+		FROM login_history
+		%s
+		ORDER BY attempted_at DESC, id DESC
+		OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
+	`, whereClause, offset, filter.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query login history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.LoginHistory{}
+	for rows.Next() {
+		var h models.LoginHistory
+		var userID sql.NullInt64
+		var success int
+		var failureReason, ipAddress, userAgent, requestID sql.NullString
+
+		err := rows.Scan(
+			&h.ID, &h.Username, &userID, &success, &failureReason,
+			&ipAddress, &userAgent, &requestID, &h.AttemptedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan login history: %w", err)
+		}
+
+		if userID.Valid {
+			h.UserID = &userID.Int64
+		}
+		h.Success = success == 1
+		h.FailureReason = failureReason.String
+		h.IPAddress = ipAddress.String
+		h.UserAgent = userAgent.String
+		h.RequestID = requestID.String
+
+		history = append(history, h)
+	}
+
+	return history, total, nil
+}
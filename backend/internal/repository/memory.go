@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+// MemoryStore holds the rows of the in-memory repositories. A single lock
+// guards all of them, so a transaction and the stock change it makes are
+// applied together, as they are in one database transaction. The memory
+// repositories keep no audit log and know nothing of accounting periods;
+// they exist so handlers and services can be tested without a database.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	products     map[int64]models.Product
+	revisions    map[int64][]models.ProductRevision
+	stores       map[int64]models.Store
+	transactions []models.Transaction
+	users        map[int64]models.User
+	userStores   map[int64][]int64
+
+	nextProductID     int64
+	nextRevisionID    int64
+	nextStoreID       int64
+	nextTransactionID int64
+	nextUserID        int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		products:   map[int64]models.Product{},
+		revisions:  map[int64][]models.ProductRevision{},
+		stores:     map[int64]models.Store{},
+		users:      map[int64]models.User{},
+		userStores: map[int64][]int64{},
+	}
+}
+
+// now is CURRENT_TIMESTAMP for the memory repositories. It is truncated to
+// microseconds, the precision of an Oracle TIMESTAMP, so values survive a
+// round trip through a cursor unchanged.
+func (m *MemoryStore) now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// userName returns a user's full name, or "" for an unknown user. The
+// caller must hold the lock.
+func (m *MemoryStore) userName(id int64) string {
+	return m.users[id].FullName
+}
+
+// containsFold reports whether any of values contains search, ignoring case,
+// like the UPPER(...) LIKE filters of the SQL repositories
+func containsFold(search string, values ...string) bool {
+	term := strings.ToUpper(search)
+	for _, v := range values {
+		if strings.Contains(strings.ToUpper(v), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeAllows applies a store scope to a possibly NULL store ID the way
+// storeScopeClause does: a restricted scope never matches NULL
+func scopeAllows(scope *models.StoreScope, storeID *int64) bool {
+	if scope == nil || scope.All {
+		return true
+	}
+	return storeID != nil && scope.Allows(*storeID)
+}
+
+// after reports whether the row at (t, id) comes after the cursor in
+// (time, id) order, descending when desc is set
+func (k *keysetCursor) after(t time.Time, id int64, desc bool) bool {
+	if desc {
+		return t.Before(k.Time) || (t.Equal(k.Time) && id < k.ID)
+	}
+	return t.After(k.Time) || (t.Equal(k.Time) && id > k.ID)
+}
+
+var (
+	_ ProductRepository     = (*MemoryProductRepository)(nil)
+	_ StoreRepository       = (*MemoryStoreRepository)(nil)
+	_ TransactionRepository = (*MemoryTransactionRepository)(nil)
+	_ UserRepository        = (*MemoryUserRepository)(nil)
+)
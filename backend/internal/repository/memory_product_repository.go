@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"pos-backoffice/internal/models"
+)
+
+// MemoryProductRepository is the ProductRepository kept in a MemoryStore
+type MemoryProductRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryProductRepository(m *MemoryStore) *MemoryProductRepository {
+	return &MemoryProductRepository{m: m}
+}
+
+// FindAll retrieves products with pagination and search
+func (r *MemoryProductRepository) FindAll(page, pageSize int, search string, status string) ([]models.Product, int64, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	products := r.filter(search, status)
+	total := int64(len(products))
+
+	offset := (page - 1) * pageSize
+	if offset > len(products) {
+		offset = len(products)
+	}
+	end := offset + pageSize
+	if end > len(products) {
+		end = len(products)
+	}
+	return products[offset:end], total, nil
+}
+
+// FindPage retrieves the page of products after cursor, newest first, and
+// the cursor for the page after it ("" on the last page). The total is only
+// counted when includeTotal is set.
+func (r *MemoryProductRepository) FindPage(pageSize int, search, status, cursor string, includeTotal bool) ([]models.Product, string, *int64, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	matches := r.filter(search, status)
+
+	var total *int64
+	if includeTotal {
+		count := int64(len(matches))
+		total = &count
+	}
+
+	products := []models.Product{}
+	for _, p := range matches {
+		if after == nil || after.after(p.CreatedAt, p.ID, true) {
+			products = append(products, p)
+		}
+	}
+
+	next := ""
+	if len(products) > pageSize {
+		products = products[:pageSize]
+		last := products[len(products)-1]
+		next = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return products, next, total, nil
+}
+
+// filter returns the products matching the list filters, newest first. The
+// caller must hold the lock.
+func (r *MemoryProductRepository) filter(search, status string) []models.Product {
+	products := []models.Product{}
+	for _, p := range r.m.products {
+		if status != "" && p.Status != status {
+			continue
+		}
+		if search != "" && !containsFold(search, p.Name, p.SKU) {
+			continue
+		}
+		products = append(products, p)
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		if !products[i].CreatedAt.Equal(products[j].CreatedAt) {
+			return products[i].CreatedAt.After(products[j].CreatedAt)
+		}
+		return products[i].ID > products[j].ID
+	})
+	return products
+}
+
+// FindByID retrieves a product by ID
+func (r *MemoryProductRepository) FindByID(id int64) (*models.Product, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	p, ok := r.m.products[id]
+	if !ok {
+		return nil, fmt.Errorf("product not found")
+	}
+	return &p, nil
+}
+
+// FindBySKU retrieves a product by SKU
+func (r *MemoryProductRepository) FindBySKU(sku string) (*models.Product, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	for _, p := range r.m.products {
+		if p.SKU == sku {
+			return &p, nil
+		}
+	}
+	return nil, nil // SKU not found is not an error
+}
+
+// Create creates a new product, posting its initial stock as an
+// OPENING_BALANCE movement
+func (r *MemoryProductRepository) Create(product *models.Product, audit *models.AuditContext) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, p := range r.m.products {
+		if p.SKU == product.SKU {
+			return fmt.Errorf("failed to create product: sku %s already exists", product.SKU)
+		}
+	}
+
+	r.m.nextProductID++
+	now := r.m.now()
+	product.ID = r.m.nextProductID
+	product.Version = 1
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	r.m.products[product.ID] = *product
+
+	if err := r.insertRevision(nil, product, nil, product.CreatedBy); err != nil {
+		return err
+	}
+
+	if product.Stock > 0 {
+		opening := models.Transaction{
+			TransactionType: "OPENING_BALANCE",
+			ProductID:       product.ID,
+			Quantity:        product.Stock,
+			UnitPrice:       product.Cost,
+			TotalAmount:     product.Cost * float64(product.Stock),
+			Notes:           "Opening balance",
+			CreatedBy:       product.CreatedBy,
+		}
+		if audit != nil {
+			opening.ImpersonatedBy = audit.ImpersonatorID
+		}
+		r.m.nextTransactionID++
+		opening.ID = r.m.nextTransactionID
+		opening.TransactionDate = now
+		r.m.transactions = append(r.m.transactions, opening)
+	}
+
+	return nil
+}
+
+// Update updates an existing product and records the change as a new
+// revision. A non-zero product.Version makes the update conditional on the
+// product still being at that version; on success it holds the new version.
+func (r *MemoryProductRepository) Update(product *models.Product, audit *models.AuditContext) error {
+	return r.update(product, nil)
+}
+
+// Revert writes a product whose fields were restored from an older revision.
+// The result is stored as a new revision that points back at the old one.
+func (r *MemoryProductRepository) Revert(product *models.Product, revision int, audit *models.AuditContext) error {
+	return r.update(product, &revision)
+}
+
+func (r *MemoryProductRepository) update(product *models.Product, revertedFrom *int) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	before, ok := r.m.products[product.ID]
+	if !ok {
+		return fmt.Errorf("product not found")
+	}
+	if err := checkVersion(before.Version, product.Version); err != nil {
+		return err
+	}
+
+	after := before
+	after.Name = product.Name
+	after.Description = product.Description
+	after.Price = product.Price
+	after.Cost = product.Cost
+	after.UpdatedBy = product.UpdatedBy
+	after.Version++
+	r.m.products[product.ID] = after
+
+	if err := r.insertRevision(&before, &after, revertedFrom, product.UpdatedBy); err != nil {
+		return err
+	}
+
+	product.Version = after.Version
+	return nil
+}
+
+// Delete soft deletes a product (sets status to INACTIVE). A non-zero
+// version makes the delete conditional on the product still being at it.
+func (r *MemoryProductRepository) Delete(id int64, userID int64, version int, audit *models.AuditContext) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	before, ok := r.m.products[id]
+	if !ok {
+		return fmt.Errorf("product not found")
+	}
+	if err := checkVersion(before.Version, version); err != nil {
+		return err
+	}
+
+	after := before
+	after.Status = "INACTIVE"
+	after.UpdatedBy = userID
+	after.Version++
+	r.m.products[id] = after
+
+	return r.insertRevision(&before, &after, nil, userID)
+}
+
+// FindRevisions returns a product's revisions, newest first
+func (r *MemoryProductRepository) FindRevisions(productID int64) ([]models.ProductRevision, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	stored := r.m.revisions[productID]
+	revisions := make([]models.ProductRevision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		rev := stored[i]
+		rev.CreatedByName = r.m.userName(rev.CreatedBy)
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+// FindRevision returns one revision of a product
+func (r *MemoryProductRepository) FindRevision(productID int64, revision int) (*models.ProductRevision, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	for _, rev := range r.m.revisions[productID] {
+		if rev.Revision == revision {
+			rev.CreatedByName = r.m.userName(rev.CreatedBy)
+			return &rev, nil
+		}
+	}
+	return nil, fmt.Errorf("revision not found")
+}
+
+// insertRevision stores the product's new state as its next revision, like
+// the SQL insertRevision. The caller must hold the write lock.
+func (r *MemoryProductRepository) insertRevision(before, after *models.Product, revertedFrom *int, userID int64) error {
+	var changes json.RawMessage
+	if before != nil {
+		diff, err := diffJSON(snapshotProduct(before), snapshotProduct(after))
+		if err != nil {
+			return fmt.Errorf("failed to build revision diff: %w", err)
+		}
+		if diff == "" {
+			return nil
+		}
+		changes = json.RawMessage(diff)
+	}
+
+	r.m.nextRevisionID++
+	r.m.revisions[after.ID] = append(r.m.revisions[after.ID], models.ProductRevision{
+		ID:           r.m.nextRevisionID,
+		ProductID:    after.ID,
+		Revision:     len(r.m.revisions[after.ID]) + 1,
+		SKU:          after.SKU,
+		Name:         after.Name,
+		Description:  after.Description,
+		Price:        after.Price,
+		Cost:         after.Cost,
+		Status:       after.Status,
+		Changes:      changes,
+		RevertedFrom: revertedFrom,
+		CreatedBy:    userID,
+		CreatedAt:    r.m.now(),
+	})
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"pos-backoffice/internal/models"
+)
+
+// MemoryStoreRepository is the StoreRepository kept in a MemoryStore
+type MemoryStoreRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryStoreRepository(m *MemoryStore) *MemoryStoreRepository {
+	return &MemoryStoreRepository{m: m}
+}
+
+// GetAll returns all active stores visible in the scope with optional search
+func (r *MemoryStoreRepository) GetAll(search string, scope *models.StoreScope) ([]models.Store, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	stores := []models.Store{}
+	for _, store := range r.m.stores {
+		if store.Status != "ACTIVE" {
+			continue
+		}
+		if search != "" && !containsFold(search, store.Name, store.Code) {
+			continue
+		}
+		if !scopeAllows(scope, &store.ID) {
+			continue
+		}
+		stores = append(stores, store)
+	}
+
+	sort.Slice(stores, func(i, j int) bool {
+		if stores[i].Name != stores[j].Name {
+			return stores[i].Name < stores[j].Name
+		}
+		return stores[i].ID < stores[j].ID
+	})
+	return stores, nil
+}
+
+// GetByID returns a store by ID, or sql.ErrNoRows like the SQL repository
+func (r *MemoryStoreRepository) GetByID(id int64) (*models.Store, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	store, ok := r.m.stores[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &store, nil
+}
+
+// Create creates a new store
+func (r *MemoryStoreRepository) Create(store *models.Store, audit *models.AuditContext) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, s := range r.m.stores {
+		if s.Code == store.Code {
+			return fmt.Errorf("store code %s already exists", store.Code)
+		}
+	}
+
+	r.m.nextStoreID++
+	now := r.m.now()
+	store.ID = r.m.nextStoreID
+	store.Version = 1
+	store.CreatedAt = now
+	store.UpdatedAt = now
+	r.m.stores[store.ID] = *store
+	return nil
+}
+
+// Update updates an existing store. A non-zero store.Version makes the
+// update conditional on the store still being at that version; on success
+// the store holds the new version and timestamps.
+func (r *MemoryStoreRepository) Update(store *models.Store, audit *models.AuditContext) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	before, ok := r.m.stores[store.ID]
+	if !ok {
+		return fmt.Errorf("store not found")
+	}
+	if err := checkVersion(before.Version, store.Version); err != nil {
+		return err
+	}
+	for _, s := range r.m.stores {
+		if s.ID != store.ID && s.Code == store.Code {
+			return fmt.Errorf("store code %s already exists", store.Code)
+		}
+	}
+
+	after := before
+	after.Code = store.Code
+	after.Name = store.Name
+	after.Address = store.Address
+	after.Phone = store.Phone
+	after.Status = store.Status
+	after.UpdatedBy = store.UpdatedBy
+	after.UpdatedAt = r.m.now()
+	after.Version++
+	r.m.stores[store.ID] = after
+
+	*store = after
+	return nil
+}
+
+// Delete soft deletes a store. A non-zero version makes the delete
+// conditional on the store still being at it.
+func (r *MemoryStoreRepository) Delete(id int64, userID int64, version int, audit *models.AuditContext) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	store, ok := r.m.stores[id]
+	if !ok {
+		return fmt.Errorf("store not found")
+	}
+	if err := checkVersion(store.Version, version); err != nil {
+		return err
+	}
+
+	store.Status = "INACTIVE"
+	store.UpdatedBy = userID
+	store.UpdatedAt = r.m.now()
+	store.Version++
+	r.m.stores[id] = store
+	return nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+
+	"pos-backoffice/internal/models"
+)
+
+// MemoryTransactionRepository is the TransactionRepository kept in a
+// MemoryStore
+type MemoryTransactionRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryTransactionRepository(m *MemoryStore) *MemoryTransactionRepository {
+	return &MemoryTransactionRepository{m: m}
+}
+
+// Create creates a new transaction and updates product stock under the same
+// lock, so concurrent movements never lose an update
+func (r *MemoryTransactionRepository) Create(tx *models.Transaction, audit *models.AuditContext) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	product, ok := r.m.products[tx.ProductID]
+	if !ok {
+		return fmt.Errorf("product not found")
+	}
+	if tx.StoreID != nil {
+		if _, ok := r.m.stores[*tx.StoreID]; !ok {
+			return fmt.Errorf("store not found")
+		}
+	}
+
+	r.m.nextTransactionID++
+	now := r.m.now()
+	tx.ID = r.m.nextTransactionID
+	tx.TransactionDate = now
+	r.m.transactions = append(r.m.transactions, *tx)
+
+	if tx.TransactionType == "INCREASE" {
+		product.Stock += tx.Quantity
+	} else {
+		product.Stock -= tx.Quantity
+	}
+	product.UpdatedAt = now
+	r.m.products[product.ID] = product
+
+	return nil
+}
+
+// GetAll returns transactions matching the filter within the store scope,
+// with the total count of matches for pagination
+func (r *MemoryTransactionRepository) GetAll(filter *models.TransactionFilter, scope *models.StoreScope) ([]models.Transaction, int, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	transactions := r.filter(filter, scope)
+	total := len(transactions)
+
+	sortKey, ok := transactionSortKeys[filter.Sort]
+	if !ok {
+		sortKey = transactionSortKeys["date"]
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := &transactions[i], &transactions[j]
+		// NULLS LAST in either direction
+		aNull, bNull := a.StoreID == nil && filter.Sort == "store", b.StoreID == nil && filter.Sort == "store"
+		if aNull != bNull {
+			return bNull
+		}
+		c := sortKey(a, b)
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if filter.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	offset := (filter.Page - 1) * filter.Limit
+	if offset > len(transactions) {
+		offset = len(transactions)
+	}
+	end := offset + filter.Limit
+	if end > len(transactions) {
+		end = len(transactions)
+	}
+	return transactions[offset:end], total, nil
+}
+
+// GetPage returns the page of transactions after cursor in date order, and
+// the cursor for the page after it ("" on the last page). The total number
+// of matches is only counted when includeTotal is set. filter.Sort and
+// filter.Page are ignored.
+func (r *MemoryTransactionRepository) GetPage(filter *models.TransactionFilter, cursor string, includeTotal bool, scope *models.StoreScope) ([]models.Transaction, string, *int, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	matches := r.filter(filter, scope)
+
+	var total *int
+	if includeTotal {
+		count := len(matches)
+		total = &count
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := &matches[i], &matches[j]
+		if !a.TransactionDate.Equal(b.TransactionDate) {
+			return a.TransactionDate.Before(b.TransactionDate) != filter.Desc
+		}
+		return (a.ID < b.ID) != filter.Desc
+	})
+
+	transactions := []models.Transaction{}
+	for _, t := range matches {
+		if after == nil || after.after(t.TransactionDate, t.ID, filter.Desc) {
+			transactions = append(transactions, t)
+		}
+	}
+
+	next := ""
+	if len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+		last := transactions[len(transactions)-1]
+		next = encodeCursor(last.TransactionDate, last.ID)
+	}
+	return transactions, next, total, nil
+}
+
+// filter returns copies of the transactions matching the filter and scope,
+// with the names the SQL repository joins in. The caller must hold the lock.
+func (r *MemoryTransactionRepository) filter(filter *models.TransactionFilter, scope *models.StoreScope) []models.Transaction {
+	transactions := []models.Transaction{}
+	for _, t := range r.m.transactions {
+		switch {
+		case filter.From != nil && t.TransactionDate.Before(*filter.From),
+			filter.To != nil && !t.TransactionDate.Before(*filter.To),
+			filter.Type != "" && t.TransactionType != filter.Type,
+			filter.ProductID != 0 && t.ProductID != filter.ProductID,
+			filter.StoreID != 0 && (t.StoreID == nil || *t.StoreID != filter.StoreID),
+			filter.UserID != 0 && t.CreatedBy != filter.UserID,
+			filter.MinAmount != nil && t.TotalAmount < *filter.MinAmount,
+			filter.MaxAmount != nil && t.TotalAmount > *filter.MaxAmount,
+			!scopeAllows(scope, t.StoreID):
+			continue
+		}
+
+		t.ProductName = r.m.products[t.ProductID].Name
+		if t.StoreID != nil {
+			t.StoreName = r.m.stores[*t.StoreID].Name
+		}
+		t.CreatedByName = r.m.userName(t.CreatedBy)
+		if t.ImpersonatedBy != nil {
+			t.ImpersonatedByName = r.m.userName(*t.ImpersonatedBy)
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions
+}
+
+// transactionSortKeys compares two transactions by the column
+// TransactionFilter.Sort names, mirroring transactionSortColumns
+var transactionSortKeys = map[string]func(a, b *models.Transaction) int{
+	"date": func(a, b *models.Transaction) int {
+		return a.TransactionDate.Compare(b.TransactionDate)
+	},
+	"amount": func(a, b *models.Transaction) int {
+		return cmp.Compare(a.TotalAmount, b.TotalAmount)
+	},
+	"quantity": func(a, b *models.Transaction) int {
+		return cmp.Compare(a.Quantity, b.Quantity)
+	},
+	"product": func(a, b *models.Transaction) int {
+		return strings.Compare(a.ProductName, b.ProductName)
+	},
+	"store": func(a, b *models.Transaction) int {
+		return strings.Compare(a.StoreName, b.StoreName)
+	},
+	"type": func(a, b *models.Transaction) int {
+		return strings.Compare(a.TransactionType, b.TransactionType)
+	},
+}
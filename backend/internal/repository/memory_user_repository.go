@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+
+	"pos-backoffice/internal/models"
+)
+
+// MemoryUserRepository is the UserRepository kept in a MemoryStore
+type MemoryUserRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryUserRepository(m *MemoryStore) *MemoryUserRepository {
+	return &MemoryUserRepository{m: m}
+}
+
+// FindByUsername finds an active user by username
+func (r *MemoryUserRepository) FindByUsername(username string) (*models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	for _, user := range r.m.users {
+		if user.Username == username && user.Status == "ACTIVE" {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+// FindByID finds a user by ID
+func (r *MemoryUserRepository) FindByID(id int64) (*models.User, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	user, ok := r.m.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &user, nil
+}
+
+// Create creates a new user
+func (r *MemoryUserRepository) Create(user *models.User) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, u := range r.m.users {
+		if u.Username == user.Username {
+			return fmt.Errorf("failed to create user: username %s already exists", user.Username)
+		}
+	}
+
+	r.m.nextUserID++
+	now := r.m.now()
+	user.ID = r.m.nextUserID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.m.users[user.ID] = *user
+	return nil
+}
+
+// UpdateStatus activates or deactivates a user
+func (r *MemoryUserRepository) UpdateStatus(id int64, status string, audit *models.AuditContext) error {
+	return r.update(id, func(user *models.User) { user.Status = status })
+}
+
+// UpdatePassword replaces a user's password hash
+func (r *MemoryUserRepository) UpdatePassword(id int64, passwordHash string, audit *models.AuditContext) error {
+	return r.update(id, func(user *models.User) { user.PasswordHash = passwordHash })
+}
+
+// UpdateRole assigns a role to a user
+func (r *MemoryUserRepository) UpdateRole(id int64, role string, audit *models.AuditContext) error {
+	return r.update(id, func(user *models.User) { user.Role = role })
+}
+
+// update applies change to a user under the write lock
+func (r *MemoryUserRepository) update(id int64, change func(user *models.User)) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	user, ok := r.m.users[id]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+	change(&user)
+	user.UpdatedAt = r.m.now()
+	r.m.users[id] = user
+	return nil
+}
+
+// FindStoreIDs returns the stores a user is assigned to
+func (r *MemoryUserRepository) FindStoreIDs(userID int64) ([]int64, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	storeIDs := append([]int64{}, r.m.userStores[userID]...)
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+	return storeIDs, nil
+}
+
+// ReplaceStores sets the complete list of stores a user is assigned to
+func (r *MemoryUserRepository) ReplaceStores(userID int64, storeIDs []int64, audit *models.AuditContext) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, storeID := range storeIDs {
+		if _, ok := r.m.stores[storeID]; !ok {
+			return fmt.Errorf("failed to assign store %d: store not found", storeID)
+		}
+	}
+
+	r.m.userStores[userID] = append([]int64{}, storeIDs...)
+	return nil
+}
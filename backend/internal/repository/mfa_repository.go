@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"pos-backoffice/internal/database"
+	"pos-backoffice/internal/models"
+)
+
+type MFARepository struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// FindByUserID returns a user's TOTP enrollment, or nil if there is none
+func (r *MFARepository) FindByUserID(userID int64) (*models.UserMFA, error) {
+	query := `
+		SELECT user_id, secret, enabled_at, last_used_step, created_at
+		FROM user_mfa
+		WHERE user_id = :1
+	`
+
+	var m models.UserMFA
+	var enabledAt sql.NullTime
+	err := r.db.QueryRow(query, userID).Scan(&m.UserID, &m.Secret, &enabledAt, &m.LastUsedStep, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query MFA enrollment: %w", err)
+	}
+
+	if enabledAt.Valid {
+		m.EnabledAt = &enabledAt.Time
+	}
+
+	return &m, nil
+}
+
+// SavePending starts or restarts an unconfirmed enrollment with a new secret
+func (r *MFARepository) SavePending(userID int64, secret string) error {
+	query := `
+		MERGE INTO user_mfa m
+		USING (SELECT :1 AS user_id FROM dual) s
+		ON (m.user_id = s.user_id)
+		WHEN MATCHED THEN UPDATE SET
+			secret = :2, enabled_at = NULL, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+			WHERE m.enabled_at IS NULL
+		WHEN NOT MATCHED THEN INSERT (user_id, secret)
+			VALUES (:3, :4)
+	`
+	args := []interface{}{userID, secret, userID, secret}
+	if database.Current() != database.Oracle {
+		query = `
+			INSERT INTO user_mfa (user_id, secret) VALUES (:1, :2)
+			ON CONFLICT (user_id) DO UPDATE SET
+				secret = excluded.secret, enabled_at = NULL, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+			WHERE user_mfa.enabled_at IS NULL
+		`
+		args = []interface{}{userID, secret}
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to save MFA enrollment: %w", err)
+	}
+	return nil
+}
+
+// Enable confirms a pending enrollment and stores its first recovery codes
+func (r *MFARepository) Enable(userID, step int64, codeHashes []string, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	result, err := dbTx.Exec(`
+		UPDATE user_mfa SET enabled_at = CURRENT_TIMESTAMP, last_used_step = :1
+		WHERE user_id = :2 AND enabled_at IS NULL
+	`, step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable MFA: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("no pending MFA enrollment")
+	}
+
+	if err := replaceRecoveryCodes(dbTx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	err = insertAudit(dbTx, audit, models.EntityUser, auditID(userID), models.AuditUpdate,
+		map[string]bool{"mfa_enabled": false},
+		map[string]bool{"mfa_enabled": true},
+	)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// UseStep records a TOTP time step as used. It reports false if the step is
+// not newer than the last accepted one, i.e. the code is being replayed.
+func (r *MFARepository) UseStep(userID, step int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_mfa SET last_used_step = :1
+		WHERE user_id = :2 AND last_used_step < :3
+	`, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record MFA code use: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// UseRecoveryCode consumes an unused recovery code, reporting whether one matched
+func (r *MFARepository) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = :1 AND code_hash = :2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (r *MFARepository) CountRecoveryCodes(userID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = :1 AND used_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// ReplaceRecoveryCodes invalidates all existing recovery codes and stores new ones
+func (r *MFARepository) ReplaceRecoveryCodes(userID int64, codeHashes []string, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if err := replaceRecoveryCodes(dbTx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityUser, auditID(userID), models.AuditRotate, nil, nil); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Delete removes a user's enrollment and recovery codes
+func (r *MFARepository) Delete(userID int64, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if _, err := dbTx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = :1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	var enabled int
+	err = dbTx.QueryRow(`SELECT COUNT(*) FROM user_mfa WHERE user_id = :1 AND enabled_at IS NOT NULL`, userID).Scan(&enabled)
+	if err != nil {
+		return fmt.Errorf("failed to query MFA enrollment: %w", err)
+	}
+
+	if _, err := dbTx.Exec(`DELETE FROM user_mfa WHERE user_id = :1`, userID); err != nil {
+		return fmt.Errorf("failed to delete MFA enrollment: %w", err)
+	}
+
+	// Dropping a pending enrollment changes nothing worth auditing
+	if enabled > 0 {
+		err = insertAudit(dbTx, audit, models.EntityUser, auditID(userID), models.AuditUpdate,
+			map[string]bool{"mfa_enabled": true},
+			map[string]bool{"mfa_enabled": false},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbTx.Commit()
+}
+
+func replaceRecoveryCodes(dbTx *sql.Tx, userID int64, codeHashes []string) error {
+	if _, err := dbTx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = :1`, userID); err != nil {
+		return fmt.Errorf("failed to clear recovery codes: %w", err)
+	}
+
+	for _, hash := range codeHashes {
+		_, err := dbTx.Exec(`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (:1, :2)`, userID, hash)
+		if err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+type OIDCRepository struct {
+	db *sql.DB
+}
+
+func NewOIDCRepository(db *sql.DB) *OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+// FindUserID returns the user linked to an identity provider subject, or 0 if none
+func (r *OIDCRepository) FindUserID(issuer, subject string) (int64, error) {
+	var userID int64
+	err := r.db.QueryRow(`
+		SELECT user_id FROM user_identities WHERE issuer = :1 AND subject = :2
+	`, issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query user identity: %w", err)
+	}
+	return userID, nil
+}
+
+// CreateUserWithIdentity provisions a user on first SSO login and links it to
+// the provider subject
+func (r *OIDCRepository) CreateUserWithIdentity(user *models.User, issuer, subject string, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		INSERT INTO users (username, password_hash, full_name, email, role, status)
+		VALUES (:1, :2, :3, :4, :5, :6)
+		RETURNING id INTO :7
+	`
+	_, err = dbTx.Exec(query,
+		user.Username, user.PasswordHash, user.FullName, user.Email, user.Role, user.Status,
+		sql.Out{Dest: &user.ID},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	_, err = dbTx.Exec(`
+		INSERT INTO user_identities (issuer, subject, user_id) VALUES (:1, :2, :3)
+	`, issuer, subject, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to link user identity: %w", err)
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityUser, auditID(user.ID), models.AuditCreate, nil, user); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// UpdateProfile syncs the name, email and role asserted by the identity
+// provider. Only a profile that actually changed is audited.
+func (r *OIDCRepository) UpdateProfile(userID int64, fullName, email, role string, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	before, err := findUserForUpdate(dbTx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbTx.Exec(`
+		UPDATE users
+		SET full_name = :1, email = :2, role = :3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = :4
+	`, fullName, email, role, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user profile: %w", err)
+	}
+
+	_, err = dbTx.Exec(`
+		UPDATE user_identities SET last_login_at = CURRENT_TIMESTAMP WHERE user_id = :1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user identity: %w", err)
+	}
+
+	after, err := findUserForUpdate(dbTx, userID)
+	if err != nil {
+		return err
+	}
+
+	if before.FullName != after.FullName || before.Email != after.Email || before.Role != after.Role {
+		if err := insertAudit(dbTx, audit, models.EntityUser, auditID(userID), models.AuditUpdate, before, after); err != nil {
+			return err
+		}
+	}
+
+	return dbTx.Commit()
+}
+
+// HasIdentity reports whether a user signs in through an identity provider
+func (r *OIDCRepository) HasIdentity(userID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM user_identities WHERE user_id = :1`, userID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to query user identity: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CreateLogin stores the state of an authorization request in flight
+func (r *OIDCRepository) CreateLogin(stateHash, nonce, codeVerifier string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO oidc_logins (state_hash, nonce, code_verifier, expires_at)
+		VALUES (:1, :2, :3, :4)
+	`, stateHash, nonce, codeVerifier, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to store SSO login: %w", err)
+	}
+	return nil
+}
+
+// ConsumeState returns the nonce and PKCE verifier for a state and marks the
+// state used, so a callback URL cannot be replayed
+func (r *OIDCRepository) ConsumeState(stateHash string, now time.Time) (id int64, nonce, codeVerifier string, err error) {
+	result, err := r.db.Exec(`
+		UPDATE oidc_logins SET state_hash = NULL
+		WHERE state_hash = :1 AND expires_at > :2
+		RETURNING id, nonce, code_verifier INTO :3, :4, :5
+	`, stateHash, now, sql.Out{Dest: &id}, sql.Out{Dest: &nonce}, sql.Out{Dest: &codeVerifier})
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to consume SSO state: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, "", "", fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return 0, "", "", fmt.Errorf("SSO login not found or expired")
+	}
+
+	return id, nonce, codeVerifier, nil
+}
+
+// SetHandoff attaches the authenticated user and a one-time handoff code to a login
+func (r *OIDCRepository) SetHandoff(id, userID int64, handoffHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE oidc_logins SET handoff_hash = :1, user_id = :2, expires_at = :3
+		WHERE id = :4
+	`, handoffHash, userID, expiresAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to store SSO handoff: %w", err)
+	}
+	return nil
+}
+
+// ConsumeHandoff deletes a completed login and returns its user
+func (r *OIDCRepository) ConsumeHandoff(handoffHash string, now time.Time) (int64, error) {
+	var userID int64
+	result, err := r.db.Exec(`
+		DELETE FROM oidc_logins
+		WHERE handoff_hash = :1 AND expires_at > :2
+		RETURNING user_id INTO :3
+	`, handoffHash, now, sql.Out{Dest: &userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume SSO handoff: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return 0, fmt.Errorf("SSO code not found or expired")
+	}
+
+	return userID, nil
+}
+
+// DeleteExpiredLogins removes abandoned logins
+func (r *OIDCRepository) DeleteExpiredLogins(now time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM oidc_logins WHERE expires_at <= :1`, now); err != nil {
+		return fmt.Errorf("failed to delete expired SSO logins: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+type PasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// Create stores a new reset token for a user and invalidates any earlier
+// unused ones, so only the most recent link works
+func (r *PasswordResetRepository) Create(userID int64, tokenHash string, expiresAt time.Time, requestedIP string) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	_, err = dbTx.Exec(`
+		UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = :1 AND used_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	_, err = dbTx.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, requested_ip)
+		VALUES (:1, :2, :3, :4)
+	`, userID, tokenHash, expiresAt, requestedIP)
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	return dbTx.Commit()
+}
+
+// ResetPassword consumes a token and sets the new password hash in one
+// transaction, returning the user the token belonged to
+func (r *PasswordResetRepository) ResetPassword(tokenHash, passwordHash string, now time.Time, audit *models.AuditContext) (int64, error) {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	var userID int64
+	result, err := dbTx.Exec(`
+		UPDATE password_reset_tokens SET used_at = :1
+		WHERE token_hash = :2 AND used_at IS NULL AND expires_at > :3
+		RETURNING user_id INTO :4
+	`, now, tokenHash, now, sql.Out{Dest: &userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume reset token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return 0, fmt.Errorf("reset token not found or expired")
+	}
+
+	result, err = dbTx.Exec(`
+		UPDATE users SET password_hash = :1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = :2 AND status = 'ACTIVE'
+	`, passwordHash, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update user password: %w", err)
+	}
+
+	rows, err = result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return 0, fmt.Errorf("user not found")
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityUser, auditID(userID), models.AuditPasswordReset, nil, nil); err != nil {
+		return 0, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit password reset: %w", err)
+	}
+
+	return userID, nil
+}
+
+// DeleteExpired removes tokens that can no longer be used
+func (r *PasswordResetRepository) DeleteExpired(now time.Time) error {
+	_, err := r.db.Exec(`
+		DELETE FROM password_reset_tokens WHERE expires_at <= :1 OR used_at IS NOT NULL
+	`, now)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired reset tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+var (
+	// ErrPeriodClosed is returned when a transaction would be dated inside a
+	// closed accounting period, or a closed period is closed again
+	ErrPeriodClosed = errors.New("accounting period is closed")
+	// ErrPeriodNotClosed is returned when reopening a period that is open
+	ErrPeriodNotClosed = errors.New("accounting period is not closed")
+)
+
+// PeriodRepository stores accounting periods. Closing one freezes the
+// ledger for its dates and records what the stock was worth at its end.
+type PeriodRepository struct {
+	db *sql.DB
+}
+
+func NewPeriodRepository(db *sql.DB) *PeriodRepository {
+	return &PeriodRepository{db: db}
+}
+
+const periodSelect = `
+	SELECT ap.id, ap.period, ap.period_start, ap.period_end, ap.status, ap.stock_value,
+	       ap.closed_by, cu.full_name, ap.closed_at,
+	       ap.reopened_by, ru.full_name, ap.reopened_at, ap.reopen_reason
+	FROM accounting_periods ap
+	LEFT JOIN users cu ON ap.closed_by = cu.id
+	LEFT JOIN users ru ON ap.reopened_by = ru.id
+`
+
+// FindAll returns every period that has been closed at least once, newest
+// first
+func (r *PeriodRepository) FindAll() ([]models.AccountingPeriod, error) {
+	rows, err := r.db.Query(periodSelect + ` ORDER BY ap.period_start DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounting periods: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []models.AccountingPeriod{}
+	for rows.Next() {
+		period, err := scanPeriod(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, *period)
+	}
+
+	return periods, rows.Err()
+}
+
+// FindByPeriod returns a period with its closing valuation, or nil when it
+// has never been closed
+func (r *PeriodRepository) FindByPeriod(period string) (*models.AccountingPeriod, error) {
+	p, err := scanPeriod(r.db.QueryRow(periodSelect+` WHERE ap.period = :1`, period))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT v.product_id, pr.sku, pr.name, v.quantity, v.unit_cost, v.value
+		FROM period_valuations v
+		JOIN products pr ON v.product_id = pr.id
+		WHERE v.period_id = :1
+		ORDER BY pr.sku
+	`, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query period valuation: %w", err)
+	}
+	defer rows.Close()
+
+	p.Valuation = []models.PeriodValuation{}
+	for rows.Next() {
+		var v models.PeriodValuation
+		if err := rows.Scan(&v.ProductID, &v.SKU, &v.Name, &v.Quantity, &v.UnitCost, &v.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan period valuation: %w", err)
+		}
+		p.Valuation = append(p.Valuation, v)
+	}
+
+	return p, rows.Err()
+}
+
+// Close closes the period from start to end, creating it if needed, and
+// stores every product's stock and value at end. Closing again after a
+// reopen replaces the earlier valuation.
+func (r *PeriodRepository) Close(period string, start, end time.Time, userID int64, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := scanPeriod(tx.QueryRow(periodSelect+` WHERE ap.period = :1 FOR UPDATE OF ap.status`, period))
+	if err == sql.ErrNoRows {
+		before = &models.AccountingPeriod{Period: period, Start: start, End: end, Status: models.PeriodOpen}
+		_, err = tx.Exec(`
+			INSERT INTO accounting_periods (period, period_start, period_end, status)
+			VALUES (:1, :2, :3, :4)
+			RETURNING id INTO :5
+		`, period, start, end, models.PeriodOpen, sql.Out{Dest: &before.ID})
+		if isUniqueViolation(err) {
+			// Closed by someone else a moment ago
+			return ErrPeriodClosed
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create accounting period: %w", err)
+		}
+	} else if err != nil {
+		return err
+	}
+	if before.Status == models.PeriodClosed {
+		return ErrPeriodClosed
+	}
+
+	if _, err := tx.Exec(`DELETE FROM period_valuations WHERE period_id = :1`, before.ID); err != nil {
+		return fmt.Errorf("failed to clear period valuation: %w", err)
+	}
+
+	previous, err := latestSnapshot(tx, end)
+	if err != nil {
+		return err
+	}
+	stock, args := stockAsOfQuery(previous, end, 0, 2)
+	query := fmt.Sprintf(`
+		INSERT INTO period_valuations (period_id, product_id, quantity, unit_cost, value)
+		SELECT :1, id, quantity, unit_cost, ROUND(quantity * unit_cost, 2) FROM (%s)
+	`, stock)
+	if _, err := tx.Exec(query, append([]interface{}{before.ID}, args...)...); err != nil {
+		return fmt.Errorf("failed to store period valuation: %w", err)
+	}
+
+	var stockValue float64
+	err = tx.QueryRow(`SELECT NVL(SUM(value), 0) FROM period_valuations WHERE period_id = :1`, before.ID).Scan(&stockValue)
+	if err != nil {
+		return fmt.Errorf("failed to total period valuation: %w", err)
+	}
+
+	after := *before
+	after.Status = models.PeriodClosed
+	after.StockValue = &stockValue
+	after.ClosedBy = &userID
+	_, err = tx.Exec(`
+		UPDATE accounting_periods
+		SET status = :1, stock_value = :2, closed_by = :3, closed_at = CURRENT_TIMESTAMP
+		WHERE id = :4
+		RETURNING closed_at INTO :5
+	`, after.Status, stockValue, userID, before.ID, sql.Out{Dest: &after.ClosedAt})
+	if err != nil {
+		return fmt.Errorf("failed to close accounting period: %w", err)
+	}
+
+	if err := insertAudit(tx, audit, models.EntityPeriod, period, models.AuditClose, before, &after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reopen lets transactions be posted into a closed period again. The reason
+// is kept on the period and in the audit log.
+func (r *PeriodRepository) Reopen(period string, userID int64, reason string, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := scanPeriod(tx.QueryRow(periodSelect+` WHERE ap.period = :1 FOR UPDATE OF ap.status`, period))
+	if err == sql.ErrNoRows {
+		return ErrPeriodNotClosed
+	}
+	if err != nil {
+		return err
+	}
+	if before.Status != models.PeriodClosed {
+		return ErrPeriodNotClosed
+	}
+
+	after := *before
+	after.Status = models.PeriodOpen
+	after.ReopenedBy = &userID
+	after.ReopenReason = reason
+	_, err = tx.Exec(`
+		UPDATE accounting_periods
+		SET status = :1, reopened_by = :2, reopened_at = CURRENT_TIMESTAMP, reopen_reason = :3
+		WHERE id = :4
+		RETURNING reopened_at INTO :5
+	`, after.Status, userID, reason, before.ID, sql.Out{Dest: &after.ReopenedAt})
+	if err != nil {
+		return fmt.Errorf("failed to reopen accounting period: %w", err)
+	}
+
+	if err := insertAudit(tx, audit, models.EntityPeriod, period, models.AuditReopen, before, &after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkPeriodOpen returns ErrPeriodClosed when at falls inside a closed
+// accounting period. Posting paths call it with the date the database gave
+// the transaction, inside the same database transaction.
+func checkPeriodOpen(q queryRower, at time.Time) error {
+	var closed int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM accounting_periods
+		WHERE status = 'CLOSED' AND period_start <= :1 AND period_end > :2
+	`, at, at).Scan(&closed)
+	if err != nil {
+		return fmt.Errorf("failed to check accounting period: %w", err)
+	}
+	if closed > 0 {
+		return ErrPeriodClosed
+	}
+	return nil
+}
+
+func scanPeriod(row rowScanner) (*models.AccountingPeriod, error) {
+	var p models.AccountingPeriod
+	var stockValue sql.NullFloat64
+	var closedBy, reopenedBy sql.NullInt64
+	var closedByName, reopenedByName, reopenReason sql.NullString
+	var closedAt, reopenedAt sql.NullTime
+
+	err := row.Scan(&p.ID, &p.Period, &p.Start, &p.End, &p.Status, &stockValue,
+		&closedBy, &closedByName, &closedAt,
+		&reopenedBy, &reopenedByName, &reopenedAt, &reopenReason)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan accounting period: %w", err)
+	}
+
+	if stockValue.Valid {
+		p.StockValue = &stockValue.Float64
+	}
+	if closedBy.Valid {
+		p.ClosedBy = &closedBy.Int64
+	}
+	if closedAt.Valid {
+		p.ClosedAt = &closedAt.Time
+	}
+	if reopenedBy.Valid {
+		p.ReopenedBy = &reopenedBy.Int64
+	}
+	if reopenedAt.Valid {
+		p.ReopenedAt = &reopenedAt.Time
+	}
+	p.ClosedByName = closedByName.String
+	p.ReopenedByName = reopenedByName.String
+	p.ReopenReason = reopenReason.String
+	return &p, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloseAfterSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	start := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	// The daily job has taken a snapshot part way through the month
+	if _, err := NewInventoryRepository(db).CreateSnapshot(start.AddDate(0, 0, 9)); err != nil {
+		t.Fatal(err)
+	}
+	postMovement(t, db, "INCREASE", 2, 3, start.AddDate(0, 0, 14))
+	postMovement(t, db, "ADJUSTMENT", 3, 7, start.AddDate(0, 0, 19))
+
+	repo := NewPeriodRepository(db)
+	if err := repo.Close("2099-01", start, end, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	period, err := repo.FindByPeriod("2099-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The seeded ledger plus the movements since the snapshot, at seeded cost
+	want := map[int64]int{1: 500, 2: 453, 3: 307, 4: 400, 5: 600}
+	if len(period.Valuation) != len(want) {
+		t.Fatalf("valuation has %d products, want %d", len(period.Valuation), len(want))
+	}
+	for _, v := range period.Valuation {
+		if v.Quantity != want[v.ProductID] {
+			t.Errorf("product %d: quantity %d, want %d", v.ProductID, v.Quantity, want[v.ProductID])
+		}
+	}
+	if period.StockValue == nil {
+		t.Fatal("closed period has no stock value")
+	}
+	if *period.StockValue != 22214 {
+		t.Errorf("stock value %.2f, want 22214", *period.StockValue)
+	}
+}
@@ -2,25 +2,120 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"pos-backoffice/internal/models"
 )
 
-type ProductRepository struct {
+// ProductRepository stores products and their revision history
+type ProductRepository interface {
+	FindAll(page, pageSize int, search string, status string) ([]models.Product, int64, error)
+	FindPage(pageSize int, search, status, cursor string, includeTotal bool) ([]models.Product, string, *int64, error)
+	FindByID(id int64) (*models.Product, error)
+	FindBySKU(sku string) (*models.Product, error)
+	Create(product *models.Product, audit *models.AuditContext) error
+	Update(product *models.Product, audit *models.AuditContext) error
+	Revert(product *models.Product, revision int, audit *models.AuditContext) error
+	Delete(id int64, userID int64, version int, audit *models.AuditContext) error
+	FindRevisions(productID int64) ([]models.ProductRevision, error)
+	FindRevision(productID int64, revision int) (*models.ProductRevision, error)
+}
+
+// SQLProductRepository is the ProductRepository backed by the database
+type SQLProductRepository struct {
 	db *sql.DB
 }
 
-func NewProductRepository(db *sql.DB) *ProductRepository {
-	return &ProductRepository{db: db}
+func NewProductRepository(db *sql.DB) *SQLProductRepository {
+	return &SQLProductRepository{db: db}
 }
 
 // FindAll retrieves products with pagination and search
-func (r *ProductRepository) FindAll(page, pageSize int, search string, status string) ([]models.Product, int64, error) {
+func (r *SQLProductRepository) FindAll(page, pageSize int, search string, status string) ([]models.Product, int64, error) {
 	offset := (page - 1) * pageSize
+	whereClause, args, _ := productWhere(search, status)
+
+	// Count total records
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM products %s", whereClause)
+	var total int64
+	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	// Query with pagination using OFFSET/FETCH
+	query := fmt.Sprintf(`
+		SELECT id, sku, name, description, price, cost, stock, status, 
+		       created_at, updated_at, created_by, updated_by, version
+		FROM products
+		%s
+		ORDER BY created_at DESC, id DESC
+		OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
+	`, whereClause, offset, pageSize)
 
-	// Build WHERE clause
+	products, err := r.query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// FindPage retrieves the page of products after cursor, newest first, and
+// the cursor for the page after it ("" on the last page). The total is only
+// counted when includeTotal is set.
+func (r *SQLProductRepository) FindPage(pageSize int, search, status, cursor string, includeTotal bool) ([]models.Product, string, *int64, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	whereClause, args, argIndex := productWhere(search, status)
+
+	var total *int64
+	if includeTotal {
+		var count int64
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM products %s", whereClause)
+		if err := r.db.QueryRow(countQuery, args...).Scan(&count); err != nil {
+			return nil, "", nil, fmt.Errorf("failed to count products: %w", err)
+		}
+		total = &count
+	}
+
+	if after != nil {
+		clause, cursorArgs := after.clause("created_at", "id", true, argIndex)
+		whereClause += clause
+		args = append(args, cursorArgs...)
+	}
+
+	// One extra row tells whether there is a next page
+	query := fmt.Sprintf(`
+		SELECT id, sku, name, description, price, cost, stock, status,
+		       created_at, updated_at, created_by, updated_by, version
+		FROM products
+		%s
+		ORDER BY created_at DESC, id DESC
+		FETCH FIRST %d ROWS ONLY
+	`, whereClause, pageSize+1)
+
+	products, err := r.query(query, args...)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	next := ""
+	if len(products) > pageSize {
+		products = products[:pageSize]
+		last := products[len(products)-1]
+		next = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return products, next, total, nil
+}
+
+// productWhere builds the WHERE clause for the product list filters,
+// returning the next free bind placeholder number with it
+func productWhere(search, status string) (string, []interface{}, int) {
 	whereClause := "WHERE 1=1"
 	args := []interface{}{}
 	argIndex := 1
@@ -39,81 +134,40 @@ func (r *ProductRepository) FindAll(page, pageSize int, search string, status st
 		argIndex += 2
 	}
 
-	// Count total records
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM products %s", whereClause)
-	var total int64
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count products: %w", err)
-	}
-
-	// Query with pagination using OFFSET/FETCH
-	query := fmt.Sprintf(`
-		SELECT id, sku, name, description, price, cost, stock, status, 
-		       created_at, updated_at, created_by, updated_by
-		FROM products
-		%s
-		ORDER BY created_at DESC
-		OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
-	`, whereClause, offset, pageSize)
+	return whereClause, args, argIndex
+}
 
+// query runs a product list query and scans the rows
+func (r *SQLProductRepository) query(query string, args ...interface{}) ([]models.Product, error) {
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query products: %w", err)
+		return nil, fmt.Errorf("failed to query products: %w", err)
 	}
 	defer rows.Close()
 
 	// Initialize empty slice to ensure JSON returns [] instead of null
 	products := []models.Product{}
 	for rows.Next() {
-		var p models.Product
-		err := rows.Scan(
-			&p.ID,
-			&p.SKU,
-			&p.Name,
-			&p.Description,
-			&p.Price,
-			&p.Cost,
-			&p.Stock,
-			&p.Status,
-			&p.CreatedAt,
-			&p.UpdatedAt,
-			&p.CreatedBy,
-			&p.UpdatedBy,
-		)
+		p, err := scanProduct(rows)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan product: %w", err)
+			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
-		products = append(products, p)
+		products = append(products, *p)
 	}
 
-	return products, total, nil
+	return products, rows.Err()
 }
 
 // FindByID retrieves a product by ID
-func (r *ProductRepository) FindByID(id int64) (*models.Product, error) {
+func (r *SQLProductRepository) FindByID(id int64) (*models.Product, error) {
 	query := `
 		SELECT id, sku, name, description, price, cost, stock, status,
-		       created_at, updated_at, created_by, updated_by
+		       created_at, updated_at, created_by, updated_by, version
 		FROM products
 		WHERE id = :1
 	`
 
-	var p models.Product
-	err := r.db.QueryRow(query, id).Scan(
-		&p.ID,
-		&p.SKU,
-		&p.Name,
-		&p.Description,
-		&p.Price,
-		&p.Cost,
-		&p.Stock,
-		&p.Status,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-		&p.CreatedBy,
-		&p.UpdatedBy,
-	)
+	p, err := scanProduct(r.db.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("product not found")
@@ -122,33 +176,19 @@ func (r *ProductRepository) FindByID(id int64) (*models.Product, error) {
 		return nil, fmt.Errorf("failed to query product: %w", err)
 	}
 
-	return &p, nil
+	return p, nil
 }
 
 // FindBySKU retrieves a product by SKU
-func (r *ProductRepository) FindBySKU(sku string) (*models.Product, error) {
+func (r *SQLProductRepository) FindBySKU(sku string) (*models.Product, error) {
 	query := `
 		SELECT id, sku, name, description, price, cost, stock, status,
-		       created_at, updated_at, created_by, updated_by
+		       created_at, updated_at, created_by, updated_by, version
 		FROM products
 		WHERE sku = :1
 	`
 
-	var p models.Product
-	err := r.db.QueryRow(query, sku).Scan(
-		&p.ID,
-		&p.SKU,
-		&p.Name,
-		&p.Description,
-		&p.Price,
-		&p.Cost,
-		&p.Stock,
-		&p.Status,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-		&p.CreatedBy,
-		&p.UpdatedBy,
-	)
+	p, err := scanProduct(r.db.QueryRow(query, sku))
 
 	if err == sql.ErrNoRows {
 		return nil, nil // SKU not found is not an error
@@ -157,18 +197,24 @@ func (r *ProductRepository) FindBySKU(sku string) (*models.Product, error) {
 		return nil, fmt.Errorf("failed to query product by SKU: %w", err)
 	}
 
-	return &p, nil
+	return p, nil
 }
 
 // Create creates a new product
-func (r *ProductRepository) Create(product *models.Product) error {
+func (r *SQLProductRepository) Create(product *models.Product, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO products (sku, name, description, price, cost, stock, status, created_by, updated_by)
 		VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9)
-		RETURNING id INTO :10
+		RETURNING id, version INTO :10, :11
 	`
 
-	_, err := r.db.Exec(query,
+	_, err = tx.Exec(query,
 		product.SKU,
 		product.Name,
 		product.Description,
@@ -179,24 +225,102 @@ func (r *ProductRepository) Create(product *models.Product) error {
 		product.CreatedBy,
 		product.UpdatedBy,
 		sql.Out{Dest: &product.ID},
+		sql.Out{Dest: &product.Version},
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 
-	return nil
+	if err := insertRevision(tx, nil, product, nil, product.CreatedBy); err != nil {
+		return err
+	}
+
+	if err := insertAudit(tx, audit, models.EntityProduct, auditID(product.ID), models.AuditCreate, nil, product); err != nil {
+		return err
+	}
+
+	// Stock a product starts with enters the ledger like any other movement
+	if product.Stock > 0 {
+		if err := insertOpeningBalance(tx, product, audit); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertOpeningBalance posts the OPENING_BALANCE movement for a new
+// product's initial stock, valued at its cost and attributed to its creator
+func insertOpeningBalance(tx *sql.Tx, product *models.Product, audit *models.AuditContext) error {
+	opening := &models.Transaction{
+		TransactionType: "OPENING_BALANCE",
+		ProductID:       product.ID,
+		Quantity:        product.Stock,
+		UnitPrice:       product.Cost,
+		TotalAmount:     product.Cost * float64(product.Stock),
+		Notes:           "Opening balance",
+		CreatedBy:       product.CreatedBy,
+	}
+	if audit != nil {
+		opening.ImpersonatedBy = audit.ImpersonatorID
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO transactions (
+			transaction_type, product_id, quantity, unit_price, total_amount, notes, created_by, impersonated_by
+		)
+		VALUES (:1, :2, :3, :4, :5, :6, :7, :8)
+		RETURNING id, transaction_date INTO :9, :10
+	`, opening.TransactionType, opening.ProductID, opening.Quantity, opening.UnitPrice,
+		opening.TotalAmount, opening.Notes, opening.CreatedBy, opening.ImpersonatedBy,
+		sql.Out{Dest: &opening.ID}, sql.Out{Dest: &opening.TransactionDate})
+	if err != nil {
+		return fmt.Errorf("failed to post opening balance: %w", err)
+	}
+
+	if err := checkPeriodOpen(tx, opening.TransactionDate); err != nil {
+		return err
+	}
+
+	return insertAudit(tx, audit, models.EntityTransaction, auditID(opening.ID), models.AuditCreate, nil, opening)
+}
+
+// Update updates an existing product and records the change as a new
+// revision. A non-zero product.Version makes the update conditional on the
+// row still being at that version; on success it holds the new version.
+func (r *SQLProductRepository) Update(product *models.Product, audit *models.AuditContext) error {
+	return r.update(product, nil, audit)
+}
+
+// Revert writes a product whose fields were restored from an older revision.
+// The result is stored as a new revision that points back at the old one.
+func (r *SQLProductRepository) Revert(product *models.Product, revision int, audit *models.AuditContext) error {
+	return r.update(product, &revision, audit)
 }
 
-// Update updates an existing product
-func (r *ProductRepository) Update(product *models.Product) error {
+func (r *SQLProductRepository) update(product *models.Product, revertedFrom *int, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := r.FindByIDForUpdate(tx, product.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(before.Version, product.Version); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE products
-		SET name = :1, description = :2, price = :3, cost = :4, updated_by = :5
+		SET name = :1, description = :2, price = :3, cost = :4, updated_by = :5, version = version + 1
 		WHERE id = :6
 	`
 
-	result, err := r.db.Exec(query,
+	_, err = tx.Exec(query,
 		product.Name,
 		product.Description,
 		product.Price,
@@ -209,45 +333,72 @@ func (r *ProductRepository) Update(product *models.Product) error {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
+	after, err := r.FindByIDForUpdate(tx, product.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("product not found")
+	if err := insertRevision(tx, before, after, revertedFrom, product.UpdatedBy); err != nil {
+		return err
+	}
+
+	if err := insertAudit(tx, audit, models.EntityProduct, auditID(product.ID), models.AuditUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	product.Version = after.Version
 	return nil
 }
 
-// Delete soft deletes a product (sets status to INACTIVE)
-func (r *ProductRepository) Delete(id int64, userID int64) error {
+// Delete soft deletes a product (sets status to INACTIVE). A non-zero
+// version makes the delete conditional on the row still being at it.
+func (r *SQLProductRepository) Delete(id int64, userID int64, version int, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := r.FindByIDForUpdate(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(before.Version, version); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE products
-		SET status = 'INACTIVE', updated_by = :1
+		SET status = 'INACTIVE', updated_by = :1, version = version + 1
 		WHERE id = :2
 	`
 
-	result, err := r.db.Exec(query, userID, id)
-	if err != nil {
+	if _, err := tx.Exec(query, userID, id); err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
+	after, err := r.FindByIDForUpdate(tx, id)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("product not found")
+	if err := insertRevision(tx, before, after, nil, userID); err != nil {
+		return err
 	}
 
-	return nil
+	if err := insertAudit(tx, audit, models.EntityProduct, auditID(id), models.AuditDelete, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // UpdateStock updates product stock (used within transactions)
-func (r *ProductRepository) UpdateStock(tx *sql.Tx, productID int64, newStock int) error {
+func (r *SQLProductRepository) UpdateStock(tx *sql.Tx, productID int64, newStock int) error {
 	query := `
 		UPDATE products
 		SET stock = :1
@@ -272,30 +423,16 @@ func (r *ProductRepository) UpdateStock(tx *sql.Tx, productID int64, newStock in
 }
 
 // FindByIDForUpdate retrieves a product with row lock (FOR UPDATE)
-func (r *ProductRepository) FindByIDForUpdate(tx *sql.Tx, id int64) (*models.Product, error) {
+func (r *SQLProductRepository) FindByIDForUpdate(tx *sql.Tx, id int64) (*models.Product, error) {
 	query := `
 		SELECT id, sku, name, description, price, cost, stock, status,
-		       created_at, updated_at, created_by, updated_by
+		       created_at, updated_at, created_by, updated_by, version
 		FROM products
 		WHERE id = :1
 		FOR UPDATE
 	`
 
-	var p models.Product
-	err := tx.QueryRow(query, id).Scan(
-		&p.ID,
-		&p.SKU,
-		&p.Name,
-		&p.Description,
-		&p.Price,
-		&p.Cost,
-		&p.Stock,
-		&p.Status,
-		&p.CreatedAt,
-		&p.UpdatedAt,
-		&p.CreatedBy,
-		&p.UpdatedBy,
-	)
+	p, err := scanProduct(tx.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("product not found")
@@ -304,5 +441,156 @@ func (r *ProductRepository) FindByIDForUpdate(tx *sql.Tx, id int64) (*models.Pro
 		return nil, fmt.Errorf("failed to query product: %w", err)
 	}
 
+	return p, nil
+}
+
+// FindRevisions returns a product's revisions, newest first
+func (r *SQLProductRepository) FindRevisions(productID int64) ([]models.ProductRevision, error) {
+	query := `
+		SELECT pr.id, pr.product_id, pr.revision, pr.sku, pr.name, pr.description, pr.price, pr.cost,
+		       pr.status, pr.changes, pr.reverted_from, pr.created_by, u.full_name, pr.created_at
+		FROM product_revisions pr
+		LEFT JOIN users u ON pr.created_by = u.id
+		WHERE pr.product_id = :1
+		ORDER BY pr.revision DESC
+	`
+
+	rows, err := r.db.Query(query, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.ProductRevision{}
+	for rows.Next() {
+		rev, err := scanProductRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product revision: %w", err)
+		}
+		revisions = append(revisions, *rev)
+	}
+
+	return revisions, nil
+}
+
+// FindRevision returns one revision of a product
+func (r *SQLProductRepository) FindRevision(productID int64, revision int) (*models.ProductRevision, error) {
+	query := `
+		SELECT pr.id, pr.product_id, pr.revision, pr.sku, pr.name, pr.description, pr.price, pr.cost,
+		       pr.status, pr.changes, pr.reverted_from, pr.created_by, u.full_name, pr.created_at
+		FROM product_revisions pr
+		LEFT JOIN users u ON pr.created_by = u.id
+		WHERE pr.product_id = :1 AND pr.revision = :2
+	`
+
+	rev, err := scanProductRevision(r.db.QueryRow(query, productID, revision))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("revision not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product revision: %w", err)
+	}
+
+	return rev, nil
+}
+
+// productSnapshot holds the product fields tracked by revisions. Stock is
+// left out because it is driven by stock transactions, not edits.
+type productSnapshot struct {
+	SKU         string  `json:"sku"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Cost        float64 `json:"cost"`
+	Status      string  `json:"status"`
+}
+
+func snapshotProduct(p *models.Product) *productSnapshot {
+	if p == nil {
+		return nil
+	}
+	return &productSnapshot{
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Cost:        p.Cost,
+		Status:      p.Status,
+	}
+}
+
+// insertRevision stores the product's new state as the next revision, with
+// the diff against its previous state. An edit that changes none of the
+// tracked fields adds no revision. The caller must hold the product row lock.
+func insertRevision(tx *sql.Tx, before, after *models.Product, revertedFrom *int, userID int64) error {
+	var changes string
+	if before != nil {
+		diff, err := diffJSON(snapshotProduct(before), snapshotProduct(after))
+		if err != nil {
+			return fmt.Errorf("failed to build revision diff: %w", err)
+		}
+		if diff == "" {
+			return nil
+		}
+		changes = diff
+	}
+
+	query := `
+		INSERT INTO product_revisions (product_id, revision, sku, name, description, price, cost, status, changes, reverted_from, created_by)
+		SELECT :1, NVL(MAX(revision), 0) + 1, :2, :3, :4, :5, :6, :7, :8, :9, :10
+		FROM product_revisions
+		WHERE product_id = :11
+	`
+
+	_, err := tx.Exec(query,
+		after.ID, after.SKU, after.Name, after.Description, after.Price, after.Cost, after.Status,
+		changes, revertedFrom, userID, after.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record product revision: %w", err)
+	}
+
+	return nil
+}
+
+// scanProduct reads a product row. The description is optional.
+func scanProduct(row rowScanner) (*models.Product, error) {
+	var p models.Product
+	var description sql.NullString
+	err := row.Scan(
+		&p.ID, &p.SKU, &p.Name, &description, &p.Price, &p.Cost, &p.Stock, &p.Status,
+		&p.CreatedAt, &p.UpdatedAt, &p.CreatedBy, &p.UpdatedBy, &p.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.Description = description.String
 	return &p, nil
 }
+
+func scanProductRevision(row rowScanner) (*models.ProductRevision, error) {
+	var rev models.ProductRevision
+	var description, changes, createdByName sql.NullString
+	var revertedFrom, createdBy sql.NullInt64
+
+	err := row.Scan(
+		&rev.ID, &rev.ProductID, &rev.Revision, &rev.SKU, &rev.Name, &description, &rev.Price, &rev.Cost,
+		&rev.Status, &changes, &revertedFrom, &createdBy, &createdByName, &rev.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rev.Description = description.String
+	if changes.Valid && changes.String != "" {
+		rev.Changes = json.RawMessage(changes.String)
+	}
+	if revertedFrom.Valid {
+		n := int(revertedFrom.Int64)
+		rev.RevertedFrom = &n
+	}
+	rev.CreatedBy = createdBy.Int64
+	rev.CreatedByName = createdByName.String
+
+	return &rev, nil
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"pos-backoffice/internal/models"
+)
+
+// ReconciliationRepository compares each product's stock column with the
+// balance its transactions add up to, and keeps a record of every run
+type ReconciliationRepository struct {
+	db *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// Reconcile checks every product and stores the run with its mismatches.
+// With a non-zero fixAs, each mismatch also gets an ADJUSTMENT transaction
+// posted by that user, so the ledger adds up to the stock again. runBy is
+// nil for scheduled runs.
+func (r *ReconciliationRepository) Reconcile(runBy *int64, fixAs int64, audit *models.AuditContext) (*models.StockReconciliation, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	run := &models.StockReconciliation{Fix: fixAs != 0, RunBy: runBy}
+	_, err = tx.Exec(`
+		INSERT INTO stock_reconciliations (fix, run_by)
+		VALUES (:1, :2)
+		RETURNING id, started_at INTO :3, :4
+	`, boolToInt(run.Fix), runBy, sql.Out{Dest: &run.ID}, sql.Out{Dest: &run.StartedAt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconciliation: %w", err)
+	}
+
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM products`).Scan(&run.ProductsChecked); err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	mismatches, err := findMismatches(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	run.Items = []models.StockMismatch{}
+	for _, item := range mismatches {
+		if run.Fix {
+			if err := postAdjustment(tx, &item, run.ID, fixAs, audit); err != nil {
+				return nil, err
+			}
+			// A movement posted since the scan already closed the gap
+			if item.Difference == 0 {
+				continue
+			}
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO stock_reconciliation_items (
+				reconciliation_id, product_id, stock, ledger_stock, difference, adjustment_id
+			)
+			VALUES (:1, :2, :3, :4, :5, :6)
+		`, run.ID, item.ProductID, item.Stock, item.LedgerStock, item.Difference, item.AdjustmentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store reconciliation item: %w", err)
+		}
+		run.Items = append(run.Items, item)
+	}
+
+	run.Mismatches = len(run.Items)
+	_, err = tx.Exec(`
+		UPDATE stock_reconciliations
+		SET products_checked = :1, mismatches = :2, finished_at = CURRENT_TIMESTAMP
+		WHERE id = :3
+		RETURNING finished_at INTO :4
+	`, run.ProductsChecked, run.Mismatches, run.ID, sql.Out{Dest: &run.FinishedAt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish reconciliation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reconciliation: %w", err)
+	}
+	return run, nil
+}
+
+// findMismatches returns every product whose stock differs from its ledger
+func findMismatches(tx *sql.Tx) ([]models.StockMismatch, error) {
+	query := fmt.Sprintf(`
+		SELECT p.id, p.sku, p.name, p.stock, NVL(l.quantity, 0)
+		FROM products p
+		LEFT JOIN (
+			SELECT t.product_id, SUM(%s) AS quantity
+			FROM transactions t
+			GROUP BY t.product_id
+		) l ON l.product_id = p.id
+		WHERE p.stock <> NVL(l.quantity, 0)
+		ORDER BY p.sku
+	`, signedQuantity)
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare stock with ledger: %w", err)
+	}
+	defer rows.Close()
+
+	mismatches := []models.StockMismatch{}
+	for rows.Next() {
+		var item models.StockMismatch
+		if err := rows.Scan(&item.ProductID, &item.SKU, &item.ProductName, &item.Stock, &item.LedgerStock); err != nil {
+			return nil, fmt.Errorf("failed to scan mismatch: %w", err)
+		}
+		item.Difference = item.Stock - item.LedgerStock
+		mismatches = append(mismatches, item)
+	}
+
+	return mismatches, rows.Err()
+}
+
+// postAdjustment locks the product, measures the gap again now that no
+// movement can be posted against it, and closes it with an ADJUSTMENT valued
+// at the product's cost. Stock is left alone; it is the ledger being fixed.
+func postAdjustment(tx *sql.Tx, item *models.StockMismatch, runID, fixAs int64, audit *models.AuditContext) error {
+	var cost float64
+	err := tx.QueryRow(`SELECT stock, cost FROM products WHERE id = :1 FOR UPDATE`, item.ProductID).Scan(&item.Stock, &cost)
+	if err != nil {
+		return fmt.Errorf("failed to lock product %d: %w", item.ProductID, err)
+	}
+
+	query := fmt.Sprintf(`SELECT NVL(SUM(%s), 0) FROM transactions t WHERE t.product_id = :1`, signedQuantity)
+	if err := tx.QueryRow(query, item.ProductID).Scan(&item.LedgerStock); err != nil {
+		return fmt.Errorf("failed to sum ledger for product %d: %w", item.ProductID, err)
+	}
+
+	item.Difference = item.Stock - item.LedgerStock
+	if item.Difference == 0 {
+		return nil
+	}
+
+	adjustment := &models.Transaction{
+		TransactionType: "ADJUSTMENT",
+		ProductID:       item.ProductID,
+		Quantity:        item.Difference,
+		UnitPrice:       cost,
+		TotalAmount:     cost * float64(item.Difference),
+		Notes:           fmt.Sprintf("Stock reconciliation #%d", runID),
+		CreatedBy:       fixAs,
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO transactions (
+			transaction_type, product_id, quantity, unit_price, total_amount, notes, created_by
+		)
+		VALUES (:1, :2, :3, :4, :5, :6, :7)
+		RETURNING id, transaction_date INTO :8, :9
+	`, adjustment.TransactionType, adjustment.ProductID, adjustment.Quantity, adjustment.UnitPrice,
+		adjustment.TotalAmount, adjustment.Notes, adjustment.CreatedBy,
+		sql.Out{Dest: &adjustment.ID}, sql.Out{Dest: &adjustment.TransactionDate})
+	if err != nil {
+		return fmt.Errorf("failed to post adjustment for product %d: %w", item.ProductID, err)
+	}
+
+	if err := checkPeriodOpen(tx, adjustment.TransactionDate); err != nil {
+		return err
+	}
+
+	if err := insertAudit(tx, audit, models.EntityTransaction, auditID(adjustment.ID), models.AuditCreate, nil, adjustment); err != nil {
+		return err
+	}
+
+	item.AdjustmentID = &adjustment.ID
+	return nil
+}
+
+// FindAll returns reconciliation runs, newest first, without their items
+func (r *ReconciliationRepository) FindAll(page, limit int) ([]models.StockReconciliation, int, error) {
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM stock_reconciliations`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reconciliations: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	query := fmt.Sprintf(`
+		SELECT r.id, r.fix, r.products_checked, r.mismatches, r.run_by, u.full_name,
+		       r.started_at, r.finished_at
+		FROM stock_reconciliations r
+		LEFT JOIN users u ON r.run_by = u.id
+		ORDER BY r.started_at DESC, r.id DESC
+		OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
+	`, offset, limit)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query reconciliations: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.StockReconciliation{}
+	for rows.Next() {
+		run, err := scanReconciliation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, total, rows.Err()
+}
+
+// FindByID returns a reconciliation run with its mismatches, or nil when it
+// does not exist
+func (r *ReconciliationRepository) FindByID(id int64) (*models.StockReconciliation, error) {
+	row := r.db.QueryRow(`
+		SELECT r.id, r.fix, r.products_checked, r.mismatches, r.run_by, u.full_name,
+		       r.started_at, r.finished_at
+		FROM stock_reconciliations r
+		LEFT JOIN users u ON r.run_by = u.id
+		WHERE r.id = :1
+	`, id)
+
+	run, err := scanReconciliation(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT i.product_id, p.sku, p.name, i.stock, i.ledger_stock, i.difference, i.adjustment_id
+		FROM stock_reconciliation_items i
+		JOIN products p ON i.product_id = p.id
+		WHERE i.reconciliation_id = :1
+		ORDER BY p.sku
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reconciliation items: %w", err)
+	}
+	defer rows.Close()
+
+	run.Items = []models.StockMismatch{}
+	for rows.Next() {
+		var item models.StockMismatch
+		var adjustmentID sql.NullInt64
+		if err := rows.Scan(&item.ProductID, &item.SKU, &item.ProductName, &item.Stock,
+			&item.LedgerStock, &item.Difference, &adjustmentID); err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation item: %w", err)
+		}
+		if adjustmentID.Valid {
+			item.AdjustmentID = &adjustmentID.Int64
+		}
+		run.Items = append(run.Items, item)
+	}
+
+	return run, rows.Err()
+}
+
+func scanReconciliation(row rowScanner) (*models.StockReconciliation, error) {
+	var run models.StockReconciliation
+	var fix int
+	var runBy sql.NullInt64
+	var runByName sql.NullString
+	var finishedAt sql.NullTime
+
+	err := row.Scan(&run.ID, &fix, &run.ProductsChecked, &run.Mismatches, &runBy, &runByName,
+		&run.StartedAt, &finishedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan reconciliation: %w", err)
+	}
+
+	run.Fix = fix == 1
+	if runBy.Valid {
+		run.RunBy = &runBy.Int64
+	}
+	run.RunByName = runByName.String
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
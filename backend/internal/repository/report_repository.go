@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"pos-backoffice/internal/database"
+	"pos-backoffice/internal/models"
+)
+
+// ReportRepository aggregates the transaction ledger in the database
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// movementGroup says how transactions are grouped for one grouping
+type movementGroup struct {
+	key   string
+	label string
+	order string
+}
+
+var movementGroups = map[string]movementGroup{
+	models.ReportGroupStore:   {"NVL(TO_CHAR(t.store_id), '-')", "NVL(s.name, 'Warehouse')", "report_label"},
+	models.ReportGroupProduct: {"TO_CHAR(t.product_id)", "p.name", "report_label"},
+	models.ReportGroupUser:    {"TO_CHAR(t.created_by)", "u.full_name", "report_label"},
+	models.ReportGroupType:    {"t.transaction_type", "t.transaction_type", "report_key"},
+	models.ReportGroupDay:     {"TO_CHAR(TRUNC(t.transaction_date), 'YYYY-MM-DD')", "TO_CHAR(TRUNC(t.transaction_date), 'YYYY-MM-DD')", "report_key"},
+	models.ReportGroupWeek:    {"TO_CHAR(TRUNC(t.transaction_date, 'IW'), 'YYYY-MM-DD')", `TO_CHAR(TRUNC(t.transaction_date, 'IW'), 'IYYY-"W"IW')`, "report_key"},
+	models.ReportGroupMonth:   {"TO_CHAR(TRUNC(t.transaction_date, 'MM'), 'YYYY-MM-DD')", "TO_CHAR(TRUNC(t.transaction_date, 'MM'), 'YYYY-MM')", "report_key"},
+}
+
+// postgresMovementGroups are the movementGroups in PostgreSQL, which has
+// DATE_TRUNC instead of TRUNC and no TO_CHAR for plain numbers
+var postgresMovementGroups = map[string]movementGroup{
+	models.ReportGroupStore:   {"COALESCE(CAST(t.store_id AS VARCHAR), '-')", "COALESCE(s.name, 'Warehouse')", "report_label"},
+	models.ReportGroupProduct: {"CAST(t.product_id AS VARCHAR)", "p.name", "report_label"},
+	models.ReportGroupUser:    {"CAST(t.created_by AS VARCHAR)", "u.full_name", "report_label"},
+	models.ReportGroupType:    {"t.transaction_type", "t.transaction_type", "report_key"},
+	models.ReportGroupDay:     {"TO_CHAR(t.transaction_date, 'YYYY-MM-DD')", "TO_CHAR(t.transaction_date, 'YYYY-MM-DD')", "report_key"},
+	models.ReportGroupWeek:    {"TO_CHAR(DATE_TRUNC('week', t.transaction_date), 'YYYY-MM-DD')", `TO_CHAR(DATE_TRUNC('week', t.transaction_date), 'IYYY-"W"IW')`, "report_key"},
+	models.ReportGroupMonth:   {"TO_CHAR(DATE_TRUNC('month', t.transaction_date), 'YYYY-MM-DD')", "TO_CHAR(t.transaction_date, 'YYYY-MM')", "report_key"},
+}
+
+// sqliteMonday and sqliteThursday are the Monday and Thursday of a
+// transaction's ISO week. The ISO year and week number are those of the
+// Thursday.
+const (
+	sqliteMonday   = `date(t.transaction_date, '-' || ((CAST(strftime('%w', t.transaction_date) AS INTEGER) + 6) % 7) || ' days')`
+	sqliteThursday = `date(t.transaction_date, '-' || ((CAST(strftime('%w', t.transaction_date) AS INTEGER) + 6) % 7) || ' days', '+3 days')`
+)
+
+// sqliteMovementGroups are the movementGroups in SQLite, which formats
+// dates with strftime
+var sqliteMovementGroups = map[string]movementGroup{
+	models.ReportGroupStore:   {"COALESCE(CAST(t.store_id AS TEXT), '-')", "COALESCE(s.name, 'Warehouse')", "report_label"},
+	models.ReportGroupProduct: {"CAST(t.product_id AS TEXT)", "p.name", "report_label"},
+	models.ReportGroupUser:    {"CAST(t.created_by AS TEXT)", "u.full_name", "report_label"},
+	models.ReportGroupType:    {"t.transaction_type", "t.transaction_type", "report_key"},
+	models.ReportGroupDay:     {"strftime('%Y-%m-%d', t.transaction_date)", "strftime('%Y-%m-%d', t.transaction_date)", "report_key"},
+	models.ReportGroupWeek: {
+		sqliteMonday,
+		"strftime('%Y', " + sqliteThursday + ") || '-W' || printf('%02d', (CAST(strftime('%j', " + sqliteThursday + ") AS INTEGER) - 1) / 7 + 1)",
+		"report_key",
+	},
+	models.ReportGroupMonth: {"strftime('%Y-%m-01', t.transaction_date)", "strftime('%Y-%m', t.transaction_date)", "report_key"},
+}
+
+// currentMovementGroups returns the movementGroups of the database's dialect
+func currentMovementGroups() map[string]movementGroup {
+	switch database.Current() {
+	case database.Postgres:
+		return postgresMovementGroups
+	case database.SQLite:
+		return sqliteMovementGroups
+	}
+	return movementGroups
+}
+
+// ValidReportGroup reports whether groupBy is a grouping MovementReport supports
+func ValidReportGroup(groupBy string) bool {
+	_, ok := movementGroups[groupBy]
+	return ok
+}
+
+// MovementReport sums the transactions matching the filter within the store
+// scope, one row per group, plus the totals over all of them. Without a
+// grouping only the totals are computed.
+func (r *ReportRepository) MovementReport(filter *models.ReportFilter, scope *models.StoreScope) ([]models.MovementReportRow, *models.MovementMetrics, error) {
+	rows := []models.MovementReportRow{}
+	if filter.GroupBy != "" {
+		group, ok := currentMovementGroups()[filter.GroupBy]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown report grouping %q", filter.GroupBy)
+		}
+
+		var err error
+		rows, err = r.movements(filter, scope, group)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	totals, err := r.movements(filter, scope, movementGroup{"'total'", "'Total'", "report_key"})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(totals) == 0 {
+		return rows, &models.MovementMetrics{Cost: new(float64)}, nil
+	}
+	return rows, &totals[0].MovementMetrics, nil
+}
+
+func (r *ReportRepository) movements(filter *models.ReportFilter, scope *models.StoreScope, group movementGroup) ([]models.MovementReportRow, error) {
+	whereClause, args, _ := transactionWhere(&models.TransactionFilter{
+		From:      filter.From,
+		To:        filter.To,
+		Type:      filter.Type,
+		ProductID: filter.ProductID,
+		StoreID:   filter.StoreID,
+	}, scope)
+
+	// The unit cost of a sale is the product's cost in the last revision
+	// made before it, so later cost changes do not rewrite past margins
+	query := fmt.Sprintf(`
+		SELECT report_key, report_label,
+		       COUNT(*),
+		       COUNT(CASE WHEN transaction_type = 'DECREASE' THEN 1 END),
+		       NVL(SUM(CASE WHEN transaction_type = 'DECREASE' THEN quantity END), 0),
+		       NVL(SUM(CASE WHEN transaction_type = 'DECREASE' THEN total_amount END), 0),
+		       COUNT(CASE WHEN transaction_type = 'INCREASE' THEN 1 END),
+		       NVL(SUM(CASE WHEN transaction_type = 'INCREASE' THEN quantity END), 0),
+		       NVL(SUM(CASE WHEN transaction_type = 'INCREASE' THEN total_amount END), 0),
+		       COUNT(CASE WHEN transaction_type = 'ADJUSTMENT' THEN 1 END),
+		       NVL(SUM(CASE WHEN transaction_type = 'ADJUSTMENT' THEN quantity END), 0),
+		       COUNT(CASE WHEN transaction_type = 'OPENING_BALANCE' THEN 1 END),
+		       NVL(SUM(CASE WHEN transaction_type = 'OPENING_BALANCE' THEN quantity END), 0),
+		       NVL(SUM(CASE WHEN transaction_type = 'DECREASE' THEN 0 WHEN quantity > 0 THEN quantity END), 0),
+		       NVL(SUM(CASE WHEN transaction_type = 'DECREASE' THEN quantity WHEN quantity < 0 THEN -quantity END), 0),
+		       NVL(SUM(CASE WHEN transaction_type = 'DECREASE' THEN quantity * unit_cost END), 0)
+		FROM (
+			SELECT %s AS report_key, %s AS report_label,
+			       t.transaction_type, t.quantity, t.total_amount,
+			       NVL(%s, p.cost) AS unit_cost
+			FROM transactions t
+			JOIN products p ON t.product_id = p.id
+			LEFT JOIN stores s ON t.store_id = s.id
+			JOIN users u ON t.created_by = u.id
+			%s
+		)
+		GROUP BY report_key, report_label
+		ORDER BY %s
+	`, group.key, group.label, revisionCost("t.product_id", "t.transaction_date"), whereClause, group.order)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query movement report: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.MovementReportRow{}
+	for rows.Next() {
+		var row models.MovementReportRow
+		var cost float64
+		err := rows.Scan(
+			&row.Key, &row.Label,
+			&row.TransactionCount,
+			&row.SalesCount, &row.SalesQuantity, &row.SalesAmount,
+			&row.PurchaseCount, &row.PurchaseQuantity, &row.PurchaseAmount,
+			&row.AdjustmentCount, &row.AdjustmentQuantity,
+			&row.OpeningBalanceCount, &row.OpeningBalanceQuantity,
+			&row.QuantityIn, &row.QuantityOut,
+			&cost,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan movement report: %w", err)
+		}
+		row.NetQuantity = row.QuantityIn - row.QuantityOut
+		row.Cost = &cost
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"pos-backoffice/internal/models"
+)
+
+type RoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// FindAllPermissions lists every permission that can be granted
+func (r *RoleRepository) FindAllPermissions() ([]models.Permission, error) {
+	rows, err := r.db.Query(`SELECT code, description FROM permissions ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+	for rows.Next() {
+		var p models.Permission
+		var description sql.NullString
+		if err := rows.Scan(&p.Code, &description); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		p.Description = description.String
+		permissions = append(permissions, p)
+	}
+
+	return permissions, nil
+}
+
+// FindAll retrieves all roles with their permissions
+func (r *RoleRepository) FindAll() ([]models.Role, error) {
+	query := `
+		SELECT id, code, name, description, is_system, require_mfa, created_at, updated_at
+		FROM roles
+		ORDER BY code
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	rows.Close()
+
+	for i := range roles {
+		if roles[i].Permissions, err = r.FindPermissionsByRole(roles[i].Code); err != nil {
+			return nil, err
+		}
+	}
+
+	return roles, nil
+}
+
+// FindByID retrieves a role by ID
+func (r *RoleRepository) FindByID(id int64) (*models.Role, error) {
+	query := `
+		SELECT id, code, name, description, is_system, require_mfa, created_at, updated_at
+		FROM roles
+		WHERE id = :1
+	`
+
+	role, err := scanRole(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("role not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Permissions, err = r.FindPermissionsByRole(role.Code); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// FindByCode retrieves a role by code, returning nil if it does not exist
+func (r *RoleRepository) FindByCode(code string) (*models.Role, error) {
+	query := `
+		SELECT id, code, name, description, is_system, require_mfa, created_at, updated_at
+		FROM roles
+		WHERE code = :1
+	`
+
+	role, err := scanRole(r.db.QueryRow(query, code))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// FindPermissionsByRole returns the permission codes granted to a role
+func (r *RoleRepository) FindPermissionsByRole(roleCode string) ([]string, error) {
+	query := `
+		SELECT rp.permission_code
+		FROM role_permissions rp
+		JOIN roles r ON rp.role_id = r.id
+		WHERE r.code = :1
+		ORDER BY rp.permission_code
+	`
+
+	rows, err := r.db.Query(query, roleCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		permissions = append(permissions, code)
+	}
+
+	return permissions, nil
+}
+
+// Create creates a role and grants its permissions
+func (r *RoleRepository) Create(role *models.Role, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		INSERT INTO roles (code, name, description, is_system, require_mfa)
+		VALUES (:1, :2, :3, 0, :4)
+		RETURNING id INTO :5
+	`
+
+	_, err = dbTx.Exec(query, role.Code, role.Name, role.Description, boolToInt(role.RequireMFA), sql.Out{Dest: &role.ID})
+	if err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	if err := replacePermissions(dbTx, role.ID, role.Permissions); err != nil {
+		return err
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityRole, auditID(role.ID), models.AuditCreate, nil, role); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Update updates a role and replaces its permissions
+func (r *RoleRepository) Update(role *models.Role, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	before, err := findRoleForUpdate(dbTx, role.ID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE roles
+		SET name = :1, description = :2, require_mfa = :3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = :4
+	`
+
+	result, err := dbTx.Exec(query, role.Name, role.Description, boolToInt(role.RequireMFA), role.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("role not found")
+	}
+
+	if err := replacePermissions(dbTx, role.ID, role.Permissions); err != nil {
+		return err
+	}
+
+	after, err := findRoleForUpdate(dbTx, role.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityRole, auditID(role.ID), models.AuditUpdate, before, after); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// Delete removes a non-system role that is not assigned to any user
+func (r *RoleRepository) Delete(id int64, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	before, err := findRoleForUpdate(dbTx, id)
+	if err != nil {
+		return err
+	}
+
+	var inUse int
+	err = dbTx.QueryRow(`
+		SELECT COUNT(*) FROM users u JOIN roles r ON u.role = r.code WHERE r.id = :1
+	`, id).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("failed to check role usage: %w", err)
+	}
+	if inUse > 0 {
+		return fmt.Errorf("role is assigned to %d user(s)", inUse)
+	}
+
+	if _, err := dbTx.Exec(`DELETE FROM role_permissions WHERE role_id = :1`, id); err != nil {
+		return fmt.Errorf("failed to delete role permissions: %w", err)
+	}
+
+	result, err := dbTx.Exec(`DELETE FROM roles WHERE id = :1 AND is_system = 0`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("role not found or is a system role")
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntityRole, auditID(id), models.AuditDelete, before, nil); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// findRoleForUpdate locks a role row and returns it with its permissions
+func findRoleForUpdate(dbTx *sql.Tx, id int64) (*models.Role, error) {
+	query := `
+		SELECT id, code, name, description, is_system, require_mfa, created_at, updated_at
+		FROM roles
+		WHERE id = :1
+		FOR UPDATE
+	`
+
+	role, err := scanRole(dbTx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("role not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := dbTx.Query(`SELECT permission_code FROM role_permissions WHERE role_id = :1 ORDER BY permission_code`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		role.Permissions = append(role.Permissions, code)
+	}
+
+	return role, nil
+}
+
+func replacePermissions(dbTx *sql.Tx, roleID int64, permissions []string) error {
+	if _, err := dbTx.Exec(`DELETE FROM role_permissions WHERE role_id = :1`, roleID); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+
+	for _, code := range permissions {
+		_, err := dbTx.Exec(`INSERT INTO role_permissions (role_id, permission_code) VALUES (:1, :2)`, roleID, code)
+		if err != nil {
+			return fmt.Errorf("failed to grant permission %s: %w", code, err)
+		}
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRole(row rowScanner) (*models.Role, error) {
+	var role models.Role
+	var description sql.NullString
+	var isSystem, requireMFA int
+
+	err := row.Scan(
+		&role.ID, &role.Code, &role.Name, &description, &isSystem, &requireMFA,
+		&role.CreatedAt, &role.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan role: %w", err)
+	}
+
+	role.Description = description.String
+	role.IsSystem = isSystem == 1
+	role.RequireMFA = requireMFA == 1
+	role.Permissions = []string{}
+
+	return &role, nil
+}
+
+// boolToInt converts a bool to the NUMBER(1) flag used in the schema
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create opens a new session together with its first refresh token
+func (r *SessionRepository) Create(session *models.Session, token *models.RefreshToken) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		INSERT INTO auth_sessions (id, user_id, ip_address, user_agent)
+		VALUES (:1, :2, :3, :4)
+	`
+	_, err = dbTx.Exec(query, session.ID, session.UserID, session.IPAddress, session.UserAgent)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if err := insertRefreshToken(dbTx, token); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// CreateImpersonation opens a session in which impersonatorID acts as
+// session.UserID. Impersonation sessions have no refresh token.
+func (r *SessionRepository) CreateImpersonation(session *models.Session, impersonatorID int64, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		INSERT INTO auth_sessions (id, user_id, ip_address, user_agent, impersonator_id)
+		VALUES (:1, :2, :3, :4, :5)
+	`
+	_, err = dbTx.Exec(query, session.ID, session.UserID, session.IPAddress, session.UserAgent, impersonatorID)
+	if err != nil {
+		return fmt.Errorf("failed to create impersonation session: %w", err)
+	}
+
+	session.ImpersonatorID = &impersonatorID
+
+	if err := insertAudit(dbTx, audit, models.EntitySession, session.ID, models.AuditImpersonate, nil, session); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// FindRefreshToken looks up a refresh token by hash, joined with its session state
+func (r *SessionRepository) FindRefreshToken(tokenHash string) (*models.RefreshToken, *models.Session, error) {
+	query := `
+		SELECT rt.id, rt.session_id, rt.user_id, rt.token_hash, rt.expires_at, rt.used_at, rt.created_at,
+		       s.revoked_at
+		FROM refresh_tokens rt
+		JOIN auth_sessions s ON rt.session_id = s.id
+		WHERE rt.token_hash = :1
+	`
+
+	var token models.RefreshToken
+	var session models.Session
+	var usedAt, revokedAt sql.NullTime
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID, &token.SessionID, &token.UserID, &token.TokenHash,
+		&token.ExpiresAt, &usedAt, &token.CreatedAt,
+		&revokedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("refresh token not found")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query refresh token: %w", err)
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	session.ID = token.SessionID
+	session.UserID = token.UserID
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return &token, &session, nil
+}
+
+// Rotate marks the old refresh token as used and stores its replacement.
+// It fails if the old token was consumed concurrently.
+func (r *SessionRepository) Rotate(oldTokenID int64, newToken *models.RefreshToken) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	result, err := dbTx.Exec(
+		`UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = :1 AND used_at IS NULL`,
+		oldTokenID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("refresh token already used")
+	}
+
+	if err := insertRefreshToken(dbTx, newToken); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// RevokeSession revokes a single session and every token issued under it.
+// A session revoked with reason LOGOUT is audited as a logout.
+func (r *SessionRepository) RevokeSession(sessionID string, reason string, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	query := `
+		UPDATE auth_sessions
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = :1
+		WHERE id = :2 AND revoked_at IS NULL
+	`
+
+	result, err := dbTx.Exec(query, reason, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return nil
+	}
+
+	action := models.AuditRevoke
+	if reason == "LOGOUT" {
+		action = models.AuditLogout
+	}
+	details := map[string]string{"revoked_reason": reason}
+	if err := insertAudit(dbTx, audit, models.EntitySession, sessionID, action, nil, details); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// RevokeUserSessions revokes all active sessions of a user
+func (r *SessionRepository) RevokeUserSessions(userID int64, reason string) error {
+	query := `
+		UPDATE auth_sessions
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = :1
+		WHERE user_id = :2 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.Exec(query, reason, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions revokes all active sessions of a user except one
+func (r *SessionRepository) RevokeOtherSessions(userID int64, keepSessionID, reason string) error {
+	query := `
+		UPDATE auth_sessions
+		SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = :1
+		WHERE user_id = :2 AND id <> :3 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.Exec(query, reason, userID, keepSessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeToken adds an access token ID to the deny list until it expires
+func (r *SessionRepository) RevokeToken(tokenID string, userID int64, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES (:1, :2, :3)
+	`
+
+	_, err := r.db.Exec(query, tokenID, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token was revoked directly by ID
+// or indirectly through its session
+func (r *SessionRepository) IsTokenRevoked(tokenID string, sessionID string) (bool, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM revoked_tokens WHERE jti = :1) +
+			(SELECT COUNT(*) FROM auth_sessions WHERE id = :2 AND revoked_at IS NOT NULL)
+		FROM dual
+	`
+
+	var count int
+	if err := r.db.QueryRow(query, tokenID, sessionID).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// DeleteExpired purges deny-list entries whose tokens have expired anyway
+func (r *SessionRepository) DeleteExpired() error {
+	_, err := r.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("failed to purge revoked tokens: %w", err)
+	}
+	return nil
+}
+
+func insertRefreshToken(dbTx *sql.Tx, token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (session_id, user_id, token_hash, expires_at)
+		VALUES (:1, :2, :3, :4)
+		RETURNING id INTO :5
+	`
+
+	_, err := dbTx.Exec(query,
+		token.SessionID, token.UserID, token.TokenHash, token.ExpiresAt,
+		sql.Out{Dest: &token.ID},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+)
+
+type SigningKeyRepository struct {
+	db *sql.DB
+}
+
+func NewSigningKeyRepository(db *sql.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+// FindAll lists every signing key, newest first
+func (r *SigningKeyRepository) FindAll() ([]models.SigningKey, error) {
+	return r.find(`
+		SELECT kid, algorithm, private_key, created_at, retired_at
+		FROM jwt_signing_keys
+		ORDER BY created_at DESC
+	`)
+}
+
+// FindUsable returns the active key and keys retired after retiredSince
+func (r *SigningKeyRepository) FindUsable(retiredSince time.Time) ([]models.SigningKey, error) {
+	return r.find(`
+		SELECT kid, algorithm, private_key, created_at, retired_at
+		FROM jwt_signing_keys
+		WHERE retired_at IS NULL OR retired_at > :1
+		ORDER BY created_at DESC
+	`, retiredSince)
+}
+
+// Rotate retires the active key and installs a new one in a single transaction
+func (r *SigningKeyRepository) Rotate(key *models.SigningKey, audit *models.AuditContext) error {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if _, err := dbTx.Exec(`UPDATE jwt_signing_keys SET retired_at = CURRENT_TIMESTAMP WHERE retired_at IS NULL`); err != nil {
+		return fmt.Errorf("failed to retire signing key: %w", err)
+	}
+
+	query := `
+		INSERT INTO jwt_signing_keys (kid, algorithm, private_key)
+		VALUES (:1, :2, :3)
+		RETURNING created_at INTO :4
+	`
+	_, err = dbTx.Exec(query, key.KID, key.Algorithm, key.PrivateKeyPEM, sql.Out{Dest: &key.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+
+	if err := insertAudit(dbTx, audit, models.EntitySigningKey, key.KID, models.AuditRotate, nil, key); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+func (r *SigningKeyRepository) find(query string, args ...interface{}) ([]models.SigningKey, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.SigningKey{}
+	for rows.Next() {
+		var k models.SigningKey
+		var retiredAt sql.NullTime
+		if err := rows.Scan(&k.KID, &k.Algorithm, &k.PrivateKeyPEM, &k.CreatedAt, &retiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %w", err)
+		}
+		if retiredAt.Valid {
+			k.RetiredAt = &retiredAt.Time
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
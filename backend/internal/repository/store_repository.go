@@ -8,20 +8,29 @@ import (
 	"pos-backoffice/internal/models"
 )
 
-type StoreRepository struct {
+// StoreRepository stores stores
+type StoreRepository interface {
+	GetAll(search string, scope *models.StoreScope) ([]models.Store, error)
+	GetByID(id int64) (*models.Store, error)
+	Create(store *models.Store, audit *models.AuditContext) error
+	Update(store *models.Store, audit *models.AuditContext) error
+	Delete(id int64, userID int64, version int, audit *models.AuditContext) error
+}
+
+// SQLStoreRepository is the StoreRepository backed by the database
+type SQLStoreRepository struct {
 	db *sql.DB
 }
 
-func NewStoreRepository(db *sql.DB) *StoreRepository {
-	return &StoreRepository{db: db}
+func NewStoreRepository(db *sql.DB) *SQLStoreRepository {
+	return &SQLStoreRepository{db: db}
 }
 
-// GetAll returns all stores with optional search
-// GetAll returns all stores with optional search
-func (r *StoreRepository) GetAll(search string) ([]models.Store, error) {
+// GetAll returns all stores visible in the scope with optional search
+func (r *SQLStoreRepository) GetAll(search string, scope *models.StoreScope) ([]models.Store, error) {
 	queryBuf := `
 		SELECT id, code, name, address, phone, status, 
-		       created_at, updated_at, created_by, updated_by
+		       created_at, updated_at, created_by, updated_by, version
 		FROM stores
 		WHERE status = 'ACTIVE'
 	`
@@ -34,6 +43,10 @@ func (r *StoreRepository) GetAll(search string) ([]models.Store, error) {
 		args = append(args, term, term)
 	}
 
+	scopeClause, scopeArgs := storeScopeClause("id", scope, len(args)+1)
+	queryBuf += scopeClause
+	args = append(args, scopeArgs...)
+
 	queryBuf += " ORDER BY name"
 
 	rows, err := r.db.Query(queryBuf, args...)
@@ -45,108 +58,205 @@ func (r *StoreRepository) GetAll(search string) ([]models.Store, error) {
 	// Initialize empty slice to ensure JSON returns [] instead of null
 	stores := []models.Store{}
 	for rows.Next() {
-		var store models.Store
-		err := rows.Scan(
-			&store.ID, &store.Code, &store.Name, &store.Address, &store.Phone,
-			&store.Status, &store.CreatedAt, &store.UpdatedAt,
-			&store.CreatedBy, &store.UpdatedBy,
-		)
+		store, err := scanStore(rows)
 		if err != nil {
 			return nil, err
 		}
-		stores = append(stores, store)
+		stores = append(stores, *store)
 	}
 
 	return stores, nil
 }
 
 // GetByID returns a store by ID
-func (r *StoreRepository) GetByID(id int64) (*models.Store, error) {
+func (r *SQLStoreRepository) GetByID(id int64) (*models.Store, error) {
 	query := `
 		SELECT id, code, name, address, phone, status, 
-		       created_at, updated_at, created_by, updated_by
+		       created_at, updated_at, created_by, updated_by, version
 		FROM stores
 		WHERE id = :1
 	`
 
-	var store models.Store
-	err := r.db.QueryRow(query, id).Scan(
-		&store.ID, &store.Code, &store.Name, &store.Address, &store.Phone,
-		&store.Status, &store.CreatedAt, &store.UpdatedAt,
-		&store.CreatedBy, &store.UpdatedBy,
-	)
+	store, err := scanStore(r.db.QueryRow(query, id))
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &store, nil
+	return store, nil
+}
+
+// findByIDForUpdate locks a store row and returns its current state
+func (r *SQLStoreRepository) findByIDForUpdate(tx *sql.Tx, id int64) (*models.Store, error) {
+	query := `
+		SELECT id, code, name, address, phone, status,
+		       created_at, updated_at, created_by, updated_by, version
+		FROM stores
+		WHERE id = :1
+		FOR UPDATE
+	`
+
+	store, err := scanStore(tx.QueryRow(query, id))
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("store not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
 }
 
 // Create creates a new store
-func (r *StoreRepository) Create(store *models.Store) error {
+func (r *SQLStoreRepository) Create(store *models.Store, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO stores (code, name, address, phone, status, created_by, updated_by)
 		VALUES (:1, :2, :3, :4, :5, :6, :7)
-		RETURNING id INTO :8
+		RETURNING id, version INTO :8, :9
 	`
 
-	_, err := r.db.Exec(query,
+	_, err = tx.Exec(query,
 		store.Code, store.Name, store.Address, store.Phone,
 		store.Status, store.CreatedBy, store.UpdatedBy,
 		sql.Out{Dest: &store.ID},
+		sql.Out{Dest: &store.Version},
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := insertAudit(tx, audit, models.EntityStore, auditID(store.ID), models.AuditCreate, nil, store); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// Update updates an existing store
-func (r *StoreRepository) Update(store *models.Store) error {
+// Update updates an existing store. A non-zero store.Version makes the
+// update conditional on the row still being at that version; on success the
+// store holds the new version and timestamps.
+func (r *SQLStoreRepository) Update(store *models.Store, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := r.findByIDForUpdate(tx, store.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(before.Version, store.Version); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE stores
 		SET code = :1, name = :2, address = :3, phone = :4, 
-		    status = :5, updated_by = :6, updated_at = CURRENT_TIMESTAMP
+		    status = :5, updated_by = :6, updated_at = CURRENT_TIMESTAMP, version = version + 1
 		WHERE id = :7
 	`
 
-	result, err := r.db.Exec(query,
+	_, err = tx.Exec(query,
 		store.Code, store.Name, store.Address, store.Phone,
 		store.Status, store.UpdatedBy, store.ID,
 	)
-
 	if err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
+	after, err := r.findByIDForUpdate(tx, store.ID)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("store not found")
+	if err := insertAudit(tx, audit, models.EntityStore, auditID(store.ID), models.AuditUpdate, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
+	*store = *after
 	return nil
 }
 
-// Delete soft deletes a store
-func (r *StoreRepository) Delete(id int64) error {
-	query := `UPDATE stores SET status = 'INACTIVE' WHERE id = :1`
+// Delete soft deletes a store. A non-zero version makes the delete
+// conditional on the row still being at it.
+func (r *SQLStoreRepository) Delete(id int64, userID int64, version int, audit *models.AuditContext) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	result, err := r.db.Exec(query, id)
+	before, err := r.findByIDForUpdate(tx, id)
 	if err != nil {
 		return err
 	}
+	if err := checkVersion(before.Version, version); err != nil {
+		return err
+	}
+
+	query := `UPDATE stores SET status = 'INACTIVE', updated_by = :1, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = :2`
+
+	if _, err := tx.Exec(query, userID, id); err != nil {
+		return err
+	}
 
-	rows, err := result.RowsAffected()
+	after, err := r.findByIDForUpdate(tx, id)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("store not found")
+	if err := insertAudit(tx, audit, models.EntityStore, auditID(id), models.AuditDelete, before, after); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
+}
+
+// storeScopeClause builds an "AND column IN (...)" filter for a store scope.
+// Bind placeholders are numbered from argIndex. A nil or unrestricted scope adds nothing.
+func storeScopeClause(column string, scope *models.StoreScope, argIndex int) (string, []interface{}) {
+	if scope == nil || scope.All {
+		return "", nil
+	}
+	if len(scope.StoreIDs) == 0 {
+		return " AND 1=0", nil
+	}
+
+	placeholders := make([]string, len(scope.StoreIDs))
+	args := make([]interface{}, len(scope.StoreIDs))
+	for i, id := range scope.StoreIDs {
+		placeholders[i] = fmt.Sprintf(":%d", argIndex+i)
+		args[i] = id
+	}
+
+	return fmt.Sprintf(" AND %s IN (%s)", column, strings.Join(placeholders, ", ")), args
+}
+
+// scanStore reads a store row. The address and phone are optional.
+func scanStore(row rowScanner) (*models.Store, error) {
+	var store models.Store
+	var address, phone sql.NullString
+	err := row.Scan(
+		&store.ID, &store.Code, &store.Name, &address, &phone,
+		&store.Status, &store.CreatedAt, &store.UpdatedAt,
+		&store.CreatedBy, &store.UpdatedBy, &store.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	store.Address = address.String
+	store.Phone = phone.String
+	return &store, nil
 }
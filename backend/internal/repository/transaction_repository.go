@@ -2,19 +2,30 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+
 	"pos-backoffice/internal/models"
 )
 
-type TransactionRepository struct {
+// TransactionRepository stores the stock movement ledger
+type TransactionRepository interface {
+	Create(tx *models.Transaction, audit *models.AuditContext) error
+	GetAll(filter *models.TransactionFilter, scope *models.StoreScope) ([]models.Transaction, int, error)
+	GetPage(filter *models.TransactionFilter, cursor string, includeTotal bool, scope *models.StoreScope) ([]models.Transaction, string, *int, error)
+}
+
+// SQLTransactionRepository is the TransactionRepository backed by the database
+type SQLTransactionRepository struct {
 	db *sql.DB
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+func NewTransactionRepository(db *sql.DB) *SQLTransactionRepository {
+	return &SQLTransactionRepository{db: db}
 }
 
-// Create creates a new transaction and updates product stock
-func (r *TransactionRepository) Create(tx *models.Transaction) error {
+// Create creates a new transaction and updates product stock. It returns
+// ErrPeriodClosed when the transaction's date is in a closed accounting period.
+func (r *SQLTransactionRepository) Create(tx *models.Transaction, audit *models.AuditContext) error {
 	// Start database transaction
 	dbTx, err := r.db.Begin()
 	if err != nil {
@@ -26,15 +37,15 @@ func (r *TransactionRepository) Create(tx *models.Transaction) error {
 	query := `
 		INSERT INTO transactions (
 			transaction_type, product_id, store_id, quantity, 
-			unit_price, total_amount, notes, created_by
+			unit_price, total_amount, notes, created_by, impersonated_by
 		)
-		VALUES (:1, :2, :3, :4, :5, :6, :7, :8)
-		RETURNING id, transaction_date INTO :9, :10
+		VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9)
+		RETURNING id, transaction_date INTO :10, :11
 	`
 
 	_, err = dbTx.Exec(query,
 		tx.TransactionType, tx.ProductID, tx.StoreID, tx.Quantity,
-		tx.UnitPrice, tx.TotalAmount, tx.Notes, tx.CreatedBy,
+		tx.UnitPrice, tx.TotalAmount, tx.Notes, tx.CreatedBy, tx.ImpersonatedBy,
 		sql.Out{Dest: &tx.ID},
 		sql.Out{Dest: &tx.TransactionDate},
 	)
@@ -43,6 +54,10 @@ func (r *TransactionRepository) Create(tx *models.Transaction) error {
 		return err
 	}
 
+	if err := checkPeriodOpen(dbTx, tx.TransactionDate); err != nil {
+		return err
+	}
+
 	// Update product stock
 	var stockUpdate string
 	if tx.TransactionType == "INCREASE" {
@@ -56,117 +71,220 @@ func (r *TransactionRepository) Create(tx *models.Transaction) error {
 		return err
 	}
 
+	if err := insertAudit(dbTx, audit, models.EntityTransaction, auditID(tx.ID), models.AuditCreate, nil, tx); err != nil {
+		return err
+	}
+
 	return dbTx.Commit()
 }
 
-// GetByProductID returns all transactions for a product
-func (r *TransactionRepository) GetByProductID(productID int64, limit int) ([]models.Transaction, error) {
-	query := `
-		SELECT 
-			t.id, t.transaction_type, t.product_id, p.name as product_name,
-			t.store_id, s.name as store_name,
-			t.quantity, t.unit_price, t.total_amount, t.notes,
-			t.transaction_date, t.created_by, u.full_name as created_by_name
-		FROM transactions t
-		JOIN products p ON t.product_id = p.id
-		LEFT JOIN stores s ON t.store_id = s.id
-		JOIN users u ON t.created_by = u.id
-		WHERE t.product_id = :1
-		ORDER BY t.transaction_date DESC
-		FETCH FIRST :2 ROWS ONLY
-	`
+// transactionSortColumns maps TransactionFilter.Sort to the column it orders by
+var transactionSortColumns = map[string]string{
+	"date":     "t.transaction_date",
+	"amount":   "t.total_amount",
+	"quantity": "t.quantity",
+	"product":  "p.name",
+	"store":    "s.name",
+	"type":     "t.transaction_type",
+}
 
-	rows, err := r.db.Query(query, productID, limit)
-	if err != nil {
-		return nil, err
+// ValidTransactionSort reports whether sort is a column GetAll can order by
+func ValidTransactionSort(sort string) bool {
+	_, ok := transactionSortColumns[sort]
+	return ok
+}
+
+// GetAll returns transactions matching the filter within the store scope,
+// with the total count of matches for pagination
+func (r *SQLTransactionRepository) GetAll(filter *models.TransactionFilter, scope *models.StoreScope) ([]models.Transaction, int, error) {
+	whereClause, args, _ := transactionWhere(filter, scope)
+
+	var total int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM transactions t %s", whereClause)
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count transactions: %w", err)
 	}
-	defer rows.Close()
 
-	var transactions []models.Transaction
-	for rows.Next() {
-		var tx models.Transaction
-		var storeID sql.NullInt64
-		var storeName sql.NullString
-		var notes sql.NullString
+	sortColumn, ok := transactionSortColumns[filter.Sort]
+	if !ok {
+		sortColumn = transactionSortColumns["date"]
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
 
-		err := rows.Scan(
-			&tx.ID, &tx.TransactionType, &tx.ProductID, &tx.ProductName,
-			&storeID, &storeName,
-			&tx.Quantity, &tx.UnitPrice, &tx.TotalAmount, &notes,
-			&tx.TransactionDate, &tx.CreatedBy, &tx.CreatedByName,
-		)
+	// t.id breaks ties so that pages do not overlap
+	offset := (filter.Page - 1) * filter.Limit
+	query := fmt.Sprintf(`%s
+		%s
+		ORDER BY %s %s NULLS LAST, t.id %s
+		OFFSET %d ROWS FETCH NEXT %d ROWS ONLY
+	`, transactionSelect, whereClause, sortColumn, direction, direction, offset, filter.Limit)
 
-		if err != nil {
-			return nil, err
-		}
+	transactions, err := r.query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
 
-		if storeID.Valid {
-			tx.StoreID = &storeID.Int64
-		}
+// GetPage returns the page of transactions after cursor in date order, and
+// the cursor for the page after it ("" on the last page). The total number
+// of matches is only counted when includeTotal is set. filter.Sort and
+// filter.Page are ignored.
+func (r *SQLTransactionRepository) GetPage(filter *models.TransactionFilter, cursor string, includeTotal bool, scope *models.StoreScope) ([]models.Transaction, string, *int, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", nil, err
+	}
 
-		if storeName.Valid {
-			tx.StoreName = storeName.String
-		}
+	whereClause, args, argIndex := transactionWhere(filter, scope)
 
-		if notes.Valid {
-			tx.Notes = notes.String
+	var total *int
+	if includeTotal {
+		var count int
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM transactions t %s", whereClause)
+		if err := r.db.QueryRow(countQuery, args...).Scan(&count); err != nil {
+			return nil, "", nil, fmt.Errorf("failed to count transactions: %w", err)
 		}
+		total = &count
+	}
 
-		transactions = append(transactions, tx)
+	if after != nil {
+		clause, cursorArgs := after.clause("t.transaction_date", "t.id", filter.Desc, argIndex)
+		whereClause += clause
+		args = append(args, cursorArgs...)
 	}
 
-	return transactions, nil
-}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
 
-// GetAll returns all transactions with pagination
-func (r *TransactionRepository) GetAll(page, limit int) ([]models.Transaction, int, error) {
-	offset := (page - 1) * limit
+	// One extra row tells whether there is a next page
+	query := fmt.Sprintf(`%s
+		%s
+		ORDER BY t.transaction_date %s, t.id %s
+		FETCH FIRST %d ROWS ONLY
+	`, transactionSelect, whereClause, direction, direction, filter.Limit+1)
 
-	// Get total count
-	var total int
-	countQuery := `SELECT COUNT(*) FROM transactions`
-	err := r.db.QueryRow(countQuery).Scan(&total)
+	transactions, err := r.query(query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, "", nil, err
 	}
 
-	// Get transactions
-	query := `
+	next := ""
+	if len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+		last := transactions[len(transactions)-1]
+		next = encodeCursor(last.TransactionDate, last.ID)
+	}
+	return transactions, next, total, nil
+}
+
+const transactionSelect = `
 		SELECT 
 			t.id, t.transaction_type, t.product_id, p.name as product_name,
 			t.store_id, s.name as store_name,
 			t.quantity, t.unit_price, t.total_amount, t.notes,
-			t.transaction_date, t.created_by, u.full_name as created_by_name
+			t.transaction_date, t.created_by, u.full_name as created_by_name,
+			t.impersonated_by, iu.full_name as impersonated_by_name
 		FROM transactions t
 		JOIN products p ON t.product_id = p.id
 		LEFT JOIN stores s ON t.store_id = s.id
 		JOIN users u ON t.created_by = u.id
-		ORDER BY t.transaction_date DESC
-		OFFSET :1 ROWS FETCH NEXT :2 ROWS ONLY
-	`
+		LEFT JOIN users iu ON t.impersonated_by = iu.id`
+
+// transactionWhere builds the WHERE clause for a filter and store scope,
+// returning the next free bind placeholder number with it
+func transactionWhere(filter *models.TransactionFilter, scope *models.StoreScope) (string, []interface{}, int) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.From != nil {
+		whereClause += fmt.Sprintf(" AND t.transaction_date >= :%d", argIndex)
+		args = append(args, *filter.From)
+		argIndex++
+	}
+
+	if filter.To != nil {
+		whereClause += fmt.Sprintf(" AND t.transaction_date < :%d", argIndex)
+		args = append(args, *filter.To)
+		argIndex++
+	}
+
+	if filter.Type != "" {
+		whereClause += fmt.Sprintf(" AND t.transaction_type = :%d", argIndex)
+		args = append(args, filter.Type)
+		argIndex++
+	}
+
+	if filter.ProductID != 0 {
+		whereClause += fmt.Sprintf(" AND t.product_id = :%d", argIndex)
+		args = append(args, filter.ProductID)
+		argIndex++
+	}
+
+	if filter.StoreID != 0 {
+		whereClause += fmt.Sprintf(" AND t.store_id = :%d", argIndex)
+		args = append(args, filter.StoreID)
+		argIndex++
+	}
 
-	rows, err := r.db.Query(query, offset, limit)
+	if filter.UserID != 0 {
+		whereClause += fmt.Sprintf(" AND t.created_by = :%d", argIndex)
+		args = append(args, filter.UserID)
+		argIndex++
+	}
+
+	if filter.MinAmount != nil {
+		whereClause += fmt.Sprintf(" AND t.total_amount >= :%d", argIndex)
+		args = append(args, *filter.MinAmount)
+		argIndex++
+	}
+
+	if filter.MaxAmount != nil {
+		whereClause += fmt.Sprintf(" AND t.total_amount <= :%d", argIndex)
+		args = append(args, *filter.MaxAmount)
+		argIndex++
+	}
+
+	scopeClause, scopeArgs := storeScopeClause("t.store_id", scope, argIndex)
+	whereClause += scopeClause
+	args = append(args, scopeArgs...)
+
+	return whereClause, args, argIndex + len(scopeArgs)
+}
+
+// query runs a transactionSelect query and scans the rows
+func (r *SQLTransactionRepository) query(query string, args ...interface{}) ([]models.Transaction, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
 	}
 	defer rows.Close()
 
-	var transactions []models.Transaction
+	transactions := []models.Transaction{}
 	for rows.Next() {
 		var tx models.Transaction
 		var storeID sql.NullInt64
 		var storeName sql.NullString
 		var notes sql.NullString
+		var impersonatedBy sql.NullInt64
+		var impersonatedByName sql.NullString
 
 		err := rows.Scan(
 			&tx.ID, &tx.TransactionType, &tx.ProductID, &tx.ProductName,
 			&storeID, &storeName,
 			&tx.Quantity, &tx.UnitPrice, &tx.TotalAmount, &notes,
 			&tx.TransactionDate, &tx.CreatedBy, &tx.CreatedByName,
+			&impersonatedBy, &impersonatedByName,
 		)
 
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
 		if storeID.Valid {
@@ -181,57 +299,13 @@ func (r *TransactionRepository) GetAll(page, limit int) ([]models.Transaction, i
 			tx.Notes = notes.String
 		}
 
-		transactions = append(transactions, tx)
-	}
-
-	return transactions, total, nil
-}
-
-// GetByStoreID returns all transactions for a store
-func (r *TransactionRepository) GetByStoreID(storeID int64, limit int) ([]models.Transaction, error) {
-	query := `
-		SELECT 
-			t.id, t.transaction_type, t.product_id, p.name as product_name,
-			t.store_id, s.name as store_name,
-			t.quantity, t.unit_price, t.total_amount, t.notes,
-			t.transaction_date, t.created_by, u.full_name as created_by_name
-		FROM transactions t
-		JOIN products p ON t.product_id = p.id
-		JOIN stores s ON t.store_id = s.id
-		JOIN users u ON t.created_by = u.id
-		WHERE t.store_id = :1
-		ORDER BY t.transaction_date DESC
-		FETCH FIRST :2 ROWS ONLY
-	`
-
-	rows, err := r.db.Query(query, storeID, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var transactions []models.Transaction
-	for rows.Next() {
-		var tx models.Transaction
-		var notes sql.NullString
-
-		err := rows.Scan(
-			&tx.ID, &tx.TransactionType, &tx.ProductID, &tx.ProductName,
-			&tx.StoreID, &tx.StoreName,
-			&tx.Quantity, &tx.UnitPrice, &tx.TotalAmount, &notes,
-			&tx.TransactionDate, &tx.CreatedBy, &tx.CreatedByName,
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		if notes.Valid {
-			tx.Notes = notes.String
+		if impersonatedBy.Valid {
+			tx.ImpersonatedBy = &impersonatedBy.Int64
+			tx.ImpersonatedByName = impersonatedByName.String
 		}
 
 		transactions = append(transactions, tx)
 	}
 
-	return transactions, nil
+	return transactions, rows.Err()
 }
@@ -7,34 +7,36 @@ import (
 	"pos-backoffice/internal/models"
 )
 
-type UserRepository struct {
+// UserRepository stores users and their store assignments
+type UserRepository interface {
+	FindByUsername(username string) (*models.User, error)
+	FindByID(id int64) (*models.User, error)
+	Create(user *models.User) error
+	UpdateStatus(id int64, status string, audit *models.AuditContext) error
+	UpdatePassword(id int64, passwordHash string, audit *models.AuditContext) error
+	UpdateRole(id int64, role string, audit *models.AuditContext) error
+	FindStoreIDs(userID int64) ([]int64, error)
+	ReplaceStores(userID int64, storeIDs []int64, audit *models.AuditContext) error
+}
+
+// SQLUserRepository is the UserRepository backed by the database
+type SQLUserRepository struct {
 	db *sql.DB
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *sql.DB) *SQLUserRepository {
+	return &SQLUserRepository{db: db}
 }
 
 // FindByUsername finds a user by username using raw SQL
-func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
+func (r *SQLUserRepository) FindByUsername(username string) (*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, full_name, role, status, created_at, updated_at
+		SELECT id, username, password_hash, full_name, email, role, status, created_at, updated_at
 		FROM users
 		WHERE username = :1 AND status = 'ACTIVE'
 	`
 
-	var user models.User
-	err := r.db.QueryRow(query, username).Scan(
-		&user.ID,
-		&user.Username,
-		&user.PasswordHash,
-		&user.FullName,
-		&user.Role,
-		&user.Status,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(query, username))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
 	}
@@ -42,29 +44,18 @@ func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to query user: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 // FindByID finds a user by ID
-func (r *UserRepository) FindByID(id int64) (*models.User, error) {
+func (r *SQLUserRepository) FindByID(id int64) (*models.User, error) {
 	query := `
-		SELECT id, username, password_hash, full_name, role, status, created_at, updated_at
+		SELECT id, username, password_hash, full_name, email, role, status, created_at, updated_at
 		FROM users
 		WHERE id = :1
 	`
 
-	var user models.User
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.PasswordHash,
-		&user.FullName,
-		&user.Role,
-		&user.Status,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
 	}
@@ -72,21 +63,22 @@ func (r *UserRepository) FindByID(id int64) (*models.User, error) {
 		return nil, fmt.Errorf("failed to query user: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 // Create creates a new user
-func (r *UserRepository) Create(user *models.User) error {
+func (r *SQLUserRepository) Create(user *models.User) error {
 	query := `
-		INSERT INTO users (username, password_hash, full_name, role, status)
-		VALUES (:1, :2, :3, :4, :5)
-		RETURNING id INTO :6
+		INSERT INTO users (username, password_hash, full_name, email, role, status)
+		VALUES (:1, :2, :3, :4, :5, :6)
+		RETURNING id INTO :7
 	`
 
 	_, err := r.db.Exec(query,
 		user.Username,
 		user.PasswordHash,
 		user.FullName,
+		user.Email,
 		user.Role,
 		user.Status,
 		sql.Out{Dest: &user.ID},
@@ -98,3 +90,171 @@ func (r *UserRepository) Create(user *models.User) error {
 
 	return nil
 }
+
+// UpdateStatus activates or deactivates a user
+func (r *SQLUserRepository) UpdateStatus(id int64, status string, audit *models.AuditContext) error {
+	query := `
+		UPDATE users
+		SET status = :1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = :2
+	`
+
+	if err := r.update(id, models.AuditUpdate, audit, query, status, id); err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePassword replaces a user's password hash. The hash itself never
+// reaches the audit log; the entry only records that it changed.
+func (r *SQLUserRepository) UpdatePassword(id int64, passwordHash string, audit *models.AuditContext) error {
+	query := `
+		UPDATE users
+		SET password_hash = :1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = :2
+	`
+
+	if err := r.update(id, models.AuditPasswordChange, audit, query, passwordHash, id); err != nil {
+		return fmt.Errorf("failed to update user password: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRole assigns a role to a user
+func (r *SQLUserRepository) UpdateRole(id int64, role string, audit *models.AuditContext) error {
+	query := `
+		UPDATE users
+		SET role = :1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = :2
+	`
+
+	if err := r.update(id, models.AuditUpdate, audit, query, role, id); err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	return nil
+}
+
+// update runs a single-row UPDATE on a user and audits the change in the
+// same transaction
+func (r *SQLUserRepository) update(id int64, action string, audit *models.AuditContext, query string, args ...interface{}) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := findUserForUpdate(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	after, err := findUserForUpdate(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := insertAudit(tx, audit, models.EntityUser, auditID(id), action, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findUserForUpdate locks a user row and returns its current state
+func findUserForUpdate(tx *sql.Tx, id int64) (*models.User, error) {
+	query := `
+		SELECT id, username, password_hash, full_name, email, role, status, created_at, updated_at
+		FROM users
+		WHERE id = :1
+		FOR UPDATE
+	`
+
+	user, err := scanUser(tx.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, err
+}
+
+// FindStoreIDs returns the stores a user is assigned to
+func (r *SQLUserRepository) FindStoreIDs(userID int64) ([]int64, error) {
+	rows, err := r.db.Query(`SELECT store_id FROM user_stores WHERE user_id = :1 ORDER BY store_id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user stores: %w", err)
+	}
+	defer rows.Close()
+
+	storeIDs := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user store: %w", err)
+		}
+		storeIDs = append(storeIDs, id)
+	}
+
+	return storeIDs, nil
+}
+
+// ReplaceStores sets the complete list of stores a user is assigned to
+func (r *SQLUserRepository) ReplaceStores(userID int64, storeIDs []int64, audit *models.AuditContext) error {
+	before, err := r.FindStoreIDs(userID)
+	if err != nil {
+		return err
+	}
+
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	if _, err := dbTx.Exec(`DELETE FROM user_stores WHERE user_id = :1`, userID); err != nil {
+		return fmt.Errorf("failed to clear user stores: %w", err)
+	}
+
+	for _, storeID := range storeIDs {
+		_, err := dbTx.Exec(`INSERT INTO user_stores (user_id, store_id) VALUES (:1, :2)`, userID, storeID)
+		if err != nil {
+			return fmt.Errorf("failed to assign store %d: %w", storeID, err)
+		}
+	}
+
+	err = insertAudit(dbTx, audit, models.EntityUser, auditID(userID), models.AuditUpdate,
+		map[string][]int64{"store_ids": before},
+		map[string][]int64{"store_ids": storeIDs},
+	)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var email sql.NullString
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.FullName,
+		&email,
+		&user.Role,
+		&user.Status,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = email.String
+	return &user, nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "errors"
+
+// ErrVersionConflict is returned when a row changed after the caller read it
+var ErrVersionConflict = errors.New("record was changed by someone else, reload it and try again")
+
+// checkVersion compares a locked row's version with the version the caller
+// read. An expected version of zero skips the check.
+func checkVersion(current, expected int) error {
+	if expected != 0 && current != expected {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+// apiKeyTouchInterval limits how often last_used_at is written per key
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+	roleRepo   *repository.RoleRepository
+}
+
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository, roleRepo *repository.RoleRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		roleRepo:   roleRepo,
+	}
+}
+
+// CreateAPIKey issues a new key. The caller may only grant permissions it
+// holds itself and stores in its own scope. The plain key is returned once
+// and never stored.
+func (s *APIKeyService) CreateAPIKey(req *models.CreateAPIKeyRequest, creatorID int64, creatorPermissions []string, creatorScope *models.StoreScope, audit *models.AuditContext) (*models.CreateAPIKeyResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	known, err := s.roleRepo.FindAllPermissions()
+	if err != nil {
+		return nil, err
+	}
+	valid := make(map[string]bool, len(known))
+	for _, p := range known {
+		valid[p.Code] = true
+	}
+
+	held := make(map[string]bool, len(creatorPermissions))
+	for _, p := range creatorPermissions {
+		held[p] = true
+	}
+
+	seen := make(map[string]bool, len(req.Permissions))
+	permissions := []string{}
+	for _, code := range req.Permissions {
+		if !valid[code] {
+			return nil, fmt.Errorf("unknown permission: %s", code)
+		}
+		if !held[code] {
+			return nil, fmt.Errorf("cannot grant permission you do not hold: %s", code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			permissions = append(permissions, code)
+		}
+	}
+
+	storeSeen := make(map[int64]bool, len(req.StoreIDs))
+	storeIDs := []int64{}
+	for _, id := range req.StoreIDs {
+		if !creatorScope.Allows(id) {
+			return nil, fmt.Errorf("cannot grant store you do not have access to: %d", id)
+		}
+		if !storeSeen[id] {
+			storeSeen[id] = true
+			storeIDs = append(storeIDs, id)
+		}
+	}
+
+	plain, prefix, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		Name:        strings.TrimSpace(req.Name),
+		KeyPrefix:   prefix,
+		KeyHash:     hashToken(plain),
+		Permissions: permissions,
+		StoreIDs:    storeIDs,
+		ExpiresAt:   req.ExpiresAt,
+		CreatedBy:   creatorID,
+	}
+
+	if err := s.apiKeyRepo.Create(key, audit); err != nil {
+		return nil, err
+	}
+
+	return &models.CreateAPIKeyResponse{APIKey: *key, Key: plain}, nil
+}
+
+// GetAPIKeys lists all API keys, including revoked ones
+func (s *APIKeyService) GetAPIKeys() ([]models.APIKey, error) {
+	return s.apiKeyRepo.FindAll()
+}
+
+// RevokeAPIKey disables a key; requests using it are refused immediately
+func (s *APIKeyService) RevokeAPIKey(id int64, audit *models.AuditContext) error {
+	return s.apiKeyRepo.Revoke(id, audit)
+}
+
+// Authenticate resolves a presented key to an active API key
+func (s *APIKeyService) Authenticate(plain string) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.FindByHash(hashToken(plain))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("API key has been revoked")
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, fmt.Errorf("API key has expired")
+	}
+
+	// Usage tracking is best effort and must not fail the request
+	if err := s.apiKeyRepo.TouchLastUsed(key.ID, now, apiKeyTouchInterval); err != nil {
+		log.Printf("Failed to record API key usage for key %d: %v", key.ID, err)
+	}
+
+	return key, nil
+}
+
+// newAPIKey returns a key of the form pos_<prefix>_<secret> and its prefix
+func newAPIKey() (string, string, error) {
+	p := make([]byte, 4)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	prefix := "pos_" + hex.EncodeToString(p)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(b), prefix, nil
+}
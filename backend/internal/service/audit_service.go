@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+// AuditService reads the audit log. Entries are written by the repositories
+// in the same transaction as the change they describe, never from here.
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// GetEntries returns audit entries matching the filter, newest first
+func (s *AuditService) GetEntries(filter *models.AuditFilter) (*models.AuditListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	entries, total, err := s.auditRepo.FindAll(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+
+	return &models.AuditListResponse{
+		Entries: entries,
+		Total:   total,
+		Page:    filter.Page,
+		Limit:   filter.Limit,
+	}, nil
+}
@@ -1,54 +1,334 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"log"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"pos-backoffice/internal/config"
 	"pos-backoffice/internal/models"
 	"pos-backoffice/internal/repository"
 	"pos-backoffice/pkg/jwt"
 )
 
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo    repository.UserRepository
+	sessionRepo *repository.SessionRepository
+	loginRepo   *repository.LoginRepository
+	roleRepo    *repository.RoleRepository
+	mfaRepo     *repository.MFARepository
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
+func NewAuthService(userRepo repository.UserRepository, sessionRepo *repository.SessionRepository, loginRepo *repository.LoginRepository, roleRepo *repository.RoleRepository, mfaRepo *repository.MFARepository) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		loginRepo:   loginRepo,
+		roleRepo:    roleRepo,
+		mfaRepo:     mfaRepo,
 	}
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *AuthService) Login(req *models.LoginRequest) (*models.LoginResponse, error) {
+// LoginLockedError is returned while a username or IP address is locked out
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Login authenticates a user and opens a new session with an access and refresh token.
+// Failed attempts are counted per username and per IP address; once a limit is
+// reached further attempts are refused for an exponentially growing period.
+// Users with two-factor authentication, or whose role requires it, receive an
+// MFA token instead and finish with LoginMFA.
+func (s *AuthService) Login(req *models.LoginRequest, client *models.AuditContext) (*models.LoginResponse, error) {
+	attempt := &models.LoginHistory{
+		Username:  req.Username,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		RequestID: client.RequestID,
+	}
+
+	userKey := "user:" + req.Username
+	ipKey := "ip:" + client.IPAddress
+
+	if err := s.checkLocked(attempt, userKey, ipKey); err != nil {
+		return nil, err
+	}
+
 	// Find user by username
 	user, err := s.userRepo.FindByUsername(req.Username)
 	if err != nil {
-		return nil, fmt.Errorf("invalid username or password")
+		return nil, s.loginFailed(attempt, userKey, ipKey, "UNKNOWN_USER")
 	}
+	attempt.UserID = &user.ID
 
 	// Verify password
-	// First try bcrypt comparison
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
+	if !checkPassword(user.PasswordHash, req.Password) {
+		return nil, s.loginFailed(attempt, userKey, ipKey, "INVALID_PASSWORD")
+	}
+
+	challenge, err := s.mfaChallenge(user)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	return s.completeLogin(user, attempt, userKey)
+}
+
+// mfaChallenge returns the MFA token response for users with two-factor
+// authentication or whose role requires it, and nil for everyone else
+func (s *AuthService) mfaChallenge(user *models.User) (*models.LoginResponse, error) {
+	mfa, err := s.mfaRepo.FindByUserID(user.ID)
 	if err != nil {
-		// If bcrypt fails, try plain text comparison (for testing only)
-		if user.PasswordHash != req.Password {
-			return nil, fmt.Errorf("invalid username or password")
-		}
+		return nil, err
 	}
+	enrolled := mfa != nil && mfa.EnabledAt != nil
 
-	// Generate JWT token
-	token, err := jwt.GenerateToken(user)
+	required, err := roleRequiresMFA(s.roleRepo, user.Role)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
+	}
+	if !enrolled && !required {
+		return nil, nil
+	}
+
+	mfaToken, expiresAt, err := jwt.GenerateMFAToken(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MFA token: %w", err)
 	}
 
 	return &models.LoginResponse{
-		Token: token,
-		User:  *user,
+		ExpiresAt:             expiresAt,
+		User:                  *user,
+		MFARequired:           true,
+		MFAEnrollmentRequired: !enrolled,
+		MFAToken:              mfaToken,
 	}, nil
 }
 
+// LoginMFA completes a two-step login with a TOTP or recovery code. For users
+// who were required to enroll, the code confirms the pending enrollment and the
+// response carries their new recovery codes.
+func (s *AuthService) LoginMFA(req *models.MFALoginRequest, client *models.AuditContext) (*models.LoginResponse, error) {
+	claims, err := jwt.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired MFA token")
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil || user.Status != "ACTIVE" {
+		return nil, fmt.Errorf("user is not active")
+	}
+
+	attempt := &models.LoginHistory{
+		Username:  user.Username,
+		UserID:    &user.ID,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		RequestID: client.RequestID,
+	}
+
+	userKey := "user:" + user.Username
+	ipKey := "ip:" + client.IPAddress
+
+	if err := s.checkLocked(attempt, userKey, ipKey); err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.mfaRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, fmt.Errorf("two-factor enrollment has not been started")
+	}
+
+	var recoveryCodes []string
+	if mfa.EnabledAt == nil {
+		// the user is not signed in yet, but the enrollment is their own
+		audit := *client
+		audit.ActorID = &user.ID
+		recoveryCodes, err = activateMFA(s.mfaRepo, mfa, req.Code, &audit)
+	} else {
+		err = checkMFACode(s.mfaRepo, mfa, req.Code)
+	}
+	if err == errInvalidMFACode {
+		s.loginFailed(attempt, userKey, ipKey, "INVALID_MFA_CODE")
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.completeLogin(user, attempt, userKey)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+
+	return resp, nil
+}
+
+// checkLocked refuses early while either key is locked, without touching the counters
+func (s *AuthService) checkLocked(attempt *models.LoginHistory, keys ...string) error {
+	for _, key := range keys {
+		throttle, err := s.loginRepo.FindThrottle(key)
+		if err != nil {
+			return err
+		}
+		if throttle != nil && throttle.LockedUntil != nil && time.Now().Before(*throttle.LockedUntil) {
+			attempt.FailureReason = "LOCKED"
+			s.recordAttempt(attempt)
+			return &LoginLockedError{RetryAfter: time.Until(*throttle.LockedUntil)}
+		}
+	}
+	return nil
+}
+
+// completeLogin clears the user's failure counter, records the successful
+// attempt and opens a session
+func (s *AuthService) completeLogin(user *models.User, attempt *models.LoginHistory, userKey string) (*models.LoginResponse, error) {
+	if err := s.loginRepo.ResetThrottle(userKey); err != nil {
+		return nil, err
+	}
+	attempt.Success = true
+	attempt.FailureReason = ""
+	s.recordAttempt(attempt)
+
+	sessionID, err := jwt.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refresh, err := newRefreshToken(sessionID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		IPAddress: attempt.IPAddress,
+		UserAgent: attempt.UserAgent,
+	}
+	if err := s.sessionRepo.Create(session, refresh); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issueTokens(user, sessionID, refreshToken)
+}
+
+// loginFailed records a failed attempt, bumps both throttle counters and
+// applies a lockout once a counter reaches its limit
+func (s *AuthService) loginFailed(attempt *models.LoginHistory, userKey, ipKey, reason string) error {
+	attempt.FailureReason = reason
+	s.recordAttempt(attempt)
+
+	cfg := config.AppConfig
+	limits := map[string]int{
+		userKey: cfg.LoginMaxAttempts,
+		ipKey:   cfg.LoginIPMaxAttempts,
+	}
+
+	now := time.Now()
+	for key, limit := range limits {
+		count, err := s.loginRepo.RecordFailure(key, now, now.Add(-cfg.LoginAttemptWindow))
+		if err != nil {
+			return err
+		}
+
+		if count >= limit {
+			if err := s.loginRepo.Lock(key, now.Add(lockoutDuration(count-limit))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("invalid username or password")
+}
+
+// recordAttempt writes login history; a failure here must not block logins
+func (s *AuthService) recordAttempt(attempt *models.LoginHistory) {
+	if err := s.loginRepo.CreateHistory(attempt); err != nil {
+		log.Printf("Failed to record login attempt for %q: %v", attempt.Username, err)
+	}
+}
+
+// lockoutDuration doubles the base lockout for every failure past the limit
+func lockoutDuration(excess int) time.Duration {
+	cfg := config.AppConfig
+	d := cfg.LoginLockoutBase
+	for i := 0; i < excess && d < cfg.LoginLockoutMax; i++ {
+		d *= 2
+	}
+	if d > cfg.LoginLockoutMax {
+		d = cfg.LoginLockoutMax
+	}
+	return d
+}
+
+// Refresh exchanges a refresh token for a new access token and a rotated refresh token.
+// Presenting an already-used refresh token revokes the whole session.
+func (s *AuthService) Refresh(req *models.RefreshRequest) (*models.LoginResponse, error) {
+	current, session, err := s.sessionRepo.FindRefreshToken(hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	if session.RevokedAt != nil {
+		return nil, fmt.Errorf("session has been revoked")
+	}
+
+	if current.UsedAt != nil {
+		// A rotated token came back: assume it was stolen and kill the session
+		if err := s.sessionRepo.RevokeSession(session.ID, "REFRESH_TOKEN_REUSE", nil); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("refresh token has already been used")
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, fmt.Errorf("refresh token has expired")
+	}
+
+	user, err := s.userRepo.FindByID(current.UserID)
+	if err != nil || user.Status != "ACTIVE" {
+		return nil, fmt.Errorf("user is not active")
+	}
+
+	refreshToken, next, err := newRefreshToken(session.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Rotate(current.ID, next); err != nil {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	return s.issueTokens(user, session.ID, refreshToken)
+}
+
+// Logout revokes the caller's session and the access token used for the request
+func (s *AuthService) Logout(userID int64, sessionID, tokenID string, tokenExpiresAt time.Time, audit *models.AuditContext) error {
+	if err := s.sessionRepo.RevokeSession(sessionID, "LOGOUT", audit); err != nil {
+		return err
+	}
+
+	if err := s.sessionRepo.RevokeToken(tokenID, userID, tokenExpiresAt); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.DeleteExpired()
+}
+
 // HashPassword hashes a password using bcrypt
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -57,3 +337,51 @@ func HashPassword(password string) (string, error) {
 	}
 	return string(bytes), nil
 }
+
+func (s *AuthService) issueTokens(user *models.User, sessionID, refreshToken string) (*models.LoginResponse, error) {
+	permissions, err := s.roleRepo.FindPermissionsByRole(user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+
+	storeIDs, err := s.userRepo.FindStoreIDs(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve stores: %w", err)
+	}
+
+	token, expiresAt, err := jwt.GenerateToken(user, sessionID, permissions, storeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &models.LoginResponse{
+		Token:        token,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+		User:         *user,
+		Permissions:  permissions,
+		StoreIDs:     storeIDs,
+	}, nil
+}
+
+// newRefreshToken returns the opaque token handed to the client and the
+// hashed record to persist
+func newRefreshToken(sessionID string, userID int64) (string, *models.RefreshToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, &models.RefreshToken{
+		SessionID: sessionID,
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(config.AppConfig.RefreshTokenTTL),
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
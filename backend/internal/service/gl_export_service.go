@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+// warehouseJournal holds movements that are not tied to a store
+const warehouseJournal = "WAREHOUSE"
+
+// glPosting is the double entry a movement type is posted as. Negative
+// ADJUSTMENTs swap the sides and use lossDescription.
+type glPosting struct {
+	debit, credit   string
+	description     string
+	lossDescription string
+	atCost          bool // post the movement's cost rather than its amount
+}
+
+var glPostings = map[string][]glPosting{
+	"OPENING_BALANCE": {
+		{debit: models.GLInventory, credit: models.GLOpeningEquity, description: "Opening balances"},
+	},
+	"INCREASE": {
+		{debit: models.GLInventory, credit: models.GLPayable, description: "Purchases"},
+	},
+	"DECREASE": {
+		{debit: models.GLReceivable, credit: models.GLRevenue, description: "Sales"},
+		{debit: models.GLCOGS, credit: models.GLInventory, description: "Cost of sales", atCost: true},
+	},
+	"ADJUSTMENT": {
+		{debit: models.GLInventory, credit: models.GLShrinkage, description: "Stock gains", lossDescription: "Stock losses"},
+	},
+}
+
+// GLExportService turns a closed period's ledger into balanced
+// general-ledger journals and records each export so a period is only ever
+// handed to accounting once
+type GLExportService struct {
+	glRepo     *repository.GLExportRepository
+	periodRepo *repository.PeriodRepository
+}
+
+func NewGLExportService(glRepo *repository.GLExportRepository, periodRepo *repository.PeriodRepository) *GLExportService {
+	return &GLExportService{
+		glRepo:     glRepo,
+		periodRepo: periodRepo,
+	}
+}
+
+// GetManifest lists every exported period without its lines
+func (s *GLExportService) GetManifest() ([]models.GLExport, error) {
+	return s.glRepo.FindAll()
+}
+
+// GetExport returns a period's export with its lines
+func (s *GLExportService) GetExport(period string) (*models.GLExport, error) {
+	if _, _, err := ParsePeriod(period); err != nil {
+		return nil, err
+	}
+
+	export, err := s.glRepo.FindByPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil {
+		return nil, fmt.Errorf("period %s has not been exported", period)
+	}
+	return export, nil
+}
+
+// Export builds and stores the journals of a closed period. Exporting a
+// period again returns the stored export unchanged, with created false.
+func (s *GLExportService) Export(period string, userID int64, audit *models.AuditContext) (*models.GLExport, bool, error) {
+	start, end, err := ParsePeriod(period)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := s.glRepo.FindByPeriod(period)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+
+	// Only a closed period's ledger is final
+	p, err := s.periodRepo.FindByPeriod(period)
+	if err != nil {
+		return nil, false, err
+	}
+	if p == nil || p.Status != models.PeriodClosed {
+		return nil, false, repository.ErrPeriodNotClosed
+	}
+
+	movements, err := s.glRepo.Movements(start, end)
+	if err != nil {
+		return nil, false, err
+	}
+
+	lines, journals, err := buildJournal(movements, config.AppConfig.GLAccounts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	export := &models.GLExport{
+		Period:         period,
+		PeriodClosedAt: *p.ClosedAt,
+		JournalCount:   journals,
+		LineCount:      len(lines),
+		ExportedBy:     userID,
+		Lines:          lines,
+	}
+	for _, line := range lines {
+		export.TotalDebit += line.Debit
+		export.TotalCredit += line.Credit
+	}
+	export.TotalDebit = roundMoney(export.TotalDebit)
+	export.TotalCredit = roundMoney(export.TotalCredit)
+
+	body, err := JournalCSV(export)
+	if err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256(body)
+	export.Checksum = hex.EncodeToString(sum[:])
+
+	return s.glRepo.Create(export, audit)
+}
+
+// buildJournal posts each movement group as a debit and a credit of the same
+// amount, so every journal balances. It returns the lines and the number of
+// journals they belong to.
+func buildJournal(movements []models.GLMovement, accounts map[string]string) ([]models.JournalLine, int, error) {
+	lines := []models.JournalLine{}
+	journals := map[string]bool{}
+
+	for _, m := range movements {
+		postings, ok := glPostings[m.TransactionType]
+		if !ok {
+			return nil, 0, fmt.Errorf("no GL posting for transaction type %s", m.TransactionType)
+		}
+
+		journal := m.StoreCode
+		if m.StoreID == nil {
+			journal = warehouseJournal
+		}
+
+		for _, p := range postings {
+			amount := m.Amount
+			if p.atCost {
+				amount = m.Cost
+			}
+			amount = roundMoney(amount)
+			if amount == 0 {
+				continue
+			}
+
+			debit, credit, description := p.debit, p.credit, p.description
+			if m.Direction < 0 {
+				debit, credit, description = p.credit, p.debit, p.lossDescription
+			}
+			description = fmt.Sprintf("%s (%d movements)", description, m.Count)
+
+			journals[journal] = true
+			lines = append(lines,
+				models.JournalLine{
+					LineNo: len(lines) + 1, Journal: journal, StoreID: m.StoreID,
+					Account: accounts[debit], AccountRole: debit, Description: description, Debit: amount,
+				},
+				models.JournalLine{
+					LineNo: len(lines) + 2, Journal: journal, StoreID: m.StoreID,
+					Account: accounts[credit], AccountRole: credit, Description: description, Credit: amount,
+				},
+			)
+		}
+	}
+
+	return lines, len(journals), nil
+}
+
+// JournalCSV renders an export's lines as CSV. The export's checksum is
+// taken over exactly these bytes.
+func JournalCSV(export *models.GLExport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"period", "line_no", "journal", "account", "account_role", "description", "debit", "credit"})
+	for _, line := range export.Lines {
+		w.Write([]string{
+			export.Period,
+			strconv.Itoa(line.LineNo),
+			line.Journal,
+			line.Account,
+			line.AccountRole,
+			line.Description,
+			strconv.FormatFloat(line.Debit, 'f', 2, 64),
+			strconv.FormatFloat(line.Credit, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write journal CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("Idempotency-Key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is still being processed")
+)
+
+// IdempotencyService lets clients retry POSTs safely: the first request with
+// a key is processed and its response stored, later ones get that response
+type IdempotencyService struct {
+	idempotencyRepo *repository.IdempotencyRepository
+}
+
+func NewIdempotencyService(idempotencyRepo *repository.IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{idempotencyRepo: idempotencyRepo}
+}
+
+// Begin claims a key for a request. It returns the claimed record's ID when
+// the request should be processed, or the stored record when it should be
+// replayed instead.
+func (s *IdempotencyService) Begin(principal, key, requestHash string) (int64, *models.IdempotencyRecord, error) {
+	now := time.Now()
+	if err := s.idempotencyRepo.DeleteExpired(now); err != nil {
+		return 0, nil, err
+	}
+
+	record := &models.IdempotencyRecord{
+		Principal:   principal,
+		KeyHash:     hashToken(key),
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(config.AppConfig.IdempotencyKeyTTL),
+	}
+	existing, err := s.idempotencyRepo.Claim(record)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing == nil {
+		return record.ID, nil, nil
+	}
+
+	if existing.RequestHash != requestHash {
+		return 0, nil, ErrIdempotencyKeyReused
+	}
+	if existing.StatusCode == 0 {
+		return 0, nil, ErrIdempotencyKeyInProgress
+	}
+	return 0, existing, nil
+}
+
+// Complete stores the response for replay
+func (s *IdempotencyService) Complete(id int64, statusCode int, responseBody string) error {
+	return s.idempotencyRepo.Complete(id, statusCode, responseBody)
+}
+
+// Release gives the key up so a retry is processed again, for responses
+// that say nothing about whether the request would succeed
+func (s *IdempotencyService) Release(id int64) error {
+	return s.idempotencyRepo.Release(id)
+}
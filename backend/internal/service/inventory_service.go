@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+// ReportInventoryAsOf names the stock reconstruction in the report envelope
+const ReportInventoryAsOf = "inventory_as_of"
+
+// snapshotLag keeps a snapshot clear of transactions that were stamped just
+// before it but had not committed yet
+const snapshotLag = time.Minute
+
+// InventoryService answers what stock was held at a past time. It replays
+// the transaction ledger from the last periodic snapshot before that time.
+type InventoryService struct {
+	inventoryRepo *repository.InventoryRepository
+}
+
+func NewInventoryService(inventoryRepo *repository.InventoryRepository) *InventoryService {
+	return &InventoryService{inventoryRepo: inventoryRepo}
+}
+
+// StockAsOf reconstructs each product's quantity and value at asOf.
+// Callers without report.view_cost get quantities only.
+func (s *InventoryService) StockAsOf(asOf time.Time, productID int64, canViewCost bool) (*models.Report, error) {
+	rows, err := s.inventoryRepo.StockAsOf(asOf, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct stock: %w", err)
+	}
+
+	totals := &models.InventoryTotals{Products: len(rows)}
+	var totalValue float64
+	for i := range rows {
+		row := &rows[i]
+		totals.Quantity += row.Quantity
+
+		if !canViewCost {
+			row.UnitCost = nil
+			continue
+		}
+		value := roundMoney(float64(row.Quantity) * *row.UnitCost)
+		row.Value = &value
+		totalValue += value
+	}
+	if canViewCost {
+		totalValue = roundMoney(totalValue)
+		totals.Value = &totalValue
+	}
+
+	return &models.Report{
+		Name:        ReportInventoryAsOf,
+		GeneratedAt: time.Now(),
+		Filter:      models.ReportFilter{AsOf: &asOf, ProductID: productID},
+		Rows:        rows,
+		Totals:      totals,
+	}, nil
+}
+
+// TakeSnapshot stores the stock of every product as of at
+func (s *InventoryService) TakeSnapshot(at time.Time) error {
+	count, err := s.inventoryRepo.CreateSnapshot(at)
+	if err != nil {
+		return err
+	}
+	log.Printf("Stored inventory snapshot for %s (%d products)", at.Format(time.RFC3339), count)
+	return nil
+}
+
+// RunSnapshots takes a snapshot whenever the latest one is older than
+// interval, checking at startup and then every interval until ctx ends
+func (s *InventoryService) RunSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		at := time.Now().Add(-snapshotLag)
+		latest, err := s.inventoryRepo.LatestSnapshot(at)
+		if err != nil {
+			log.Printf("Failed to check inventory snapshots: %v", err)
+		} else if latest == nil || at.Sub(*latest) >= interval {
+			if err := s.TakeSnapshot(at); err != nil {
+				log.Printf("Failed to take inventory snapshot: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/pkg/jwt"
+	"pos-backoffice/pkg/totp"
+)
+
+const recoveryCodeCount = 10
+
+var errInvalidMFACode = errors.New("invalid verification code")
+
+type MFAService struct {
+	userRepo repository.UserRepository
+	mfaRepo  *repository.MFARepository
+	roleRepo *repository.RoleRepository
+}
+
+func NewMFAService(userRepo repository.UserRepository, mfaRepo *repository.MFARepository, roleRepo *repository.RoleRepository) *MFAService {
+	return &MFAService{
+		userRepo: userRepo,
+		mfaRepo:  mfaRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+// GetStatus reports whether a user has two-factor authentication enabled
+// and whether their role requires it
+func (s *MFAService) GetStatus(userID int64) (*models.MFAStatus, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	required, err := roleRequiresMFA(s.roleRepo, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.MFAStatus{Required: required}
+
+	mfa, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.EnabledAt != nil {
+		status.Enabled = true
+		if status.RecoveryCodesRemaining, err = s.mfaRepo.CountRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// BeginEnrollment generates a new TOTP secret for a signed-in user. The
+// enrollment stays pending until ConfirmEnrollment is called with a code.
+func (s *MFAService) BeginEnrollment(userID int64) (*models.MFAEnrollResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.beginEnrollment(user)
+}
+
+// BeginEnrollmentWithToken starts enrollment for a user whose role requires
+// two-factor authentication but who has not set it up yet. The MFA token from
+// the password step stands in for a session; the login is completed with
+// AuthService.LoginMFA.
+func (s *MFAService) BeginEnrollmentWithToken(mfaToken string) (*models.MFAEnrollResponse, error) {
+	claims, err := jwt.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired MFA token")
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil || user.Status != "ACTIVE" {
+		return nil, fmt.Errorf("user is not active")
+	}
+	return s.beginEnrollment(user)
+}
+
+func (s *MFAService) beginEnrollment(user *models.User) (*models.MFAEnrollResponse, error) {
+	existing, err := s.mfaRepo.FindByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.EnabledAt != nil {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.SavePending(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(config.AppConfig.MFAIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication once the user proves
+// their authenticator works. The returned recovery codes are shown only once.
+func (s *MFAService) ConfirmEnrollment(userID int64, code string, audit *models.AuditContext) ([]string, error) {
+	mfa, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || mfa.EnabledAt != nil {
+		return nil, fmt.Errorf("no pending two-factor enrollment")
+	}
+
+	return activateMFA(s.mfaRepo, mfa, code, audit)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a TOTP code
+func (s *MFAService) RegenerateRecoveryCodes(userID int64, code string, audit *models.AuditContext) ([]string, error) {
+	mfa, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || mfa.EnabledAt == nil {
+		return nil, fmt.Errorf("two-factor authentication is not enabled")
+	}
+
+	if err := checkMFACode(s.mfaRepo, mfa, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes, audit); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns off two-factor authentication unless the user's role requires it
+func (s *MFAService) Disable(userID int64, code string, audit *models.AuditContext) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	required, err := roleRequiresMFA(s.roleRepo, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return fmt.Errorf("two-factor authentication is required for role %s", user.Role)
+	}
+
+	mfa, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || mfa.EnabledAt == nil {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+
+	if err := checkMFACode(s.mfaRepo, mfa, code); err != nil {
+		return err
+	}
+
+	return s.mfaRepo.Delete(userID, audit)
+}
+
+// Reset removes another user's enrollment, e.g. after a lost device. If their
+// role requires two-factor authentication they must enroll again at next login.
+func (s *MFAService) Reset(userID int64, audit *models.AuditContext) error {
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return err
+	}
+	return s.mfaRepo.Delete(userID, audit)
+}
+
+// activateMFA confirms a pending enrollment with a TOTP code and issues recovery codes
+func activateMFA(mfaRepo *repository.MFARepository, mfa *models.UserMFA, code string, audit *models.AuditContext) ([]string, error) {
+	step, ok := totp.Validate(mfa.Secret, code, time.Now())
+	if !ok {
+		return nil, errInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mfaRepo.Enable(mfa.UserID, step, hashes, audit); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// checkMFACode accepts either a current TOTP code or an unused recovery code.
+// Each TOTP code and recovery code can be used only once.
+func checkMFACode(mfaRepo *repository.MFARepository, mfa *models.UserMFA, code string) error {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(mfa.Secret, code, time.Now())
+		if !ok {
+			return errInvalidMFACode
+		}
+
+		fresh, err := mfaRepo.UseStep(mfa.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return errInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := mfaRepo.UseRecoveryCode(mfa.UserID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errInvalidMFACode
+	}
+	return nil
+}
+
+func roleRequiresMFA(roleRepo *repository.RoleRepository, roleCode string) (bool, error) {
+	role, err := roleRepo.FindByCode(roleCode)
+	if err != nil {
+		return false, err
+	}
+	return role != nil && role.RequireMFA, nil
+}
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/pkg/notify"
+)
+
+// notifyTimeout bounds how long a request waits on the notifier
+const notifyTimeout = 10 * time.Second
+
+// PasswordService lets users change their password and recover a forgotten
+// one through a single-use, expiring reset link
+type PasswordService struct {
+	userRepo    repository.UserRepository
+	resetRepo   *repository.PasswordResetRepository
+	sessionRepo *repository.SessionRepository
+	loginRepo   *repository.LoginRepository
+	oidcRepo    *repository.OIDCRepository
+	notifier    notify.Notifier
+}
+
+func NewPasswordService(userRepo repository.UserRepository, resetRepo *repository.PasswordResetRepository, sessionRepo *repository.SessionRepository, loginRepo *repository.LoginRepository, oidcRepo *repository.OIDCRepository, notifier notify.Notifier) *PasswordService {
+	return &PasswordService{
+		userRepo:    userRepo,
+		resetRepo:   resetRepo,
+		sessionRepo: sessionRepo,
+		loginRepo:   loginRepo,
+		oidcRepo:    oidcRepo,
+		notifier:    notifier,
+	}
+}
+
+// ChangePassword sets a new password for the signed-in user after checking the
+// current one. Every other session of the user is revoked; the session making
+// the request stays signed in.
+func (s *PasswordService) ChangePassword(userID int64, sessionID string, req *models.ChangePasswordRequest, audit *models.AuditContext) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	sso, err := s.oidcRepo.HasIdentity(user.ID)
+	if err != nil {
+		return err
+	}
+	if sso {
+		return fmt.Errorf("your password is managed by your identity provider")
+	}
+
+	if !checkPassword(user.PasswordHash, req.CurrentPassword) {
+		return fmt.Errorf("current password is incorrect")
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return fmt.Errorf("new password must be different from the current password")
+	}
+	if err := ValidatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	passwordHash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, passwordHash, audit); err != nil {
+		return err
+	}
+
+	if err := s.sessionRepo.RevokeOtherSessions(user.ID, sessionID, "PASSWORD_CHANGED"); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.send(user, "Your password was changed",
+		"The password for your POS Backoffice account "+user.Username+" was just changed.\n\n"+
+			"If you did not do this, contact your administrator immediately.")
+
+	return nil
+}
+
+// ForgotPassword emails a reset link to the user. It reports success whether
+// or not the username exists, so it cannot be used to discover accounts.
+func (s *PasswordService) ForgotPassword(req *models.ForgotPasswordRequest, ipAddress string) error {
+	user, err := s.userRepo.FindByUsername(req.Username)
+	if err != nil {
+		return nil
+	}
+
+	if user.Email == "" {
+		log.Printf("Password reset requested for %q, who has no email address", user.Username)
+		return nil
+	}
+
+	sso, err := s.oidcRepo.HasIdentity(user.ID)
+	if err != nil {
+		return err
+	}
+	if sso {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.resetRepo.DeleteExpired(now); err != nil {
+		return err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	ttl := config.AppConfig.PasswordResetTTL
+	if err := s.resetRepo.Create(user.ID, hashToken(token), now.Add(ttl), ipAddress); err != nil {
+		return err
+	}
+
+	s.send(user, "Reset your password",
+		"Someone asked to reset the password for your POS Backoffice account "+user.Username+".\n\n"+
+			"Open this link to choose a new password:\n"+resetLink(token)+"\n\n"+
+			"The link works once and expires in "+ttl.String()+". "+
+			"If you did not ask for this, you can ignore this message.")
+
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. The
+// token is consumed, all of the user's sessions are revoked and any login
+// lockout on the username is lifted.
+func (s *PasswordService) ResetPassword(req *models.ResetPasswordRequest, audit *models.AuditContext) error {
+	if err := ValidatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	passwordHash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userID, err := s.resetRepo.ResetPassword(hashToken(req.Token), passwordHash, time.Now(), audit)
+	if err != nil {
+		return fmt.Errorf("invalid or expired reset token")
+	}
+
+	if err := s.sessionRepo.RevokeUserSessions(userID, "PASSWORD_RESET"); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.loginRepo.ResetThrottle("user:" + user.Username); err != nil {
+		return err
+	}
+
+	s.send(user, "Your password was reset",
+		"The password for your POS Backoffice account "+user.Username+" was reset and you were signed out everywhere.\n\n"+
+			"If you did not do this, contact your administrator immediately.")
+
+	return nil
+}
+
+// ValidatePassword checks a new password against the configured strength rules
+func ValidatePassword(password string) error {
+	cfg := config.AppConfig
+
+	if len([]rune(password)) < cfg.PasswordMinLength {
+		return fmt.Errorf("password must be at least %d characters", cfg.PasswordMinLength)
+	}
+	// bcrypt silently ignores anything longer
+	if len(password) > 72 {
+		return fmt.Errorf("password must be at most 72 bytes")
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	var missing []string
+	if cfg.PasswordRequireUpper && !upper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if cfg.PasswordRequireLower && !lower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if cfg.PasswordRequireDigit && !digit {
+		missing = append(missing, "a digit")
+	}
+	if cfg.PasswordRequireSymbol && !symbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("password must contain %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// checkPassword compares against a bcrypt hash, falling back to plain text
+// comparison for the seed users (for testing only)
+func checkPassword(passwordHash, password string) bool {
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil {
+		return true
+	}
+	return passwordHash == password
+}
+
+// send delivers a notification; a failure is logged rather than returned so it
+// neither blocks the password change nor reveals whether an account exists
+func (s *PasswordService) send(user *models.User, subject, body string) {
+	if user.Email == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if err := s.notifier.Send(ctx, notify.Message{To: user.Email, Subject: subject, Body: body}); err != nil {
+		log.Printf("Failed to notify %q: %v", user.Username, err)
+	}
+}
+
+func resetLink(token string) string {
+	link := config.AppConfig.PasswordResetURL
+	sep := "?"
+	if strings.Contains(link, "?") {
+		sep = "&"
+	}
+	return link + sep + "token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+// ErrPeriodNotEnded is returned when closing a month that is not over yet
+var ErrPeriodNotEnded = errors.New("accounting period has not ended yet")
+
+// periodLayout is how periods are named: one per calendar month
+const periodLayout = "2006-01"
+
+// PeriodService opens and closes accounting periods
+type PeriodService struct {
+	periodRepo *repository.PeriodRepository
+}
+
+func NewPeriodService(periodRepo *repository.PeriodRepository) *PeriodService {
+	return &PeriodService{periodRepo: periodRepo}
+}
+
+// ParsePeriod reads a YYYY-MM period name into the month it covers, in the
+// server's time zone
+func ParsePeriod(period string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation(periodLayout, period, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("expected period as YYYY-MM, got %q", period)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// GetPeriods returns the periods that have been closed at least once
+func (s *PeriodService) GetPeriods(canViewCost bool) ([]models.AccountingPeriod, error) {
+	periods, err := s.periodRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	if !canViewCost {
+		for i := range periods {
+			periods[i].StockValue = nil
+		}
+	}
+	return periods, nil
+}
+
+// GetPeriod returns a period with its closing valuation. Months that were
+// never closed are reported as open. Callers without report.view_cost get
+// neither the valuation nor its total.
+func (s *PeriodService) GetPeriod(period string, canViewCost bool) (*models.AccountingPeriod, error) {
+	start, end, err := ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.periodRepo.FindByPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return &models.AccountingPeriod{Period: period, Start: start, End: end, Status: models.PeriodOpen}, nil
+	}
+
+	if !canViewCost {
+		p.StockValue = nil
+		p.Valuation = nil
+	}
+	return p, nil
+}
+
+// ClosePeriod closes a month that has ended and snapshots its closing stock
+// valuation. From then on no transaction dated in it can be posted.
+func (s *PeriodService) ClosePeriod(period string, userID int64, audit *models.AuditContext) (*models.AccountingPeriod, error) {
+	start, end, err := ParsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	// Leave a moment for transactions stamped just before the end to commit
+	if time.Now().Add(-snapshotLag).Before(end) {
+		return nil, ErrPeriodNotEnded
+	}
+
+	if err := s.periodRepo.Close(period, start, end, userID, audit); err != nil {
+		return nil, err
+	}
+	return s.periodRepo.FindByPeriod(period)
+}
+
+// ReopenPeriod lets transactions be posted into a closed month again
+func (s *PeriodService) ReopenPeriod(period string, userID int64, reason string, audit *models.AuditContext) (*models.AccountingPeriod, error) {
+	if _, _, err := ParsePeriod(period); err != nil {
+		return nil, err
+	}
+
+	if err := s.periodRepo.Reopen(period, userID, reason, audit); err != nil {
+		return nil, err
+	}
+	return s.periodRepo.FindByPeriod(period)
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"math"
 
@@ -8,11 +9,15 @@ import (
 	"pos-backoffice/internal/repository"
 )
 
+// ErrPriceChangeNotAllowed is returned when a user without the product.price
+// permission tries to change a product's price or cost
+var ErrPriceChangeNotAllowed = errors.New("changing price or cost requires the product.price permission")
+
 type ProductService struct {
-	productRepo *repository.ProductRepository
+	productRepo repository.ProductRepository
 }
 
-func NewProductService(productRepo *repository.ProductRepository) *ProductService {
+func NewProductService(productRepo repository.ProductRepository) *ProductService {
 	return &ProductService{
 		productRepo: productRepo,
 	}
@@ -43,6 +48,26 @@ func (s *ProductService) GetProducts(page, pageSize int, search, status string)
 	}, nil
 }
 
+// GetProductPage retrieves the products after cursor, for clients paging
+// through large catalogs
+func (s *ProductService) GetProductPage(pageSize int, search, status, cursor string, includeTotal bool) (*models.ProductPage, error) {
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	products, next, total, err := s.productRepo.FindPage(pageSize, search, status, cursor, includeTotal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	return &models.ProductPage{
+		Products:   products,
+		NextCursor: next,
+		PageSize:   pageSize,
+		Total:      total,
+	}, nil
+}
+
 // GetProductByID retrieves a product by ID
 func (s *ProductService) GetProductByID(id int64) (*models.Product, error) {
 	product, err := s.productRepo.FindByID(id)
@@ -53,7 +78,7 @@ func (s *ProductService) GetProductByID(id int64) (*models.Product, error) {
 }
 
 // CreateProduct creates a new product
-func (s *ProductService) CreateProduct(req *models.CreateProductRequest, userID int64) (*models.Product, error) {
+func (s *ProductService) CreateProduct(req *models.CreateProductRequest, userID int64, audit *models.AuditContext) (*models.Product, error) {
 	// Check if SKU already exists
 	existing, err := s.productRepo.FindBySKU(req.SKU)
 	if err != nil {
@@ -80,7 +105,7 @@ func (s *ProductService) CreateProduct(req *models.CreateProductRequest, userID
 		UpdatedBy:   userID,
 	}
 
-	err = s.productRepo.Create(product)
+	err = s.productRepo.Create(product, audit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
@@ -88,14 +113,20 @@ func (s *ProductService) CreateProduct(req *models.CreateProductRequest, userID
 	return product, nil
 }
 
-// UpdateProduct updates an existing product
-func (s *ProductService) UpdateProduct(id int64, req *models.UpdateProductRequest, userID int64) (*models.Product, error) {
+// UpdateProduct updates an existing product. A non-zero version makes the
+// update fail with repository.ErrVersionConflict if someone else changed the
+// product since the caller read it.
+func (s *ProductService) UpdateProduct(id int64, version int, req *models.UpdateProductRequest, userID int64, canChangePrice bool, audit *models.AuditContext) (*models.Product, error) {
 	// Check if product exists
 	product, err := s.productRepo.FindByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("product not found")
 	}
 
+	if !canChangePrice && (req.Price != product.Price || req.Cost != product.Cost) {
+		return nil, ErrPriceChangeNotAllowed
+	}
+
 	// Validate price > cost
 	if req.Price < req.Cost {
 		return nil, fmt.Errorf("price must be greater than or equal to cost")
@@ -107,8 +138,9 @@ func (s *ProductService) UpdateProduct(id int64, req *models.UpdateProductReques
 	product.Price = req.Price
 	product.Cost = req.Cost
 	product.UpdatedBy = userID
+	product.Version = version
 
-	err = s.productRepo.Update(product)
+	err = s.productRepo.Update(product, audit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
@@ -116,11 +148,60 @@ func (s *ProductService) UpdateProduct(id int64, req *models.UpdateProductReques
 	return product, nil
 }
 
-// DeleteProduct soft deletes a product
-func (s *ProductService) DeleteProduct(id int64, userID int64) error {
-	err := s.productRepo.Delete(id, userID)
+// DeleteProduct soft deletes a product, conditionally on version as in UpdateProduct
+func (s *ProductService) DeleteProduct(id int64, version int, userID int64, audit *models.AuditContext) error {
+	err := s.productRepo.Delete(id, userID, version, audit)
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 	return nil
 }
+
+// GetRevisions returns a product's revision history, newest first
+func (s *ProductService) GetRevisions(id int64) ([]models.ProductRevision, error) {
+	if _, err := s.productRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.productRepo.FindRevisions(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// RevertProduct restores the name, description, price and cost of an older
+// revision and saves them as a new revision. Status is not restored, so a
+// deleted product stays deleted.
+func (s *ProductService) RevertProduct(id int64, revision int, userID int64, canChangePrice bool, audit *models.AuditContext) (*models.Product, error) {
+	product, err := s.productRepo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("product not found")
+	}
+
+	rev, err := s.productRepo.FindRevision(id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canChangePrice && (rev.Price != product.Price || rev.Cost != product.Cost) {
+		return nil, ErrPriceChangeNotAllowed
+	}
+
+	if rev.Name == product.Name && rev.Description == product.Description &&
+		rev.Price == product.Price && rev.Cost == product.Cost {
+		return nil, fmt.Errorf("product already matches revision %d", revision)
+	}
+
+	product.Name = rev.Name
+	product.Description = rev.Description
+	product.Price = rev.Price
+	product.Cost = rev.Cost
+	product.UpdatedBy = userID
+
+	if err := s.productRepo.Revert(product, revision, audit); err != nil {
+		return nil, fmt.Errorf("failed to revert product: %w", err)
+	}
+
+	return s.productRepo.FindByID(id)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+// ReconciliationService checks that every product's stock matches what its
+// transaction ledger adds up to, and optionally posts adjustments to the
+// ledger where it does not
+type ReconciliationService struct {
+	reconRepo *repository.ReconciliationRepository
+	userRepo  repository.UserRepository
+}
+
+func NewReconciliationService(reconRepo *repository.ReconciliationRepository, userRepo repository.UserRepository) *ReconciliationService {
+	return &ReconciliationService{
+		reconRepo: reconRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Reconcile runs a check on behalf of userID. With fix, the mismatches are
+// closed by ADJUSTMENT transactions attributed to the same user.
+func (s *ReconciliationService) Reconcile(userID int64, fix bool, audit *models.AuditContext) (*models.StockReconciliation, error) {
+	var fixAs int64
+	if fix {
+		fixAs = userID
+	}
+
+	run, err := s.reconRepo.Reconcile(&userID, fixAs, audit)
+	if err != nil {
+		return nil, err
+	}
+	logReconciliation(run)
+	return run, nil
+}
+
+// GetReconciliations returns reconciliation runs, newest first
+func (s *ReconciliationService) GetReconciliations(page, limit int) (*models.StockReconciliationListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	runs, total, err := s.reconRepo.FindAll(page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StockReconciliationListResponse{
+		Reconciliations: runs,
+		Total:           total,
+		Page:            page,
+		Limit:           limit,
+	}, nil
+}
+
+// GetReconciliation returns a run with its mismatches
+func (s *ReconciliationService) GetReconciliation(id int64) (*models.StockReconciliation, error) {
+	run, err := s.reconRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("reconciliation not found")
+	}
+	return run, nil
+}
+
+// RunReconciliations checks stock at startup and then every interval until
+// ctx ends. When fixAs names an active user, mismatches are fixed as that
+// user; otherwise they are only recorded and logged.
+func (s *ReconciliationService) RunReconciliations(ctx context.Context, interval time.Duration, fixAs string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.runScheduled(fixAs); err != nil {
+			log.Printf("Failed to reconcile stock: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReconciliationService) runScheduled(fixAs string) error {
+	var fixAsID int64
+	if fixAs != "" {
+		// Looked up every run so a disabled user stops being used
+		user, err := s.userRepo.FindByUsername(fixAs)
+		if err != nil {
+			return fmt.Errorf("reconciliation user %s: %w", fixAs, err)
+		}
+		fixAsID = user.ID
+	}
+
+	run, err := s.reconRepo.Reconcile(nil, fixAsID, nil)
+	if err != nil {
+		return err
+	}
+	logReconciliation(run)
+	return nil
+}
+
+func logReconciliation(run *models.StockReconciliation) {
+	log.Printf("Stock reconciliation #%d checked %d products, %d mismatched", run.ID, run.ProductsChecked, run.Mismatches)
+	for _, item := range run.Items {
+		action := "not fixed"
+		if item.AdjustmentID != nil {
+			action = fmt.Sprintf("adjusted by transaction #%d", *item.AdjustmentID)
+		}
+		log.Printf("  %s: stock %d, ledger %d (%s)", item.SKU, item.Stock, item.LedgerStock, action)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+// Report names, as given in the report envelope
+const (
+	ReportMovements = "movements"
+)
+
+// ReportService builds the reports shown on the Reports page. The figures are
+// aggregated by the database; this only shapes them.
+type ReportService struct {
+	reportRepo *repository.ReportRepository
+}
+
+func NewReportService(reportRepo *repository.ReportRepository) *ReportService {
+	return &ReportService{reportRepo: reportRepo}
+}
+
+// MovementReport sums sales and purchases, grouped as the filter asks.
+// Callers without report.view_cost get no cost or margin figures.
+func (s *ReportService) MovementReport(filter *models.ReportFilter, scope *models.StoreScope, canViewCost bool) (*models.Report, error) {
+	rows, totals, err := s.reportRepo.MovementReport(filter, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build movement report: %w", err)
+	}
+
+	for i := range rows {
+		applyCostVisibility(&rows[i].MovementMetrics, canViewCost)
+	}
+	applyCostVisibility(totals, canViewCost)
+
+	return &models.Report{
+		Name:        ReportMovements,
+		GeneratedAt: time.Now(),
+		Filter:      *filter,
+		Rows:        rows,
+		Totals:      totals,
+	}, nil
+}
+
+// applyCostVisibility derives the margin from the cost, or drops both
+func applyCostVisibility(m *models.MovementMetrics, canViewCost bool) {
+	if !canViewCost || m.Cost == nil {
+		m.Cost = nil
+		return
+	}
+
+	margin := roundMoney(m.SalesAmount - *m.Cost)
+	m.Margin = &margin
+	if m.SalesAmount != 0 {
+		percent := roundMoney(margin / m.SalesAmount * 100)
+		m.MarginPercent = &percent
+	}
+	cost := roundMoney(*m.Cost)
+	m.Cost = &cost
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
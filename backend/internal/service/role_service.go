@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+type RoleService struct {
+	roleRepo *repository.RoleRepository
+}
+
+func NewRoleService(roleRepo *repository.RoleRepository) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+	}
+}
+
+// GetPermissions lists every grantable permission
+func (s *RoleService) GetPermissions() ([]models.Permission, error) {
+	return s.roleRepo.FindAllPermissions()
+}
+
+// GetRoles lists all roles with their permissions
+func (s *RoleService) GetRoles() ([]models.Role, error) {
+	return s.roleRepo.FindAll()
+}
+
+// GetRoleByID retrieves a role with its permissions
+func (s *RoleService) GetRoleByID(id int64) (*models.Role, error) {
+	return s.roleRepo.FindByID(id)
+}
+
+// CreateRole creates a new role
+func (s *RoleService) CreateRole(req *models.CreateRoleRequest, audit *models.AuditContext) (*models.Role, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+
+	existing, err := s.roleRepo.FindByCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check role code: %w", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("role code already exists")
+	}
+
+	permissions, err := s.validatePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Code:        code,
+		Name:        req.Name,
+		Description: req.Description,
+		RequireMFA:  req.RequireMFA,
+		Permissions: permissions,
+	}
+
+	if err := s.roleRepo.Create(role, audit); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.FindByID(role.ID)
+}
+
+// UpdateRole updates a role's name, description and permission set.
+// Users holding the role pick up the change when their access token is refreshed.
+func (s *RoleService) UpdateRole(id int64, req *models.UpdateRoleRequest, audit *models.AuditContext) (*models.Role, error) {
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.validatePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Name = req.Name
+	role.Description = req.Description
+	role.RequireMFA = req.RequireMFA
+	role.Permissions = permissions
+
+	if err := s.roleRepo.Update(role, audit); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.FindByID(id)
+}
+
+// DeleteRole deletes a custom role that is not in use
+func (s *RoleService) DeleteRole(id int64, audit *models.AuditContext) error {
+	return s.roleRepo.Delete(id, audit)
+}
+
+// validatePermissions rejects unknown codes and removes duplicates
+func (s *RoleService) validatePermissions(codes []string) ([]string, error) {
+	known, err := s.roleRepo.FindAllPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make(map[string]bool, len(known))
+	for _, p := range known {
+		valid[p.Code] = true
+	}
+
+	seen := make(map[string]bool, len(codes))
+	result := []string{}
+	for _, code := range codes {
+		if !valid[code] {
+			return nil, fmt.Errorf("unknown permission: %s", code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			result = append(result, code)
+		}
+	}
+
+	return result, nil
+}
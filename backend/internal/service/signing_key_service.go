@@ -0,0 +1,132 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"time"
+
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/pkg/jwt"
+)
+
+type SigningKeyService struct {
+	signingKeyRepo *repository.SigningKeyRepository
+}
+
+func NewSigningKeyService(signingKeyRepo *repository.SigningKeyRepository) *SigningKeyService {
+	return &SigningKeyService{
+		signingKeyRepo: signingKeyRepo,
+	}
+}
+
+// Init switches token signing to asymmetric keys when JWT_ALGORITHM asks for
+// them, generating the first key if none of that algorithm is active
+func (s *SigningKeyService) Init() error {
+	algorithm := config.AppConfig.JWTAlgorithm
+	if algorithm == "HS256" {
+		return nil
+	}
+
+	keys, err := s.UsableKeys()
+	if err != nil {
+		return err
+	}
+
+	hasActive := false
+	for _, k := range keys {
+		if k.RetiredAt == nil && k.Algorithm == algorithm {
+			hasActive = true
+		}
+	}
+
+	if !hasActive {
+		key, err := s.rotate(algorithm, nil)
+		if err != nil {
+			return err
+		}
+		log.Printf("Generated %s signing key %s", algorithm, key.KID)
+	}
+
+	return jwt.InitKeys(s.UsableKeys)
+}
+
+// GetSigningKeys lists all signing keys without their private parts
+func (s *SigningKeyService) GetSigningKeys() ([]models.SigningKey, error) {
+	return s.signingKeyRepo.FindAll()
+}
+
+// UsableKeys returns the active key and retired keys still in their grace period
+func (s *SigningKeyService) UsableKeys() ([]models.SigningKey, error) {
+	return s.signingKeyRepo.FindUsable(time.Now().Add(-config.AppConfig.JWTKeyGracePeriod))
+}
+
+// Rotate generates a new active key. The previous key keeps validating tokens
+// for JWT_KEY_GRACE_PERIOD, so nobody is logged out.
+func (s *SigningKeyService) Rotate(req *models.RotateSigningKeyRequest, audit *models.AuditContext) (*models.SigningKey, error) {
+	if config.AppConfig.JWTAlgorithm == "HS256" {
+		return nil, fmt.Errorf("key rotation requires JWT_ALGORITHM RS256 or ES256")
+	}
+
+	algorithm := req.Algorithm
+	if algorithm == "" {
+		algorithm = config.AppConfig.JWTAlgorithm
+	}
+
+	key, err := s.rotate(algorithm, audit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jwt.ReloadKeys(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *SigningKeyService) rotate(algorithm string, audit *models.AuditContext) (*models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	kid, err := jwt.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.SigningKey{
+		KID:           kid,
+		Algorithm:     algorithm,
+		PrivateKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	}
+
+	if err := s.signingKeyRepo.Rotate(key, audit); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
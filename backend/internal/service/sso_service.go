@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+	"pos-backoffice/pkg/oidc"
+)
+
+const (
+	// SSOLoginTTL is how long the user has to authenticate at the provider
+	SSOLoginTTL = 10 * time.Minute
+	// ssoHandoffTTL is how long the frontend has to redeem the handoff code
+	ssoHandoffTTL = time.Minute
+)
+
+// SSOService logs users in through an OpenID Connect provider, creating local
+// accounts on first login and keeping their role in sync with provider groups
+type SSOService struct {
+	provider    *oidc.Provider
+	oidcRepo    *repository.OIDCRepository
+	userRepo    repository.UserRepository
+	roleRepo    *repository.RoleRepository
+	authService *AuthService
+}
+
+func NewSSOService(provider *oidc.Provider, oidcRepo *repository.OIDCRepository, userRepo repository.UserRepository, roleRepo *repository.RoleRepository, authService *AuthService) *SSOService {
+	return &SSOService{
+		provider:    provider,
+		oidcRepo:    oidcRepo,
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		authService: authService,
+	}
+}
+
+// Enabled reports whether an identity provider is configured
+func (s *SSOService) Enabled() bool {
+	return s.provider != nil
+}
+
+// BeginLogin starts an authorization code flow and returns the provider URL
+// to redirect the browser to, and the state the browser must present to
+// HandleCallback
+func (s *SSOService) BeginLogin(ctx context.Context) (authURL, state string, err error) {
+	if !s.Enabled() {
+		return "", "", fmt.Errorf("single sign-on is not configured")
+	}
+
+	now := time.Now()
+	if err := s.oidcRepo.DeleteExpiredLogins(now); err != nil {
+		return "", "", err
+	}
+
+	state, err = oidc.NewRandomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.NewRandomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewRandomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.oidcRepo.CreateLogin(hashToken(state), nonce, verifier, now.Add(SSOLoginTTL)); err != nil {
+		return "", "", err
+	}
+
+	authURL, err = s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	return authURL, state, err
+}
+
+// HandleCallback completes the flow at the provider, provisions or updates the
+// local user and returns a one-time code that ExchangeHandoff turns into tokens.
+// browserState is the state BeginLogin handed to the browser that started the
+// login; a callback carrying any other state is refused, so nobody can finish
+// their own login in someone else's browser.
+func (s *SSOService) HandleCallback(ctx context.Context, code, state, browserState string, client *models.AuditContext) (string, error) {
+	if !s.Enabled() {
+		return "", fmt.Errorf("single sign-on is not configured")
+	}
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return "", fmt.Errorf("SSO login was not started in this browser")
+	}
+
+	now := time.Now()
+	loginID, nonce, verifier, err := s.oidcRepo.ConsumeState(hashToken(state), now)
+	if err != nil {
+		return "", fmt.Errorf("invalid or expired SSO state")
+	}
+
+	rawIDToken, err := s.provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := s.provider.VerifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.provisionUser(claims, client)
+	if err != nil {
+		return "", err
+	}
+
+	handoff, err := oidc.NewRandomString()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.oidcRepo.SetHandoff(loginID, user.ID, hashToken(handoff), now.Add(ssoHandoffTTL)); err != nil {
+		return "", err
+	}
+
+	return handoff, nil
+}
+
+// ExchangeHandoff redeems the code from HandleCallback for our own tokens.
+// The provider's own second factor does not count: lockouts apply as for a
+// password login, and users with two-factor authentication, or whose role
+// requires it, receive an MFA token and finish with LoginMFA.
+func (s *SSOService) ExchangeHandoff(handoff string, client *models.AuditContext) (*models.LoginResponse, error) {
+	userID, err := s.oidcRepo.ConsumeHandoff(hashToken(handoff), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired SSO code")
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user.Status != "ACTIVE" {
+		return nil, fmt.Errorf("user is not active")
+	}
+
+	attempt := &models.LoginHistory{
+		Username:  user.Username,
+		UserID:    &user.ID,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		RequestID: client.RequestID,
+	}
+
+	userKey := "user:" + user.Username
+	if err := s.authService.checkLocked(attempt, userKey, "ip:"+client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.authService.mfaChallenge(user)
+	if err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	return s.authService.completeLogin(user, attempt, userKey)
+}
+
+func (s *SSOService) provisionUser(claims map[string]interface{}, audit *models.AuditContext) (*models.User, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("ID token has no subject")
+	}
+
+	role, err := s.resolveRole(claimStrings(claims[config.AppConfig.OIDCGroupsClaim]))
+	if err != nil {
+		return nil, err
+	}
+
+	fullName := firstClaim(claims, "name", "preferred_username", "email")
+	if fullName == "" {
+		fullName = subject
+	}
+
+	email := truncate(firstClaim(claims, "email"), 255)
+
+	issuer := s.provider.Issuer()
+	userID, err := s.oidcRepo.FindUserID(issuer, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != 0 {
+		user, err := s.userRepo.FindByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.Status != "ACTIVE" {
+			return nil, fmt.Errorf("user is not active")
+		}
+
+		if err := s.oidcRepo.UpdateProfile(user.ID, truncate(fullName, 100), email, role, audit); err != nil {
+			return nil, err
+		}
+		user.FullName = truncate(fullName, 100)
+		user.Email = email
+		user.Role = role
+		return user, nil
+	}
+
+	username := truncate(firstClaim(claims, "preferred_username", "email"), 50)
+	if username == "" {
+		username = truncate(subject, 50)
+	}
+
+	// Never attach an SSO identity to an existing local account by name;
+	// that would let whoever controls the IdP name take the account over
+	if existing, err := s.userRepo.FindByUsername(username); err == nil && existing != nil {
+		return nil, fmt.Errorf("username %s is already used by a local account", username)
+	}
+
+	// SSO users have no usable local password
+	unusable, err := oidc.NewRandomString()
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := HashPassword(unusable)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     username,
+		PasswordHash: passwordHash,
+		FullName:     truncate(fullName, 100),
+		Email:        email,
+		Role:         role,
+		Status:       "ACTIVE",
+	}
+	if err := s.oidcRepo.CreateUserWithIdentity(user, issuer, subject, audit); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.FindByID(user.ID)
+}
+
+// resolveRole picks the role of the first configured group the user is in
+func (s *SSOService) resolveRole(groups []string) (string, error) {
+	member := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		member[g] = true
+	}
+
+	role := config.AppConfig.OIDCDefaultRole
+	for _, mapping := range config.AppConfig.OIDCGroupRoles {
+		if member[mapping.Group] {
+			role = mapping.Role
+			break
+		}
+	}
+
+	if role == "" {
+		return "", fmt.Errorf("you are not in any group with access to this application")
+	}
+
+	existing, err := s.roleRepo.FindByCode(role)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil {
+		return "", fmt.Errorf("mapped role %s does not exist", role)
+	}
+
+	return role, nil
+}
+
+// claimStrings accepts a claim given either as a list or a single string
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func firstClaim(claims map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if v, ok := claims[name].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
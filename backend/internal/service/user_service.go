@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+
+	"pos-backoffice/internal/models"
+	"pos-backoffice/internal/repository"
+)
+
+type UserService struct {
+	userRepo    repository.UserRepository
+	sessionRepo *repository.SessionRepository
+	loginRepo   *repository.LoginRepository
+	roleRepo    *repository.RoleRepository
+}
+
+func NewUserService(userRepo repository.UserRepository, sessionRepo *repository.SessionRepository, loginRepo *repository.LoginRepository, roleRepo *repository.RoleRepository) *UserService {
+	return &UserService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		loginRepo:   loginRepo,
+		roleRepo:    roleRepo,
+	}
+}
+
+// UpdateStatus activates or deactivates a user. Deactivation revokes all of
+// the user's sessions so outstanding tokens stop working immediately.
+func (s *UserService) UpdateStatus(id int64, req *models.UpdateUserStatusRequest, actorID int64, audit *models.AuditContext) (*models.User, error) {
+	if id == actorID && req.Status == "INACTIVE" {
+		return nil, fmt.Errorf("you cannot deactivate your own account")
+	}
+
+	if err := s.userRepo.UpdateStatus(id, req.Status, audit); err != nil {
+		return nil, err
+	}
+
+	if req.Status == "INACTIVE" {
+		if err := s.sessionRepo.RevokeUserSessions(id, "USER_DEACTIVATED"); err != nil {
+			return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	return s.userRepo.FindByID(id)
+}
+
+// UpdateRole assigns a role to a user. The actor may only assign a role whose
+// permissions it holds itself. The user's sessions are revoked so the new
+// permissions apply immediately rather than at the next token refresh.
+func (s *UserService) UpdateRole(id int64, req *models.UpdateUserRoleRequest, actorID int64, actorPermissions []string, audit *models.AuditContext) (*models.User, error) {
+	if id == actorID {
+		return nil, fmt.Errorf("you cannot change your own role")
+	}
+
+	role, err := s.roleRepo.FindByCode(req.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %s does not exist", req.Role)
+	}
+
+	granted, err := s.roleRepo.FindPermissionsByRole(role.Code)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[string]bool, len(actorPermissions))
+	for _, p := range actorPermissions {
+		held[p] = true
+	}
+	for _, code := range granted {
+		if !held[code] {
+			return nil, fmt.Errorf("cannot assign role %s: it grants permission you do not hold: %s", role.Code, code)
+		}
+	}
+
+	if err := s.userRepo.UpdateRole(id, role.Code, audit); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.RevokeUserSessions(id, "ROLE_CHANGED"); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return s.userRepo.FindByID(id)
+}
+
+// GetStores returns the IDs of the stores a user is assigned to
+func (s *UserService) GetStores(id int64) ([]int64, error) {
+	if _, err := s.userRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindStoreIDs(id)
+}
+
+// UpdateStores replaces a user's store assignments. Store IDs travel in the
+// access token, so the user's sessions are revoked to apply the change.
+func (s *UserService) UpdateStores(id int64, req *models.UpdateUserStoresRequest, audit *models.AuditContext) ([]int64, error) {
+	if _, err := s.userRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.ReplaceStores(id, req.StoreIDs, audit); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.RevokeUserSessions(id, "STORES_CHANGED"); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return s.userRepo.FindStoreIDs(id)
+}
+
+// Unlock clears failed login attempts and any lockout on a user's account
+func (s *UserService) Unlock(id int64, audit *models.AuditContext) error {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.loginRepo.Unlock(user.ID, "user:"+user.Username, audit)
+}
+
+// GetLoginHistory returns recorded login attempts
+func (s *UserService) GetLoginHistory(filter *models.LoginHistoryFilter) (*models.LoginHistoryListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	history, total, err := s.loginRepo.FindHistory(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+
+	return &models.LoginHistoryListResponse{
+		History: history,
+		Total:   total,
+		Page:    filter.Page,
+		Limit:   filter.Limit,
+	}, nil
+}
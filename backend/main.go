@@ -2,19 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"pos-backoffice/internal/config"
 	"pos-backoffice/internal/database"
 	"pos-backoffice/internal/handler"
 	"pos-backoffice/internal/middleware"
+	"pos-backoffice/internal/models"
 	"pos-backoffice/internal/repository"
 	"pos-backoffice/internal/service"
+	"pos-backoffice/pkg/notify"
+	"pos-backoffice/pkg/oidc"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,25 +38,111 @@ func main() {
 	}
 	defer database.CloseDB()
 
+	// "migrate up | down [N] | status" manages the schema instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// Refuse to serve against a schema with pending migrations
+	if err := database.CheckSchema(); err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
+
 	// Initialize repositories
 	db := database.GetDB()
 	userRepo := repository.NewUserRepository(db)
 	productRepo := repository.NewProductRepository(db)
 	storeRepo := repository.NewStoreRepository(db)
 	transactionRepo := repository.NewTransactionRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	loginRepo := repository.NewLoginRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	oidcRepo := repository.NewOIDCRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	impersonationRepo := repository.NewImpersonationRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	reconRepo := repository.NewReconciliationRepository(db)
+	periodRepo := repository.NewPeriodRepository(db)
+	glExportRepo := repository.NewGLExportRepository(db)
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo)
+	signingKeyService := service.NewSigningKeyService(signingKeyRepo)
+	if err := signingKeyService.Init(); err != nil {
+		log.Fatalf("Failed to initialize signing keys: %v", err)
+	}
+	authService := service.NewAuthService(userRepo, sessionRepo, loginRepo, roleRepo, mfaRepo)
+	userService := service.NewUserService(userRepo, sessionRepo, loginRepo, roleRepo)
+	roleService := service.NewRoleService(roleRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, roleRepo)
+	mfaService := service.NewMFAService(userRepo, mfaRepo, roleRepo)
+	impersonationService := service.NewImpersonationService(userRepo, sessionRepo, roleRepo, impersonationRepo)
+
+	var oidcProvider *oidc.Provider
+	if cfg := config.AppConfig; cfg.OIDCIssuer != "" {
+		oidcProvider = oidc.NewProvider(cfg.OIDCIssuer, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCRedirectURL, cfg.OIDCScopes)
+	}
+	ssoService := service.NewSSOService(oidcProvider, oidcRepo, userRepo, roleRepo, authService)
+
+	var notifier notify.Notifier = notify.NewLogNotifier()
+	if cfg := config.AppConfig; cfg.Notifier == "smtp" {
+		smtpNotifier, err := notify.NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		if err != nil {
+			log.Fatalf("Failed to configure notifier: %v", err)
+		}
+		notifier = smtpNotifier
+	}
+	passwordService := service.NewPasswordService(userRepo, passwordResetRepo, sessionRepo, loginRepo, oidcRepo, notifier)
 	productService := service.NewProductService(productRepo)
+	auditService := service.NewAuditService(auditRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo)
+	reportService := service.NewReportService(reportRepo)
+	inventoryService := service.NewInventoryService(inventoryRepo)
+	reconService := service.NewReconciliationService(reconRepo, userRepo)
+	periodService := service.NewPeriodService(periodRepo)
+	glExportService := service.NewGLExportService(glExportRepo, periodRepo)
+
+	// Periodic stock snapshots keep as-of reports fast; periodic
+	// reconciliation catches stock that has drifted from the ledger
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if interval := config.AppConfig.InventorySnapshotInterval; interval > 0 {
+		go inventoryService.RunSnapshots(jobs, interval)
+	}
+	if interval := config.AppConfig.ReconcileInterval; interval > 0 {
+		go reconService.RunReconciliations(jobs, interval, config.AppConfig.ReconcileFixAs)
+	}
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	signingKeyHandler := handler.NewSigningKeyHandler(signingKeyService)
+	ssoHandler := handler.NewSSOHandler(ssoService)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	userHandler := handler.NewUserHandler(userService)
+	impersonationHandler := handler.NewImpersonationHandler(impersonationService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 	productHandler := handler.NewProductHandler(productService)
 	storeHandler := handler.NewStoreHandler(storeRepo)
 	transactionHandler := handler.NewTransactionHandler(transactionRepo, productRepo)
+	auditHandler := handler.NewAuditHandler(auditService)
+	reportHandler := handler.NewReportHandler(reportService)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService)
+	reconHandler := handler.NewReconciliationHandler(reconService)
+	periodHandler := handler.NewPeriodHandler(periodService)
+	glExportHandler := handler.NewGLExportHandler(glExportService)
 
 	// Setup Gin router
-	router := setupRouter(authHandler, productHandler, storeHandler, transactionHandler)
+	router := setupRouter(sessionRepo, apiKeyService, impersonationService, idempotencyService, authHandler, mfaHandler, ssoHandler, passwordHandler, signingKeyHandler, userHandler, impersonationHandler, roleHandler, apiKeyHandler, productHandler, storeHandler, transactionHandler, auditHandler, reportHandler, inventoryHandler, reconHandler, periodHandler, glExportHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -85,7 +178,7 @@ func main() {
 	log.Println("Server exited")
 }
 
-func setupRouter(authHandler *handler.AuthHandler, productHandler *handler.ProductHandler, storeHandler *handler.StoreHandler, transactionHandler *handler.TransactionHandler) *gin.Engine {
+func setupRouter(revocations middleware.RevocationChecker, apiKeyService *service.APIKeyService, impersonationService *service.ImpersonationService, idempotencyService *service.IdempotencyService, authHandler *handler.AuthHandler, mfaHandler *handler.MFAHandler, ssoHandler *handler.SSOHandler, passwordHandler *handler.PasswordHandler, signingKeyHandler *handler.SigningKeyHandler, userHandler *handler.UserHandler, impersonationHandler *handler.ImpersonationHandler, roleHandler *handler.RoleHandler, apiKeyHandler *handler.APIKeyHandler, productHandler *handler.ProductHandler, storeHandler *handler.StoreHandler, transactionHandler *handler.TransactionHandler, auditHandler *handler.AuditHandler, reportHandler *handler.ReportHandler, inventoryHandler *handler.InventoryHandler, reconHandler *handler.ReconciliationHandler, periodHandler *handler.PeriodHandler, glExportHandler *handler.GLExportHandler) *gin.Engine {
 	// Set Gin mode
 	if os.Getenv("GIN_MODE") != "debug" {
 		gin.SetMode(gin.ReleaseMode)
@@ -96,6 +189,9 @@ func setupRouter(authHandler *handler.AuthHandler, productHandler *handler.Produ
 	// Apply CORS middleware
 	router.Use(middleware.CORSMiddleware())
 
+	// Tag every request with an ID that appears in the audit log
+	router.Use(middleware.RequestID())
+
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -104,6 +200,9 @@ func setupRouter(authHandler *handler.AuthHandler, productHandler *handler.Produ
 		})
 	})
 
+	// Public keys for validating access tokens
+	router.GET("/.well-known/jwks.json", signingKeyHandler.JWKS)
+
 	// API routes
 	api := router.Group("/api")
 	{
@@ -111,25 +210,110 @@ func setupRouter(authHandler *handler.AuthHandler, productHandler *handler.Produ
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/mfa", authHandler.LoginMFA)
+			auth.POST("/login/mfa/enroll", mfaHandler.EnrollWithToken)
+			auth.GET("/oidc/login", ssoHandler.Login)
+			auth.GET("/oidc/callback", ssoHandler.Callback)
+			auth.POST("/oidc/exchange", ssoHandler.Exchange)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/forgot-password", passwordHandler.ForgotPassword)
+			auth.POST("/reset-password", passwordHandler.ResetPassword)
 		}
 
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(revocations, apiKeyService))
+		protected.Use(middleware.RecordImpersonation(impersonationService))
+
+		// Lets clients retry a POST with an Idempotency-Key without repeating
+		// it. Not used where the response holds a secret that must not be stored.
+		idempotent := middleware.Idempotency(idempotencyService)
 		{
+			// Endpoints acting on the signed-in user's own session and account
+			account := protected.Group("/auth")
+			account.Use(middleware.RequirePrincipal(models.PrincipalUser))
+			{
+				account.POST("/logout", authHandler.Logout)
+
+				// An impersonating admin must not change the user's credentials
+				credentials := account.Group("")
+				credentials.Use(middleware.RejectImpersonation())
+				{
+					credentials.POST("/change-password", passwordHandler.ChangePassword)
+					credentials.GET("/mfa", mfaHandler.GetStatus)
+					credentials.POST("/mfa/enroll", mfaHandler.Enroll)
+					credentials.POST("/mfa/verify", mfaHandler.Verify)
+					credentials.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+					credentials.POST("/mfa/disable", mfaHandler.Disable)
+				}
+			}
+
+			// User routes
+			users := protected.Group("/users")
+			users.Use(middleware.RequirePermission(models.PermUserManage))
+			{
+				users.GET("/login-history", userHandler.GetLoginHistory)
+				users.PUT("/:id/status", userHandler.UpdateUserStatus)
+				users.PUT("/:id/role", userHandler.UpdateUserRole)
+				users.GET("/:id/stores", userHandler.GetUserStores)
+				users.PUT("/:id/stores", userHandler.UpdateUserStores)
+				users.POST("/:id/unlock", userHandler.UnlockUser)
+				users.DELETE("/:id/mfa", mfaHandler.ResetUserMFA)
+				users.GET("/impersonation-log", impersonationHandler.GetActions)
+				users.POST("/:id/impersonate",
+					middleware.RequirePrincipal(models.PrincipalUser),
+					middleware.RequirePermission(models.PermUserImpersonate),
+					middleware.RejectImpersonation(),
+					impersonationHandler.Impersonate,
+				)
+			}
+
+			// Role routes
+			protected.GET("/permissions", middleware.RequirePermission(models.PermRoleManage), roleHandler.GetPermissions)
+			roles := protected.Group("/roles")
+			roles.Use(middleware.RequirePermission(models.PermRoleManage), idempotent)
+			{
+				roles.GET("", roleHandler.GetRoles)
+				roles.GET("/:id", roleHandler.GetRole)
+				roles.POST("", roleHandler.CreateRole)
+				roles.PUT("/:id", roleHandler.UpdateRole)
+				roles.DELETE("/:id", roleHandler.DeleteRole)
+			}
+
+			// API key routes
+			apiKeys := protected.Group("/api-keys")
+			apiKeys.Use(middleware.RequirePermission(models.PermAPIKeyManage))
+			{
+				apiKeys.GET("", apiKeyHandler.GetAPIKeys)
+				apiKeys.POST("", middleware.RejectImpersonation(), apiKeyHandler.CreateAPIKey)
+				apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+			}
+
+			// Signing key routes
+			signingKeys := protected.Group("/signing-keys")
+			signingKeys.Use(middleware.RequirePermission(models.PermSigningKeyManage))
+			{
+				signingKeys.GET("", signingKeyHandler.GetSigningKeys)
+				signingKeys.POST("/rotate", signingKeyHandler.RotateSigningKey)
+			}
+
+			// Audit log routes
+			protected.GET("/audit", middleware.RequirePermission(models.PermAuditView), auditHandler.GetAuditLog)
+
 			// Product routes
 			products := protected.Group("/products")
 			{
 				products.GET("", productHandler.GetProducts)
 				products.GET("/:id", productHandler.GetProduct)
+				products.GET("/:id/revisions", productHandler.GetProductRevisions)
 
-				// Admin only routes
-				adminProducts := products.Group("")
-				adminProducts.Use(middleware.RequireRole("ADMIN"))
+				writeProducts := products.Group("")
+				writeProducts.Use(middleware.RequirePermission(models.PermProductWrite), idempotent)
 				{
-					adminProducts.POST("", productHandler.CreateProduct)
-					adminProducts.PUT("/:id", productHandler.UpdateProduct)
-					adminProducts.DELETE("/:id", productHandler.DeleteProduct)
+					writeProducts.POST("", productHandler.CreateProduct)
+					writeProducts.PUT("/:id", productHandler.UpdateProduct)
+					writeProducts.DELETE("/:id", productHandler.DeleteProduct)
+					writeProducts.POST("/:id/revisions/:rev/revert", productHandler.RevertProduct)
 				}
 			}
 
@@ -139,13 +323,12 @@ func setupRouter(authHandler *handler.AuthHandler, productHandler *handler.Produ
 				stores.GET("", storeHandler.GetStores)
 				stores.GET("/:id", storeHandler.GetStore)
 
-				// Admin only routes
-				adminStores := stores.Group("")
-				adminStores.Use(middleware.RequireRole("ADMIN"))
+				writeStores := stores.Group("")
+				writeStores.Use(middleware.RequirePermission(models.PermStoreWrite), idempotent)
 				{
-					adminStores.POST("", storeHandler.CreateStore)
-					adminStores.PUT("/:id", storeHandler.UpdateStore)
-					adminStores.DELETE("/:id", storeHandler.DeleteStore)
+					writeStores.POST("", storeHandler.CreateStore)
+					writeStores.PUT("/:id", storeHandler.UpdateStore)
+					writeStores.DELETE("/:id", storeHandler.DeleteStore)
 				}
 			}
 
@@ -155,10 +338,98 @@ func setupRouter(authHandler *handler.AuthHandler, productHandler *handler.Produ
 				transactions.GET("", transactionHandler.GetTransactions)
 				transactions.GET("/product/:product_id", transactionHandler.GetTransactionsByProduct)
 				transactions.GET("/store/:store_id", transactionHandler.GetTransactionsByStore)
-				transactions.POST("", transactionHandler.CreateTransaction)
+				transactions.POST("", middleware.RequirePermission(models.PermStockAdjust), idempotent, transactionHandler.CreateTransaction)
+			}
+
+			// Report routes (aggregated in the database)
+			reports := protected.Group("/reports")
+			{
+				reports.GET("/movements", reportHandler.GetMovementReport)
+			}
+
+			// Inventory routes
+			inventory := protected.Group("/inventory")
+			{
+				inventory.GET("/as-of", inventoryHandler.GetStockAsOf)
+
+				reconciliations := inventory.Group("/reconciliations")
+				reconciliations.Use(middleware.RequirePermission(models.PermInventoryReconcile))
+				{
+					reconciliations.GET("", reconHandler.GetReconciliations)
+					reconciliations.GET("/:id", reconHandler.GetReconciliation)
+					reconciliations.POST("", idempotent, reconHandler.Reconcile)
+				}
+			}
+
+			// Accounting period routes; closing and reopening are for admins
+			periods := protected.Group("/accounting-periods")
+			{
+				periods.GET("", periodHandler.GetPeriods)
+				periods.GET("/:period", periodHandler.GetPeriod)
+				periods.POST("/:period/close", middleware.RequirePermission(models.PermPeriodManage), periodHandler.ClosePeriod)
+				periods.POST("/:period/reopen", middleware.RequirePermission(models.PermPeriodManage), periodHandler.ReopenPeriod)
+			}
+
+			// General-ledger export routes
+			glExports := protected.Group("/gl-exports")
+			glExports.Use(middleware.RequirePermission(models.PermGLExport))
+			{
+				glExports.GET("", glExportHandler.GetManifest)
+				glExports.GET("/:period", glExportHandler.GetExport)
+				glExports.POST("/:period", glExportHandler.Export)
 			}
 		}
 	}
 
 	return router
 }
+
+// runMigrate runs the migrate subcommand against the configured database
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [N] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp()
+		if err == nil && len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations to revert: %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := database.MigrateDown(steps)
+		if err == nil && len(reverted) == 0 {
+			log.Println("No migrations to revert")
+		}
+		return err
+	case "status":
+		states, err := database.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			if !s.Known {
+				applied += " (unknown to this binary)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q; use up, down [N] or status", args[0])
+	}
+}
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -10,46 +12,139 @@ import (
 )
 
 type Claims struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID      int64    `json:"user_id"`
+	Username    string   `json:"username"`
+	Role        string   `json:"role"`
+	SessionID   string   `json:"sid"`
+	Permissions []string `json:"permissions"`
+	StoreIDs    []int64  `json:"store_ids"`
+
+	// Set on impersonation tokens: the admin acting as UserID
+	ImpersonatorID       int64  `json:"impersonator_id,omitempty"`
+	ImpersonatorUsername string `json:"impersonator_username,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT token for a user
-func GenerateToken(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateToken generates a short-lived access token for a user session.
+// The role's permissions and assigned stores are embedded so requests need no
+// lookup; changes to a role therefore apply once the token is refreshed.
+// The returned time is the token's expiry.
+func GenerateToken(user *models.User, sessionID string, permissions []string, storeIDs []int64) (string, time.Time, error) {
+	claims := &Claims{
+		UserID:      user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		SessionID:   sessionID,
+		Permissions: permissions,
+		StoreIDs:    storeIDs,
+	}
+	return generateAccessToken(claims, config.AppConfig.AccessTokenTTL)
+}
 
+// GenerateImpersonationToken issues an access token that acts as user with the
+// user's permissions and stores, while naming the impersonator. It lasts for
+// IMPERSONATION_TTL.
+func GenerateImpersonationToken(user, impersonator *models.User, sessionID string, permissions []string, storeIDs []int64) (string, time.Time, error) {
 	claims := &Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "pos-backoffice",
-		},
+		UserID:               user.ID,
+		Username:             user.Username,
+		Role:                 user.Role,
+		SessionID:            sessionID,
+		Permissions:          permissions,
+		StoreIDs:             storeIDs,
+		ImpersonatorID:       impersonator.ID,
+		ImpersonatorUsername: impersonator.Username,
 	}
+	return generateAccessToken(claims, config.AppConfig.ImpersonationTTL)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
+func generateAccessToken(claims *Claims, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(ttl)
+
+	tokenID, err := NewTokenID()
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", time.Time{}, err
+	}
+
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        tokenID,
+		ExpiresAt: jwt.NewNumericDate(expirationTime),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    "pos-backoffice",
 	}
 
-	return tokenString, nil
+	tokenString, err := signToken(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, expirationTime, nil
 }
 
 // ValidateToken validates a JWT token and returns the claims
 func ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey, jwt.WithValidMethods(validMethods))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	// MFA tokens are signed with the same key but must not grant access
+	for _, aud := range claims.Audience {
+		if aud == mfaAudience {
+			return nil, fmt.Errorf("invalid token")
 		}
-		return []byte(config.AppConfig.JWTSecret), nil
-	})
+	}
+
+	return claims, nil
+}
+
+// mfaAudience marks tokens that only prove the password step of a login
+const mfaAudience = "mfa"
+
+// MFAClaims identify a user who has passed the password step and still has
+// to present a second factor
+type MFAClaims struct {
+	UserID int64 `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateMFAToken issues a short-lived token for completing a two-step login
+func GenerateMFAToken(userID int64) (string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(config.AppConfig.MFATokenTTL)
+
+	claims := &MFAClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "pos-backoffice",
+		},
+	}
+
+	tokenString, err := signToken(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, expirationTime, nil
+}
+
+// ValidateMFAToken validates a token issued by GenerateMFAToken
+func ValidateMFAToken(tokenString string) (*MFAClaims, error) {
+	claims := &MFAClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey, jwt.WithValidMethods(validMethods), jwt.WithAudience(mfaAudience))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -61,3 +156,12 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// NewTokenID returns a random 128-bit hex identifier used for token IDs and sessions
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,281 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"pos-backoffice/internal/config"
+	"pos-backoffice/internal/models"
+)
+
+const (
+	// keyReloadInterval bounds how long a rotation on another instance goes unnoticed
+	keyReloadInterval = 5 * time.Minute
+	// unknownKIDReloadInterval rate-limits reloads triggered by unknown kids
+	unknownKIDReloadInterval = 30 * time.Second
+)
+
+// KeyLoader returns the active signing key and any retired keys still within
+// their grace period
+type KeyLoader func() ([]models.SigningKey, error)
+
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	retiredAt *time.Time
+}
+
+type keyring struct {
+	mu       sync.RWMutex
+	loader   KeyLoader
+	active   *signingKey
+	keys     map[string]*signingKey
+	loadedAt time.Time
+}
+
+// ring is nil while tokens are signed with the HS256 shared secret
+var ring *keyring
+
+// InitKeys switches token signing to the asymmetric keys returned by loader.
+// Tokens signed with JWT_SECRET keep validating until JWT_HS256_UNTIL.
+func InitKeys(loader KeyLoader) error {
+	r := &keyring{loader: loader}
+	if err := r.reload(); err != nil {
+		return err
+	}
+	if r.active == nil {
+		return fmt.Errorf("no active signing key")
+	}
+
+	ring = r
+	return nil
+}
+
+// ReloadKeys re-reads the key set, e.g. straight after a rotation
+func ReloadKeys() error {
+	if ring == nil {
+		return nil
+	}
+	return ring.reload()
+}
+
+func (r *keyring) reload() error {
+	stored, err := r.loader()
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	keys := make(map[string]*signingKey, len(stored))
+	var active *signingKey
+	for _, k := range stored {
+		parsed, err := parseSigningKey(k)
+		if err != nil {
+			return err
+		}
+		keys[k.KID] = parsed
+		if k.RetiredAt == nil {
+			active = parsed
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = keys
+	if active != nil {
+		r.active = active
+	}
+	r.loadedAt = time.Now()
+	return nil
+}
+
+func (r *keyring) signer() *signingKey {
+	if r.stale(keyReloadInterval) {
+		// Keep signing with the cached key if the database is briefly unavailable
+		_ = r.reload()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// lookup finds a key by kid, reloading once if it is unknown so that keys
+// created by a rotation on another instance are picked up
+func (r *keyring) lookup(kid string) (*signingKey, error) {
+	if r.stale(keyReloadInterval) {
+		_ = r.reload()
+	}
+
+	r.mu.RLock()
+	key, ok := r.keys[kid]
+	r.mu.RUnlock()
+
+	if !ok && r.stale(unknownKIDReloadInterval) {
+		if err := r.reload(); err != nil {
+			return nil, err
+		}
+		r.mu.RLock()
+		key, ok = r.keys[kid]
+		r.mu.RUnlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if key.retiredAt != nil && time.Since(*key.retiredAt) > config.AppConfig.JWTKeyGracePeriod {
+		return nil, fmt.Errorf("signing key %q has been retired", kid)
+	}
+
+	return key, nil
+}
+
+func (r *keyring) stale(maxAge time.Duration) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return time.Since(r.loadedAt) > maxAge
+}
+
+// signToken signs claims with the active key, or with JWT_SECRET when no
+// asymmetric keys are configured
+func signToken(claims jwt.Claims) (string, error) {
+	if ring == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(config.AppConfig.JWTSecret))
+	}
+
+	key := ring.signer()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// verificationKey resolves the key for a token from its alg and kid headers
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if config.AppConfig.JWTSecret == "" {
+			return nil, fmt.Errorf("HS256 tokens are not accepted")
+		}
+		// Once signing has moved to asymmetric keys the shared secret is only
+		// honoured for the transition window, so a leaked secret stops working
+		if ring != nil && !time.Now().Before(config.AppConfig.JWTHS256Until) {
+			return nil, fmt.Errorf("HS256 tokens are no longer accepted")
+		}
+		return []byte(config.AppConfig.JWTSecret), nil
+
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if ring == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, err := ring.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("signing key %q does not use %s", kid, token.Method.Alg())
+		}
+		return key.private.Public(), nil
+
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+}
+
+// validMethods are the only algorithms accepted when parsing tokens
+var validMethods = []string{"HS256", "RS256", "ES256"}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KID string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeys returns the JWKS of every key that currently validates tokens
+func PublicKeys() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if ring == nil {
+		return set
+	}
+
+	if ring.stale(keyReloadInterval) {
+		_ = ring.reload()
+	}
+
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	for kid, key := range ring.keys {
+		if key.retiredAt != nil && time.Since(*key.retiredAt) > config.AppConfig.JWTKeyGracePeriod {
+			continue
+		}
+
+		jwk := JWK{KID: kid, Alg: key.method.Alg(), Use: "sig"}
+		switch pub := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = pub.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func parseSigningKey(k models.SigningKey) (*signingKey, error) {
+	block, _ := pem.Decode([]byte(k.PrivateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q is not PEM encoded", k.KID)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %q: %w", k.KID, err)
+	}
+
+	key := &signingKey{kid: k.KID, retiredAt: k.RetiredAt}
+	switch priv := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private = jwt.SigningMethodRS256, priv
+	case *ecdsa.PrivateKey:
+		key.method, key.private = jwt.SigningMethodES256, priv
+	default:
+		return nil, fmt.Errorf("signing key %q has unsupported type %T", k.KID, parsed)
+	}
+
+	if key.method.Alg() != k.Algorithm {
+		return nil, fmt.Errorf("signing key %q is not an %s key", k.KID, k.Algorithm)
+	}
+
+	return key, nil
+}
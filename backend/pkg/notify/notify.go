@@ -0,0 +1,103 @@
+// Package notify delivers messages to users, such as password reset links.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text notification addressed to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier sends messages to users. Implementations must be safe for
+// concurrent use.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogNotifier writes messages to the server log instead of delivering them.
+// It is meant for development, where reset links can be copied from the log.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg Message) error {
+	log.Printf("Notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPNotifier sends messages as plain-text email. The connection is upgraded
+// with STARTTLS whenever the server offers it.
+type SMTPNotifier struct {
+	addr     string
+	host     string
+	from     string // From header, may include a display name
+	sender   string // bare address used as the envelope sender
+	username string
+	password string
+}
+
+func NewSMTPNotifier(host, port, username, password, from string) (*SMTPNotifier, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+
+	return &SMTPNotifier{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		from:     addr.String(),
+		sender:   addr.Address,
+		username: username,
+		password: password,
+	}, nil
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid message header")
+	}
+
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	body := strings.ReplaceAll(msg.Body, "\n", "\r\n")
+	data := []byte("From: " + n.from + "\r\n" +
+		"To: " + msg.To + "\r\n" +
+		"Subject: " + msg.Subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body + "\r\n")
+
+	// net/smtp has no context support; run the send so callers are not held
+	// past their deadline by a slow server
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(n.addr, auth, n.sender, []string{msg.To}, data)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
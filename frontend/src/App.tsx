@@ -1,6 +1,8 @@
 import { BrowserRouter, Routes, Route, Navigate } from 'react-router-dom';
 import { AuthProvider } from './context/AuthContext';
 import Login from './pages/Login';
+import SsoCallback from './pages/SsoCallback';
+import ResetPassword from './pages/ResetPassword';
 import Main from './pages/Main';
 import Products from './pages/Products';
 import Stores from './pages/Stores';
@@ -12,6 +14,8 @@ function App() {
             <BrowserRouter>
                 <Routes>
                     <Route path="/login" element={<Login />} />
+                    <Route path="/login/sso" element={<SsoCallback />} />
+                    <Route path="/reset-password" element={<ResetPassword />} />
                     <Route path="/main" element={<Main />} />
                     <Route path="/products" element={<Products />} />
                     <Route path="/stores" element={<Stores />} />
@@ -1,5 +1,10 @@
 import apiClient from "./client";
-import { LoginRequest, LoginResponse, ApiResponse } from "../types";
+import {
+  LoginRequest,
+  LoginResponse,
+  MfaEnrollResponse,
+  ApiResponse,
+} from "../types";
 
 export const authApi = {
   login: async (credentials: LoginRequest): Promise<LoginResponse> => {
@@ -9,4 +14,43 @@ export const authApi = {
     );
     return response.data.data!;
   },
+
+  loginMfa: async (mfaToken: string, code: string): Promise<LoginResponse> => {
+    const response = await apiClient.post<ApiResponse<LoginResponse>>(
+      "/auth/login/mfa",
+      { mfa_token: mfaToken, code },
+    );
+    return response.data.data!;
+  },
+
+  enrollMfa: async (mfaToken: string): Promise<MfaEnrollResponse> => {
+    const response = await apiClient.post<ApiResponse<MfaEnrollResponse>>(
+      "/auth/login/mfa/enroll",
+      { mfa_token: mfaToken },
+    );
+    return response.data.data!;
+  },
+
+  exchangeSsoCode: async (code: string): Promise<LoginResponse> => {
+    const response = await apiClient.post<ApiResponse<LoginResponse>>(
+      "/auth/oidc/exchange",
+      { code },
+    );
+    return response.data.data!;
+  },
+
+  forgotPassword: async (username: string): Promise<void> => {
+    await apiClient.post("/auth/forgot-password", { username });
+  },
+
+  resetPassword: async (token: string, newPassword: string): Promise<void> => {
+    await apiClient.post("/auth/reset-password", {
+      token,
+      new_password: newPassword,
+    });
+  },
+
+  logout: async (): Promise<void> => {
+    await apiClient.post("/auth/logout");
+  },
 };
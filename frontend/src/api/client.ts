@@ -1,4 +1,4 @@
-import axios from "axios";
+import axios, { AxiosError, InternalAxiosRequestConfig } from "axios";
 
 const apiClient = axios.create({
   baseURL: "/api",
@@ -21,15 +21,60 @@ apiClient.interceptors.request.use(
   },
 );
 
+const clearSession = () => {
+  localStorage.removeItem("token");
+  localStorage.removeItem("refreshToken");
+  localStorage.removeItem("user");
+  window.location.href = "/login";
+};
+
+// Share one refresh call between requests that fail at the same time,
+// since the server rotates (and invalidates) the refresh token on every use
+let refreshPromise: Promise<string> | null = null;
+
+const refreshAccessToken = async (): Promise<string> => {
+  const refreshToken = localStorage.getItem("refreshToken");
+  if (!refreshToken) {
+    throw new Error("No refresh token");
+  }
+
+  const response = await axios.post("/api/auth/refresh", {
+    refresh_token: refreshToken,
+  });
+  const { token, refresh_token } = response.data.data;
+  localStorage.setItem("token", token);
+  localStorage.setItem("refreshToken", refresh_token);
+  return token;
+};
+
 // Response interceptor to handle errors
 apiClient.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      // Unauthorized - clear token and redirect to login
-      localStorage.removeItem("token");
-      localStorage.removeItem("user");
-      window.location.href = "/login";
+  async (error: AxiosError) => {
+    const original = error.config as
+      | (InternalAxiosRequestConfig & { _retry?: boolean })
+      | undefined;
+
+    if (
+      error.response?.status === 401 &&
+      original &&
+      !original._retry &&
+      !original.url?.startsWith("/auth/")
+    ) {
+      original._retry = true;
+      try {
+        refreshPromise = refreshPromise ?? refreshAccessToken();
+        const token = await refreshPromise;
+        original.headers.Authorization = `Bearer ${token}`;
+        return apiClient(original);
+      } catch {
+        // Refresh failed - clear tokens and redirect to login
+        clearSession();
+      } finally {
+        refreshPromise = null;
+      }
+    } else if (error.response?.status === 401 && original?.url !== "/auth/login") {
+      clearSession();
     }
     return Promise.reject(error);
   },
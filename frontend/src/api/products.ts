@@ -40,16 +40,20 @@ export const productApi = {
 
   updateProduct: async (
     id: number,
+    version: number,
     product: UpdateProductRequest,
   ): Promise<Product> => {
     const response = await apiClient.put<ApiResponse<Product>>(
       `/products/${id}`,
       product,
+      { headers: { "If-Match": `"${version}"` } },
     );
     return response.data.data!;
   },
 
-  deleteProduct: async (id: number): Promise<void> => {
-    await apiClient.delete(`/products/${id}`);
+  deleteProduct: async (id: number, version: number): Promise<void> => {
+    await apiClient.delete(`/products/${id}`, {
+      headers: { "If-Match": `"${version}"` },
+    });
   },
 };
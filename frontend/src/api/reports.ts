@@ -0,0 +1,60 @@
+import apiClient from "./client";
+import { ApiResponse } from "../types";
+
+export type ReportGroup =
+  | "store"
+  | "product"
+  | "user"
+  | "type"
+  | "day"
+  | "week"
+  | "month";
+
+export interface ReportFilter {
+  group_by?: ReportGroup;
+  from?: string; // RFC 3339 or YYYY-MM-DD
+  to?: string; // RFC 3339 or YYYY-MM-DD; a date includes the whole day
+  store_id?: number;
+  product_id?: number;
+  type?: "INCREASE" | "DECREASE";
+}
+
+// Envelope shared by every report endpoint
+export interface Report<Row, Totals> {
+  name: string;
+  generated_at: string;
+  filter: ReportFilter;
+  rows: Row[];
+  totals: Totals;
+}
+
+// Cost and margin are only present with the report.view_cost permission
+export interface MovementMetrics {
+  transaction_count: number;
+  sales_count: number;
+  sales_quantity: number;
+  sales_amount: number;
+  purchase_count: number;
+  purchase_quantity: number;
+  purchase_amount: number;
+  cost?: number;
+  margin?: number;
+  margin_percent?: number;
+}
+
+export interface MovementReportRow extends MovementMetrics {
+  key: string;
+  label: string;
+}
+
+export type MovementReport = Report<MovementReportRow, MovementMetrics>;
+
+export const reportApi = {
+  getMovementReport: async (filter: ReportFilter): Promise<MovementReport> => {
+    const response = await apiClient.get<ApiResponse<MovementReport>>(
+      "/reports/movements",
+      { params: filter },
+    );
+    return response.data.data!;
+  },
+};
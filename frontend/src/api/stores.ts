@@ -19,6 +19,7 @@ export interface Store {
   updated_at: string;
   created_by: number;
   updated_by: number;
+  version: number;
 }
 
 export interface CreateStoreRequest {
@@ -65,17 +66,21 @@ export const storeApi = {
   },
 
   // Update store
-  updateStore: async (id: number, data: UpdateStoreRequest): Promise<Store> => {
+  updateStore: async (
+    id: number,
+    version: number,
+    data: UpdateStoreRequest,
+  ): Promise<Store> => {
     const response = await axios.put(`${API_URL}/stores/${id}`, data, {
-      headers: getAuthHeader(),
+      headers: { ...getAuthHeader(), "If-Match": `"${version}"` },
     });
     return response.data.data;
   },
 
   // Delete store
-  deleteStore: async (id: number): Promise<void> => {
+  deleteStore: async (id: number, version: number): Promise<void> => {
     await axios.delete(`${API_URL}/stores/${id}`, {
-      headers: getAuthHeader(),
+      headers: { ...getAuthHeader(), "If-Match": `"${version}"` },
     });
   },
 };
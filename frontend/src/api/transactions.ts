@@ -10,7 +10,7 @@ const getAuthHeader = () => {
 
 export interface Transaction {
   id: number;
-  transaction_type: "INCREASE" | "DECREASE";
+  transaction_type: "INCREASE" | "DECREASE" | "ADJUSTMENT" | "OPENING_BALANCE";
   product_id: number;
   product_name?: string;
   store_id?: number | null;
@@ -33,6 +33,19 @@ export interface CreateTransactionRequest {
   notes?: string;
 }
 
+export interface TransactionFilter {
+  from?: string; // RFC 3339 or YYYY-MM-DD
+  to?: string; // RFC 3339 or YYYY-MM-DD; a date includes the whole day
+  type?: "INCREASE" | "DECREASE" | "ADJUSTMENT" | "OPENING_BALANCE";
+  product_id?: number;
+  store_id?: number;
+  user_id?: number;
+  min_amount?: number;
+  max_amount?: number;
+  sort?: "date" | "amount" | "quantity" | "product" | "store" | "type";
+  order?: "asc" | "desc";
+}
+
 export interface TransactionListResponse {
   transactions: Transaction[];
   total: number;
@@ -40,24 +53,48 @@ export interface TransactionListResponse {
   limit: number;
 }
 
+// One page of a cursor-paginated listing; next_cursor is empty on the last page
+export interface TransactionPage {
+  transactions: Transaction[];
+  next_cursor: string;
+  limit: number;
+  total?: number;
+}
+
 export const transactionApi = {
-  // Create transaction (INCREASE or DECREASE)
+  // Create transaction (INCREASE or DECREASE). Resending with the same
+  // idempotency key replays the first response instead of posting it twice.
   createTransaction: async (
     data: CreateTransactionRequest,
+    idempotencyKey: string,
   ): Promise<Transaction> => {
     const response = await axios.post(`${API_URL}/transactions`, data, {
-      headers: getAuthHeader(),
+      headers: { ...getAuthHeader(), "Idempotency-Key": idempotencyKey },
     });
     return response.data.data;
   },
 
-  // Get all transactions with pagination
+  // Get filtered transactions with pagination
   getTransactions: async (
     page: number = 1,
     limit: number = 20,
+    filter: TransactionFilter = {},
   ): Promise<TransactionListResponse> => {
     const response = await axios.get(`${API_URL}/transactions`, {
-      params: { page, limit },
+      params: { ...filter, page, limit },
+      headers: getAuthHeader(),
+    });
+    return response.data.data;
+  },
+
+  // Get filtered transactions newest first by cursor; pass "" for the first page
+  getTransactionPage: async (
+    cursor: string,
+    limit: number = 100,
+    filter: Omit<TransactionFilter, "sort"> = {},
+  ): Promise<TransactionPage> => {
+    const response = await axios.get(`${API_URL}/transactions`, {
+      params: { ...filter, cursor, limit },
       headers: getAuthHeader(),
     });
     return response.data.data;
@@ -66,12 +103,14 @@ export const transactionApi = {
   // Get transactions by product
   getTransactionsByProduct: async (
     productId: number,
+    page: number = 1,
     limit: number = 50,
-  ): Promise<Transaction[]> => {
+    filter: TransactionFilter = {},
+  ): Promise<TransactionListResponse> => {
     const response = await axios.get(
       `${API_URL}/transactions/product/${productId}`,
       {
-        params: { limit },
+        params: { ...filter, page, limit },
         headers: getAuthHeader(),
       },
     );
@@ -81,12 +120,14 @@ export const transactionApi = {
   // Get transactions by store
   getTransactionsByStore: async (
     storeId: number,
+    page: number = 1,
     limit: number = 50,
-  ): Promise<Transaction[]> => {
+    filter: TransactionFilter = {},
+  ): Promise<TransactionListResponse> => {
     const response = await axios.get(
       `${API_URL}/transactions/store/${storeId}`,
       {
-        params: { limit },
+        params: { ...filter, page, limit },
         headers: getAuthHeader(),
       },
     );
@@ -1,11 +1,14 @@
 import React, { createContext, useContext, useState, useEffect, ReactNode } from 'react';
-import { User, LoginRequest } from '../types';
+import { User, LoginRequest, LoginResponse } from '../types';
 import { authApi } from '../api/auth';
 
 interface AuthContextType {
     user: User | null;
     token: string | null;
-    login: (credentials: LoginRequest) => Promise<void>;
+    // Resolves with the response; if mfa_required is set, finish with loginMfa
+    login: (credentials: LoginRequest) => Promise<LoginResponse>;
+    loginMfa: (mfaToken: string, code: string) => Promise<LoginResponse>;
+    loginSso: (code: string) => Promise<void>;
     logout: () => void;
     isAuthenticated: boolean;
     isAdmin: boolean;
@@ -28,18 +31,39 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
         }
     }, []);
 
-    const login = async (credentials: LoginRequest) => {
-        const response = await authApi.login(credentials);
-        setToken(response.token);
+    const storeSession = (response: LoginResponse) => {
+        setToken(response.token!);
         setUser(response.user);
-        localStorage.setItem('token', response.token);
+        localStorage.setItem('token', response.token!);
+        localStorage.setItem('refreshToken', response.refresh_token!);
         localStorage.setItem('user', JSON.stringify(response.user));
     };
 
+    const login = async (credentials: LoginRequest) => {
+        const response = await authApi.login(credentials);
+        if (!response.mfa_required) {
+            storeSession(response);
+        }
+        return response;
+    };
+
+    const loginMfa = async (mfaToken: string, code: string) => {
+        const response = await authApi.loginMfa(mfaToken, code);
+        storeSession(response);
+        return response;
+    };
+
+    const loginSso = async (code: string) => {
+        storeSession(await authApi.exchangeSsoCode(code));
+    };
+
     const logout = () => {
+        // Revoke the session server-side; local state is cleared regardless
+        authApi.logout().catch(() => undefined);
         setToken(null);
         setUser(null);
         localStorage.removeItem('token');
+        localStorage.removeItem('refreshToken');
         localStorage.removeItem('user');
     };
 
@@ -47,6 +71,8 @@ export const AuthProvider: React.FC<{ children: ReactNode }> = ({ children }) =>
         user,
         token,
         login,
+        loginMfa,
+        loginSso,
         logout,
         isAuthenticated: !!token && !!user,
         isAdmin: user?.role === 'ADMIN',
@@ -1,16 +1,22 @@
 import React, { useState } from 'react';
-import { useNavigate, Navigate } from 'react-router-dom';
+import { useNavigate, Navigate, Link } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
+import { authApi } from '../api/auth';
+import { MfaEnrollResponse } from '../types';
 
 const Login: React.FC = () => {
     const [username, setUsername] = useState('');
     const [password, setPassword] = useState('');
     const [error, setError] = useState('');
     const [loading, setLoading] = useState(false);
-    const { login, isAuthenticated } = useAuth();
+    const [mfaToken, setMfaToken] = useState('');
+    const [enrollment, setEnrollment] = useState<MfaEnrollResponse | null>(null);
+    const [code, setCode] = useState('');
+    const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+    const { login, loginMfa, isAuthenticated } = useAuth();
     const navigate = useNavigate();
 
-    if (isAuthenticated) {
+    if (isAuthenticated && recoveryCodes.length === 0) {
         return <Navigate to="/main" replace />;
     }
 
@@ -20,7 +26,14 @@ const Login: React.FC = () => {
         setLoading(true);
 
         try {
-            await login({ username, password });
+            const response = await login({ username, password });
+            if (response.mfa_required) {
+                setMfaToken(response.mfa_token!);
+                if (response.mfa_enrollment_required) {
+                    setEnrollment(await authApi.enrollMfa(response.mfa_token!));
+                }
+                return;
+            }
             navigate('/main');
         } catch (err: any) {
             setError(err.response?.data?.message || 'Invalid username or password');
@@ -29,6 +42,100 @@ const Login: React.FC = () => {
         }
     };
 
+    const handleMfaSubmit = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setError('');
+        setLoading(true);
+
+        try {
+            const response = await loginMfa(mfaToken, code);
+            if (response.recovery_codes?.length) {
+                // Shown once after a required enrollment; keep the user here until acknowledged
+                setRecoveryCodes(response.recovery_codes);
+                return;
+            }
+            navigate('/main');
+        } catch (err: any) {
+            setError(err.response?.data?.message || 'Invalid verification code');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    if (recoveryCodes.length > 0) {
+        return (
+            <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-primary-500 to-primary-700 py-12 px-4 sm:px-6 lg:px-8">
+                <div className="max-w-md w-full space-y-6 bg-white p-10 rounded-xl shadow-2xl">
+                    <h2 className="text-center text-2xl font-extrabold text-gray-900">Save your recovery codes</h2>
+                    <p className="text-sm text-gray-600">
+                        Each code can be used once if you lose access to your authenticator app. They will not be shown again.
+                    </p>
+                    <ul className="grid grid-cols-2 gap-2 font-mono text-sm text-gray-900">
+                        {recoveryCodes.map((c) => (
+                            <li key={c}>{c}</li>
+                        ))}
+                    </ul>
+                    <button
+                        type="button"
+                        onClick={() => navigate('/main')}
+                        className="w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-primary-600 hover:bg-primary-700"
+                    >
+                        Continue
+                    </button>
+                </div>
+            </div>
+        );
+    }
+
+    if (mfaToken) {
+        return (
+            <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-primary-500 to-primary-700 py-12 px-4 sm:px-6 lg:px-8">
+                <div className="max-w-md w-full space-y-6 bg-white p-10 rounded-xl shadow-2xl">
+                    <h2 className="text-center text-2xl font-extrabold text-gray-900">Two-factor authentication</h2>
+                    {enrollment ? (
+                        <div className="text-sm text-gray-600 space-y-2">
+                            <p>Your role requires two-factor authentication. Add this account to your authenticator app, then enter the code it shows.</p>
+                            <p className="font-mono break-all text-gray-900">{enrollment.secret}</p>
+                            <a href={enrollment.otpauth_uri} className="text-primary-600 hover:underline">
+                                Open in authenticator app
+                            </a>
+                        </div>
+                    ) : (
+                        <p className="text-sm text-gray-600">
+                            Enter the code from your authenticator app, or one of your recovery codes.
+                        </p>
+                    )}
+                    <form className="space-y-6" onSubmit={handleMfaSubmit}>
+                        {error && (
+                            <div className="rounded-md bg-red-50 p-4">
+                                <h3 className="text-sm font-medium text-red-800">{error}</h3>
+                            </div>
+                        )}
+                        <input
+                            id="code"
+                            name="code"
+                            type="text"
+                            inputMode="numeric"
+                            autoComplete="one-time-code"
+                            required
+                            className="appearance-none rounded-md relative block w-full px-3 py-2 border border-gray-300 placeholder-gray-500 text-gray-900 focus:outline-none focus:ring-primary-500 focus:border-primary-500 sm:text-sm"
+                            placeholder="Verification code"
+                            value={code}
+                            onChange={(e) => setCode(e.target.value)}
+                        />
+                        <button
+                            type="submit"
+                            disabled={loading}
+                            className="group relative w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-primary-600 hover:bg-primary-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-primary-500 disabled:opacity-50"
+                        >
+                            {loading ? 'Verifying...' : 'Verify'}
+                        </button>
+                    </form>
+                </div>
+            </div>
+        );
+    }
+
     return (
         <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-primary-500 to-primary-700 py-12 px-4 sm:px-6 lg:px-8">
             <div className="max-w-md w-full space-y-8 bg-white p-10 rounded-xl shadow-2xl">
@@ -95,6 +202,19 @@ const Login: React.FC = () => {
                         </button>
                     </div>
 
+                    <div className="text-sm text-right">
+                        <Link to="/reset-password" className="text-primary-600 hover:underline">
+                            Forgot your password?
+                        </Link>
+                    </div>
+
+                    <a
+                        href="/api/auth/oidc/login"
+                        className="w-full flex justify-center py-2 px-4 border border-gray-300 text-sm font-medium rounded-md text-gray-700 bg-white hover:bg-gray-50"
+                    >
+                        Sign in with company account
+                    </a>
+
                     <div className="text-sm text-center text-gray-600">
                         <p>Demo credentials:</p>
                         <p className="font-mono">admin / admin123 (Admin)</p>
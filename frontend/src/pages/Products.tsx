@@ -37,6 +37,7 @@ const Products: React.FC = () => {
     const [stockUnitPrice, setStockUnitPrice] = useState(0);
     const [stockStoreId, setStockStoreId] = useState<number | null>(null);
     const [stockNotes, setStockNotes] = useState('');
+    const [stockIdempotencyKey, setStockIdempotencyKey] = useState('');
     const [stores, setStores] = useState<Store[]>([]);
 
     useEffect(() => {
@@ -103,15 +104,17 @@ const Products: React.FC = () => {
         if (modalMode === 'create') {
             await productApi.createProduct(productData as CreateProductRequest);
         } else if (selectedProduct) {
-            await productApi.updateProduct(selectedProduct.id, productData as UpdateProductRequest);
+            await productApi.updateProduct(selectedProduct.id, selectedProduct.version, productData as UpdateProductRequest);
         }
         loadProducts();
     };
 
     const handleDeleteProduct = async (id: number) => {
+        const product = products.find((p) => p.id === id);
+        if (!product) return;
         if (window.confirm('Are you sure you want to delete this product?')) {
             try {
-                await productApi.deleteProduct(id);
+                await productApi.deleteProduct(id, product.version);
                 loadProducts();
             } catch (err: any) {
                 alert(err.response?.data?.message || 'Failed to delete product');
@@ -126,6 +129,7 @@ const Products: React.FC = () => {
         setStockUnitPrice(type === 'increase' ? product.cost || 0 : product.price || 0);
         setStockStoreId(null);
         setStockNotes('');
+        setStockIdempotencyKey(crypto.randomUUID());
         setStockModalOpen(true);
     };
 
@@ -147,11 +151,16 @@ const Products: React.FC = () => {
                 quantity: stockQuantity,
                 unit_price: stockUnitPrice,
                 notes: stockNotes,
-            });
+            }, stockIdempotencyKey);
 
             setStockModalOpen(false);
             loadProducts();
         } catch (err: any) {
+            // Keep the key unless the server answered and rejected the
+            // request, so that submitting again cannot post the movement twice
+            if (err.response && err.response.status < 500 && err.response.status !== 409) {
+                setStockIdempotencyKey(crypto.randomUUID());
+            }
             alert(err.response?.data?.message || 'Failed to adjust stock');
         }
     };
@@ -1,10 +1,14 @@
 import { useState, useEffect } from 'react';
 import Layout from '../components/Layout';
 import { transactionApi, Transaction } from '../api/transactions';
+import { reportApi, ReportFilter, MovementMetrics, MovementReportRow } from '../api/reports';
 import { storeApi, Store } from '../api/stores';
 
 const Reports = () => {
     const [transactions, setTransactions] = useState<Transaction[]>([]);
+    const [totals, setTotals] = useState<MovementMetrics | null>(null);
+    const [storeRows, setStoreRows] = useState<MovementReportRow[]>([]);
+    const [productRows, setProductRows] = useState<MovementReportRow[]>([]);
     const [stores, setStores] = useState<Store[]>([]);
     const [loading, setLoading] = useState(true);
     const [error, setError] = useState('');
@@ -12,19 +16,55 @@ const Reports = () => {
     const [selectedStore, setSelectedStore] = useState<number | 'all'>('all');
 
     useEffect(() => {
-        loadData();
+        storeApi.getStores()
+            .then(setStores)
+            .catch((err: any) => setError(err.response?.data?.message || 'Failed to load stores'));
     }, []);
 
+    useEffect(() => {
+        loadData();
+    }, [dateRange, selectedStore]);
+
+    // Get date range start as YYYY-MM-DD
+    const getDateFrom = (range: string): string | undefined => {
+        const now = new Date();
+        let start: Date;
+        switch (range) {
+            case 'today':
+                start = new Date(now.getFullYear(), now.getMonth(), now.getDate());
+                break;
+            case 'week':
+                start = new Date(now.getFullYear(), now.getMonth(), now.getDate() - now.getDay()); // Start of week (Sunday)
+                break;
+            case 'month':
+                start = new Date(now.getFullYear(), now.getMonth(), 1);
+                break;
+            default:
+                return undefined; // 'all' - no cutoff
+        }
+        const pad = (n: number) => String(n).padStart(2, '0');
+        return `${start.getFullYear()}-${pad(start.getMonth() + 1)}-${pad(start.getDate())}`;
+    };
+
+    // Figures are aggregated by the server; only the latest rows are listed
     const loadData = async () => {
         setLoading(true);
         setError('');
         try {
-            const [txData, storeData] = await Promise.all([
-                transactionApi.getTransactions(1, 100),
-                storeApi.getStores(),
+            const filter: ReportFilter = {
+                from: getDateFrom(dateRange),
+                store_id: selectedStore === 'all' ? undefined : selectedStore,
+            };
+            const [totalsReport, storeReport, productReport, recent] = await Promise.all([
+                reportApi.getMovementReport(filter),
+                reportApi.getMovementReport({ ...filter, group_by: 'store', type: 'DECREASE' }),
+                reportApi.getMovementReport({ ...filter, group_by: 'product' }),
+                transactionApi.getTransactions(1, 20, filter),
             ]);
-            setTransactions(txData.transactions || []);
-            setStores(storeData);
+            setTotals(totalsReport.totals);
+            setStoreRows(storeReport.rows);
+            setProductRows(productReport.rows);
+            setTransactions(recent.transactions || []);
         } catch (err: any) {
             setError(err.response?.data?.message || 'Failed to load data');
         } finally {
@@ -32,100 +72,30 @@ const Reports = () => {
         }
     };
 
-    // Get date range cutoff
-    const getDateCutoff = (range: string): Date | null => {
-        const now = new Date();
-        switch (range) {
-            case 'today': {
-                const start = new Date(now.getFullYear(), now.getMonth(), now.getDate());
-                return start;
-            }
-            case 'week': {
-                const start = new Date(now);
-                start.setDate(now.getDate() - now.getDay()); // Start of week (Sunday)
-                start.setHours(0, 0, 0, 0);
-                return start;
-            }
-            case 'month': {
-                const start = new Date(now.getFullYear(), now.getMonth(), 1);
-                return start;
-            }
-            default:
-                return null; // 'all' - no cutoff
-        }
-    };
-
-    // Filter transactions
-    const filteredTransactions = transactions.filter(tx => {
-        // Store filter
-        if (selectedStore !== 'all' && tx.store_id !== selectedStore) {
-            return false;
-        }
-
-        // Date range filter
-        const dateCutoff = getDateCutoff(dateRange);
-        if (dateCutoff) {
-            const txDate = new Date(tx.transaction_date);
-            if (txDate < dateCutoff) {
-                return false;
-            }
-        }
-
-        return true;
-    });
-
-    // Calculate summary statistics
-    const totalIncrease = filteredTransactions
-        .filter(tx => tx.transaction_type === 'INCREASE')
-        .reduce((sum, tx) => sum + tx.total_amount, 0);
-    const totalDecrease = filteredTransactions
-        .filter(tx => tx.transaction_type === 'DECREASE')
-        .reduce((sum, tx) => sum + tx.total_amount, 0);
-
     const summary = {
-        totalTransactions: filteredTransactions.length,
-        totalIncrease,
-        totalDecrease,
-        increaseCount: filteredTransactions.filter(tx => tx.transaction_type === 'INCREASE').length,
-        decreaseCount: filteredTransactions.filter(tx => tx.transaction_type === 'DECREASE').length,
-        totalRevenue: totalDecrease - totalIncrease,
+        totalTransactions: totals?.transaction_count ?? 0,
+        totalIncrease: totals?.purchase_amount ?? 0,
+        totalDecrease: totals?.sales_amount ?? 0,
+        increaseCount: totals?.purchase_count ?? 0,
+        decreaseCount: totals?.sales_count ?? 0,
+        totalRevenue: (totals?.sales_amount ?? 0) - (totals?.purchase_amount ?? 0),
     };
 
-    // Group transactions by store
-    const salesByStore = stores.map(store => {
-        const storeTx = filteredTransactions.filter(
-            tx => tx.transaction_type === 'DECREASE' && tx.store_id === store.id
-        );
-        return {
-            store: store.name,
-            transactions: storeTx.length,
-            totalSales: storeTx.reduce((sum, tx) => sum + tx.total_amount, 0),
-        };
-    }).filter(s => s.transactions > 0);
-
-    // Group transactions by product
-    const productStats = filteredTransactions.reduce((acc, tx) => {
-        const key = tx.product_name || `Product ${tx.product_id}`;
-        if (!acc[key]) {
-            acc[key] = {
-                name: key,
-                increase: 0,
-                decrease: 0,
-                revenue: 0,
-            };
-        }
-        if (tx.transaction_type === 'INCREASE') {
-            acc[key].increase += tx.quantity;
-        } else {
-            acc[key].decrease += tx.quantity;
-            acc[key].revenue += tx.total_amount;
-        }
-        return acc;
-    }, {} as Record<string, { name: string; increase: number; decrease: number; revenue: number }>);
+    const salesByStore = storeRows.map(row => ({
+        store: row.label,
+        transactions: row.sales_count,
+        totalSales: row.sales_amount,
+    }));
 
-    const topProducts = Object.values(productStats)
-        .sort((a, b) => b.revenue - a.revenue)
-        .slice(0, 5);
+    const topProducts = [...productRows]
+        .sort((a, b) => b.sales_amount - a.sales_amount)
+        .slice(0, 5)
+        .map(row => ({
+            name: row.label,
+            increase: row.purchase_quantity,
+            decrease: row.sales_quantity,
+            revenue: row.sales_amount,
+        }));
 
     return (
         <Layout>
@@ -343,7 +313,7 @@ const Reports = () => {
                                         </tr>
                                     </thead>
                                     <tbody className="bg-white divide-y divide-gray-200">
-                                        {filteredTransactions.slice(0, 20).map((tx) => (
+                                        {transactions.slice(0, 20).map((tx) => (
                                             <tr key={tx.id} className="hover:bg-gray-50">
                                                 <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900">
                                                     {(() => {
@@ -0,0 +1,118 @@
+import React, { useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { authApi } from '../api/auth';
+
+// Without a token this page requests a reset link; the emailed link
+// (PASSWORD_RESET_URL?token=...) brings the user back here to choose a password
+const ResetPassword: React.FC = () => {
+    const [params] = useSearchParams();
+    const token = params.get('token') || '';
+    const [username, setUsername] = useState('');
+    const [password, setPassword] = useState('');
+    const [confirm, setConfirm] = useState('');
+    const [error, setError] = useState('');
+    const [message, setMessage] = useState('');
+    const [loading, setLoading] = useState(false);
+
+    const handleRequest = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setError('');
+        setLoading(true);
+
+        try {
+            await authApi.forgotPassword(username);
+            setMessage('If the account exists and has an email address, a reset link is on its way.');
+        } catch (err: any) {
+            setError(err.response?.data?.message || 'Failed to request password reset');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const handleReset = async (e: React.FormEvent) => {
+        e.preventDefault();
+        setError('');
+        if (password !== confirm) {
+            setError('Passwords do not match');
+            return;
+        }
+        setLoading(true);
+
+        try {
+            await authApi.resetPassword(token, password);
+            setMessage('Your password has been reset. You can now sign in.');
+        } catch (err: any) {
+            setError(err.response?.data?.message || 'Failed to reset password');
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    const inputClass =
+        'appearance-none rounded-md relative block w-full px-3 py-2 border border-gray-300 placeholder-gray-500 text-gray-900 focus:outline-none focus:ring-primary-500 focus:border-primary-500 sm:text-sm';
+
+    return (
+        <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-primary-500 to-primary-700 py-12 px-4 sm:px-6 lg:px-8">
+            <div className="max-w-md w-full space-y-6 bg-white p-10 rounded-xl shadow-2xl">
+                <h2 className="text-center text-2xl font-extrabold text-gray-900">Reset password</h2>
+                {message ? (
+                    <p className="text-sm text-gray-600">{message}</p>
+                ) : (
+                    <form className="space-y-4" onSubmit={token ? handleReset : handleRequest}>
+                        {error && (
+                            <div className="rounded-md bg-red-50 p-4">
+                                <h3 className="text-sm font-medium text-red-800">{error}</h3>
+                            </div>
+                        )}
+                        {token ? (
+                            <>
+                                <input
+                                    type="password"
+                                    autoComplete="new-password"
+                                    required
+                                    className={inputClass}
+                                    placeholder="New password"
+                                    value={password}
+                                    onChange={(e) => setPassword(e.target.value)}
+                                />
+                                <input
+                                    type="password"
+                                    autoComplete="new-password"
+                                    required
+                                    className={inputClass}
+                                    placeholder="Confirm new password"
+                                    value={confirm}
+                                    onChange={(e) => setConfirm(e.target.value)}
+                                />
+                            </>
+                        ) : (
+                            <input
+                                type="text"
+                                autoComplete="username"
+                                required
+                                className={inputClass}
+                                placeholder="Username"
+                                value={username}
+                                onChange={(e) => setUsername(e.target.value)}
+                            />
+                        )}
+                        <button
+                            type="submit"
+                            disabled={loading}
+                            className="w-full flex justify-center py-2 px-4 border border-transparent text-sm font-medium rounded-md text-white bg-primary-600 hover:bg-primary-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-primary-500 disabled:opacity-50"
+                        >
+                            {loading ? 'Please wait...' : token ? 'Set new password' : 'Send reset link'}
+                        </button>
+                    </form>
+                )}
+                <div className="text-sm text-center">
+                    <Link to="/login" className="text-primary-600 hover:underline">
+                        Back to sign in
+                    </Link>
+                </div>
+            </div>
+        </div>
+    );
+};
+
+export default ResetPassword;
@@ -0,0 +1,45 @@
+import React, { useEffect, useRef, useState } from 'react';
+import { Link, useNavigate, useSearchParams } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+// Landing page for OIDC_FRONTEND_URL: trades the one-time code from the
+// backend's SSO callback for a session
+const SsoCallback: React.FC = () => {
+    const [params] = useSearchParams();
+    const [error, setError] = useState(params.get('error') || '');
+    const { loginSso } = useAuth();
+    const navigate = useNavigate();
+    const started = useRef(false);
+
+    useEffect(() => {
+        const code = params.get('code');
+        // The code is single-use, so guard against StrictMode's double effect
+        if (!code || started.current) {
+            return;
+        }
+        started.current = true;
+
+        loginSso(code)
+            .then(() => navigate('/main', { replace: true }))
+            .catch((err: any) => setError(err.response?.data?.message || 'Single sign-on failed'));
+    }, [params, loginSso, navigate]);
+
+    return (
+        <div className="min-h-screen flex items-center justify-center bg-gradient-to-br from-primary-500 to-primary-700 py-12 px-4 sm:px-6 lg:px-8">
+            <div className="max-w-md w-full space-y-6 bg-white p-10 rounded-xl shadow-2xl text-center">
+                {error ? (
+                    <>
+                        <h3 className="text-sm font-medium text-red-800">{error}</h3>
+                        <Link to="/login" className="text-primary-600 hover:underline">
+                            Back to sign in
+                        </Link>
+                    </>
+                ) : (
+                    <p className="text-gray-600">Signing you in...</p>
+                )}
+            </div>
+        </div>
+    );
+};
+
+export default SsoCallback;
@@ -57,7 +57,7 @@ const Stores = () => {
         try {
             if (editingStore) {
                 // Update existing store
-                await storeApi.updateStore(editingStore.id, formData);
+                await storeApi.updateStore(editingStore.id, editingStore.version, formData);
                 setEditingStore(null);
             } else {
                 // Add new store
@@ -84,10 +84,10 @@ const Stores = () => {
         setShowAddModal(true);
     };
 
-    const handleDelete = async (id: number) => {
+    const handleDelete = async (store: Store) => {
         if (window.confirm('Are you sure you want to delete this store?')) {
             try {
-                await storeApi.deleteStore(id);
+                await storeApi.deleteStore(store.id, store.version);
                 loadStores();
             } catch (err: any) {
                 setError(err.response?.data?.message || 'Failed to delete store');
@@ -237,7 +237,7 @@ const Stores = () => {
                                             Edit
                                         </button>
                                         <button
-                                            onClick={() => handleDelete(store.id)}
+                                            onClick={() => handleDelete(store)}
                                             className="flex-1 bg-red-50 text-red-600 px-3 py-2 rounded-md hover:bg-red-100 transition-colors text-sm font-medium"
                                         >
                                             Delete
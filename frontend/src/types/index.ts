@@ -2,7 +2,8 @@ export interface User {
   id: number;
   username: string;
   full_name: string;
-  role: "ADMIN" | "STAFF";
+  email?: string;
+  role: string; // role code, e.g. "ADMIN" or "STAFF"
   status: string;
   created_at: string;
   updated_at: string;
@@ -14,8 +15,20 @@ export interface LoginRequest {
 }
 
 export interface LoginResponse {
-  token: string;
+  token?: string;
+  expires_at: string;
+  refresh_token?: string;
   user: User;
+  permissions: string[];
+  mfa_required?: boolean;
+  mfa_enrollment_required?: boolean;
+  mfa_token?: string;
+  recovery_codes?: string[];
+}
+
+export interface MfaEnrollResponse {
+  secret: string;
+  otpauth_uri: string;
 }
 
 export interface Product {
@@ -31,6 +44,7 @@ export interface Product {
   updated_at: string;
   created_by: number;
   updated_by: number;
+  version: number;
 }
 
 export interface CreateProductRequest {